@@ -1,33 +1,57 @@
 package cli
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"path/filepath"
 	"runtime/debug"
+	"time"
 
 	ghcli "github.com/cli/go-gh"
 	"github.com/cli/go-gh/pkg/api"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-logr/logr"
 	"github.com/go-logr/stdr"
+	"github.com/google/go-github/v47/github"
 	"github.com/rh-ecosystem-edge/gitstream/internal/config"
 	gh "github.com/rh-ecosystem-edge/gitstream/internal/github"
 	"github.com/rh-ecosystem-edge/gitstream/internal/gitstream"
 	"github.com/rh-ecosystem-edge/gitstream/internal/gitutils"
+	"github.com/rh-ecosystem-edge/gitstream/internal/hooks"
 	"github.com/rh-ecosystem-edge/gitstream/internal/intents"
 	"github.com/rh-ecosystem-edge/gitstream/internal/markup"
+	"github.com/rh-ecosystem-edge/gitstream/internal/metrics"
 	"github.com/rh-ecosystem-edge/gitstream/internal/owners"
+	"github.com/rh-ecosystem-edge/gitstream/internal/plan"
+	"github.com/rh-ecosystem-edge/gitstream/internal/report"
+	"github.com/rh-ecosystem-edge/gitstream/internal/rules"
+	"github.com/rh-ecosystem-edge/gitstream/internal/signing"
+	"github.com/rh-ecosystem-edge/gitstream/internal/skiplist"
 	"github.com/urfave/cli/v2"
+	"golang.org/x/oauth2"
+	"gopkg.in/yaml.v3"
 )
 
 type App struct {
-	Config *config.Config
-	Logger logr.Logger
+	Config  *config.Config
+	Logger  logr.Logger
+	Metrics *metrics.Metrics
 }
 
+const (
+	junitReportFlagName   = "junit-report"
+	reportFlagName        = "report"
+	validateConfigCommand = "validate-config"
+)
+
 func (a *App) GetCLIApp() *cli.App {
-	const logLevelFlagName = "log-level"
+	const (
+		logLevelFlagName = "log-level"
+		setFlagName      = "set"
+	)
 
 	var (
 		configPath string
@@ -57,7 +81,12 @@ func (a *App) GetCLIApp() *cli.App {
 	app.Version = "0.0.1-" + commit
 
 	app.Before = func(c *cli.Context) error {
-		cfg, err := config.ReadConfigFile(configPath)
+		// validate-config reports problems in the configuration instead of failing to read it.
+		if c.Args().First() == validateConfigCommand {
+			return nil
+		}
+
+		cfg, err := config.ReadConfigFile(configPath, c.StringSlice(setFlagName)...)
 		if err != nil {
 			return fmt.Errorf("could not read config: %v", err)
 		}
@@ -72,6 +101,26 @@ func (a *App) GetCLIApp() *cli.App {
 
 		stdr.SetVerbosity(logLevel)
 		a.Logger.Info("Build information", "commit", commit)
+
+		a.Metrics = metrics.New(cfg.Upstream.URL, cfg.Downstream.GitHubRepoName)
+
+		// Count REST API calls made through clients created by gh.NewGitHubClient.
+		httpClient := &http.Client{Transport: a.Metrics.WrapTransport(http.DefaultTransport)}
+		c.Context = context.WithValue(c.Context, oauth2.HTTPClient, httpClient)
+
+		return nil
+	}
+
+	app.After = func(c *cli.Context) error {
+		// The serve command exposes metrics over HTTP instead, and configuration commands do not record any.
+		if cmd := c.Args().First(); a.Config == nil || a.Config.Metrics.Textfile == "" || cmd == "serve" || cmd == "config" || cmd == validateConfigCommand {
+			return nil
+		}
+
+		if err := a.Metrics.WriteTextfile(a.Config.Metrics.Textfile); err != nil {
+			return fmt.Errorf("could not write the metrics textfile: %v", err)
+		}
+
 		return nil
 	}
 
@@ -82,11 +131,40 @@ func (a *App) GetCLIApp() *cli.App {
 			Destination: &configPath,
 		},
 		&cli.IntFlag{Name: "log-level"},
+		&cli.StringSliceFlag{
+			Name:  setFlagName,
+			Usage: "override a configuration value, in path=value form (e.g. downstream.max_open_items=5); can be repeated",
+		},
 	}
 
+	// Values passed to --set may contain commas, for example in YAML lists.
+	app.DisableSliceFlagSeparator = true
+
 	app.Usage = "Synchronization tool between an upstream and a downstream repository on GitHub"
 
 	app.Commands = []*cli.Command{
+		{
+			Name:  "config",
+			Usage: "Inspect the configuration",
+			Subcommands: []*cli.Command{
+				{
+					Name:   "show",
+					Action: a.showConfig,
+					Usage:  "Print the effective configuration, after defaults, environment variables and --set overrides",
+				},
+			},
+		},
+		{
+			Name:   "carries",
+			Action: a.carries,
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "check-upstream",
+					Usage: "if true, upstream commits that make the same change as a downstream-only commit are reported",
+				},
+			},
+			Usage: "List downstream-only commits since the merge base with upstream, and the files they touch",
+		},
 		{
 			Name:   "delete-remote-branches",
 			Action: a.deleteRemoteBranches,
@@ -95,7 +173,13 @@ func (a *App) GetCLIApp() *cli.App {
 		{
 			Name:   "diff",
 			Action: a.diff,
-			Usage:  "List upstream commits and try to find them downstream",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "show-skipped",
+					Usage: "if true, the entries of the skip list are also listed, including expired ones",
+				},
+			},
+			Usage: "List upstream commits and try to find them downstream",
 		},
 		{
 			Name:   "make-oldest-draft-pr-ready",
@@ -103,11 +187,119 @@ func (a *App) GetCLIApp() *cli.App {
 			Flags:  []cli.Flag{flagDryRun},
 			Usage:  "Make the oldest draft GitStream PR ready",
 		},
+		{
+			Name:      "skip",
+			Action:    a.skip,
+			ArgsUsage: "SHA...",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     "reason",
+					Required: true,
+					Usage:    "why the commits should not be picked",
+				},
+				&cli.StringFlag{
+					Name:  "expires",
+					Usage: "date from which the commits can be picked again, as YYYY-MM-DD",
+				},
+			},
+			Usage: "Add upstream commits to the skip list of the downstream repository",
+		},
+		{
+			Name:      "unskip",
+			Action:    a.unskip,
+			ArgsUsage: "SHA...",
+			Usage:     "Remove upstream commits from the skip list of the downstream repository",
+		},
+		{
+			Name:      "pick",
+			Action:    a.pick,
+			ArgsUsage: "[SHA...]",
+			Flags: []cli.Flag{
+				flagDryRun,
+				&cli.BoolFlag{
+					Name:  "force",
+					Usage: "if true, commits are picked even if they already exist downstream, and the commits of unmerged PRs are picked",
+				},
+				&cli.IntFlag{
+					Name:  "pr",
+					Usage: "number of a merged upstream PR whose commits should be picked, instead of SHAs",
+				},
+			},
+			Usage: "Cherry-pick specific upstream commits now, regardless of the maximum number of open items",
+		},
+		{
+			Name:   "plan",
+			Action: a.plan,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     "out",
+					Required: true,
+					Usage:    "path of the JSON plan to write",
+				},
+			},
+			Usage: "Compute the PRs and issues that sync would create, without pushing or creating anything",
+		},
+		{
+			Name:      "apply",
+			Action:    a.apply,
+			ArgsUsage: "PLAN",
+			Usage:     "Create the PRs and issues in a plan, if upstream and downstream did not move since it was made",
+		},
+		{
+			Name:   "merge",
+			Action: a.merge,
+			Flags:  []cli.Flag{flagDryRun},
+			Usage:  "Merge the upstream ref into the main branch on a single PR, or open an issue if the merge conflicts",
+		},
+		{
+			Name:   "rebase",
+			Action: a.rebase,
+			Flags: []cli.Flag{
+				flagDryRun,
+				&cli.StringFlag{
+					Name:  reportFlagName,
+					Usage: "if set, a JSON report of the run is written to that path",
+				},
+				&cli.StringFlag{
+					Name:  junitReportFlagName,
+					Usage: "if set, a JUnit XML report of the run is written to that path",
+				},
+			},
+			Usage: "Replay the downstream-only commits on top of upstream and open a PR to replace the main branch",
+		},
+		{
+			Name:   "refresh",
+			Action: a.refresh,
+			Flags:  []cli.Flag{flagDryRun},
+			Usage:  "Cherry-pick the commits of open GitStream PRs onto the downstream main branch",
+		},
+		{
+			Name:   "serve",
+			Action: a.serve,
+			Flags:  []cli.Flag{flagDryRun},
+			Usage:  "Listen for upstream push webhooks and run sync when the upstream ref moves",
+		},
 		{
 			Name:   "sync",
 			Action: a.sync,
+			Flags: []cli.Flag{
+				flagDryRun,
+				&cli.StringFlag{
+					Name:  reportFlagName,
+					Usage: "if set, a JSON report of the run is written to that path",
+				},
+				&cli.StringFlag{
+					Name:  junitReportFlagName,
+					Usage: "if set, a JUnit XML report of the run is written to that path",
+				},
+			},
+			Usage: "Try to apply missing upstream commits to the downstream repository",
+		},
+		{
+			Name:   "tags",
+			Action: a.tags,
 			Flags:  []cli.Flag{flagDryRun},
-			Usage:  "Try to apply missing upstream commits to the downstream repository",
+			Usage:  "Create downstream tags for upstream tags whose commits are all present downstream",
 		},
 		{
 			Name:   "assign",
@@ -115,11 +307,27 @@ func (a *App) GetCLIApp() *cli.App {
 			Flags:  []cli.Flag{flagDryRun},
 			Usage:  "Assign open issues to the original commit author",
 		},
+		{
+			Name: validateConfigCommand,
+			Action: func(c *cli.Context) error {
+				return a.validateConfig(c, configPath, c.StringSlice(setFlagName))
+			},
+			Usage: "Report all problems in the configuration file and check that its keys and hooks can be loaded",
+		},
 	}
 
 	return app
 }
 
+func (a *App) newGQLClient(token string) (api.GQLClient, error) {
+	opts := api.ClientOptions{
+		AuthToken: token,
+		Transport: a.Metrics.WrapTransport(http.DefaultTransport),
+	}
+
+	return ghcli.GQLClient(&opts)
+}
+
 func getGitHubTokenFromEnv() (string, error) {
 	token, found := os.LookupEnv("GITHUB_TOKEN")
 	if !found {
@@ -129,6 +337,88 @@ func getGitHubTokenFromEnv() (string, error) {
 	return token, nil
 }
 
+func (a *App) showConfig(c *cli.Context) error {
+	enc := yaml.NewEncoder(c.App.Writer)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(a.Config); err != nil {
+		return fmt.Errorf("could not encode the configuration: %v", err)
+	}
+
+	return enc.Close()
+}
+
+func (a *App) validateConfig(c *cli.Context, configPath string, sets []string) error {
+	var problems []string
+
+	cfg, err := config.ReadConfigFile(configPath, sets...)
+	if err != nil {
+		ve := &config.ValidationError{}
+
+		if !errors.As(err, &ve) {
+			return fmt.Errorf("could not read config: %v", err)
+		}
+
+		for _, p := range ve.Problems {
+			problems = append(problems, p.String())
+		}
+	} else {
+		// Preflight checks: build what commands would build at startup.
+		a.Config = cfg
+
+		hr, err := hooks.NewRunner(cfg.Sync, cfg.Upstream.URL, a.Logger)
+		if err != nil {
+			problems = append(problems, "sync.hooks: "+err.Error())
+		}
+
+		if _, err := a.newCherryPicker(hr); err != nil {
+			problems = append(problems, "sync: "+err.Error())
+		}
+
+		if _, err := a.newRules(a.newUpstreamHelper(nil)); err != nil {
+			problems = append(problems, "rules: "+err.Error())
+		}
+	}
+
+	w := c.App.Writer
+
+	for _, p := range problems {
+		fmt.Fprintln(w, p)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%s: %d problem(s) found", configPath, len(problems))
+	}
+
+	fmt.Fprintf(w, "%s: configuration is valid\n", configPath)
+
+	return nil
+}
+
+func (a *App) carries(c *cli.Context) error {
+	repo, err := git.PlainOpenWithOptions(a.Config.Downstream.LocalRepoPath, &git.PlainOpenOptions{})
+	if err != nil {
+		return fmt.Errorf("could not open the downstream repo: %v", err)
+	}
+
+	finder, err := markup.NewFinder(a.Config.CommitMarkup...)
+	if err != nil {
+		return fmt.Errorf("could not create the markup finder: %v", err)
+	}
+
+	ca := gitstream.Carries{
+		CheckUpstream:        c.Bool("check-upstream"),
+		DownstreamMainBranch: a.Config.Downstream.MainBranch,
+		Finder:               finder,
+		GitHelper:            gitutils.NewHelper(repo, a.Logger),
+		Logger:               a.Logger,
+		Repo:                 repo,
+		UpstreamConfig:       a.Config.Upstream,
+	}
+
+	return ca.Run(c.Context)
+}
+
 func (a *App) deleteRemoteBranches(c *cli.Context) error {
 	ctx := c.Context
 
@@ -171,11 +461,18 @@ func (a *App) diff(c *cli.Context) error {
 		return fmt.Errorf("could not open the downstream repo: %v", err)
 	}
 
-	finder, err := markup.NewFinder(a.Config.CommitMarkup)
+	finder, err := markup.NewFinder(a.Config.CommitMarkup...)
 	if err != nil {
 		return fmt.Errorf("could not create the markup finder: %v", err)
 	}
 
+	uh := a.newUpstreamHelper(gc)
+
+	re, err := a.newRules(uh)
+	if err != nil {
+		return err
+	}
+
 	d := gitstream.Diff{
 		Differ: gitutils.NewDiffer(
 			gitutils.NewHelper(repo, a.Logger),
@@ -185,8 +482,11 @@ func (a *App) diff(c *cli.Context) error {
 		DiffConfig:           a.Config.Diff,
 		DownstreamMainBranch: a.Config.Downstream.MainBranch,
 		Logger:               a.Logger,
+		Metrics:              a.Metrics,
 		RepoName:             repoName,
 		Repo:                 repo,
+		Rules:                re,
+		ShowSkipped:          c.Bool("show-skipped"),
 		UpstreamConfig:       a.Config.Upstream,
 	}
 
@@ -203,7 +503,7 @@ func (a *App) makeOldestDraftPRReady(c *cli.Context) error {
 
 	gc := gh.NewGitHubClient(ctx, token)
 
-	ghgql, err := ghcli.GQLClient(&api.ClientOptions{AuthToken: token})
+	ghgql, err := a.newGQLClient(token)
 	if err != nil {
 		return fmt.Errorf("could not create a new GraphQL client: %v", err)
 	}
@@ -218,7 +518,7 @@ func (a *App) makeOldestDraftPRReady(c *cli.Context) error {
 		return fmt.Errorf("could not open the downstream repo: %v", err)
 	}
 
-	finder, err := markup.NewFinder(a.Config.CommitMarkup)
+	finder, err := markup.NewFinder(a.Config.CommitMarkup...)
 	if err != nil {
 		return fmt.Errorf("could not create the markup finder: %v", err)
 	}
@@ -228,7 +528,7 @@ func (a *App) makeOldestDraftPRReady(c *cli.Context) error {
 		Finder:         finder,
 		GitHelper:      gitutils.NewHelper(repo, a.Logger),
 		Logger:         a.Logger,
-		PRHelper:       gh.NewPRHelper(gc, ghgql, a.Config.CommitMarkup, repoName),
+		PRHelper:       gh.NewPRHelper(gc, ghgql, a.Config.CommitMarkup.Primary(), repoName),
 		Repo:           repo,
 		RepoName:       repoName,
 		UpstreamConfig: a.Config.Upstream,
@@ -237,7 +537,7 @@ func (a *App) makeOldestDraftPRReady(c *cli.Context) error {
 	return u.Run(ctx)
 }
 
-func (a *App) sync(c *cli.Context) error {
+func (a *App) merge(c *cli.Context) error {
 	ctx := c.Context
 
 	token, err := getGitHubTokenFromEnv()
@@ -247,7 +547,7 @@ func (a *App) sync(c *cli.Context) error {
 
 	gc := gh.NewGitHubClient(ctx, token)
 
-	ghgql, err := ghcli.GQLClient(&api.ClientOptions{AuthToken: token})
+	ghgql, err := a.newGQLClient(token)
 	if err != nil {
 		return fmt.Errorf("could not create a new GraphQL client: %v", err)
 	}
@@ -262,15 +562,329 @@ func (a *App) sync(c *cli.Context) error {
 		return fmt.Errorf("could not open the downstream repo: %v", err)
 	}
 
-	helper := gitutils.NewHelper(repo, a.Logger)
+	hr, err := hooks.NewRunner(a.Config.Sync, a.Config.Upstream.URL, a.Logger)
+	if err != nil {
+		return fmt.Errorf("could not create the hook runner: %v", err)
+	}
+
+	// Upstream SHAs are kept in the history, so the merge commit needs no markup.
+	opts, err := a.newCommitOptions("")
+	if err != nil {
+		return err
+	}
+
+	merger, err := a.newMerger()
+	if err != nil {
+		return err
+	}
+
+	m := gitstream.Merge{
+		CommitOptions:    opts,
+		DownstreamConfig: a.Config.Downstream,
+		DryRun:           c.Bool("dry-run"),
+		GitHelper:        gitutils.NewHelper(repo, a.Logger),
+		GitHubToken:      token,
+		Hooks:            hr,
+		IssueHelper:      gh.NewIssueHelper(gc, a.Config.CommitMarkup.Primary(), repoName),
+		Logger:           a.Logger,
+		Merger:           merger,
+		PRHelper:         gh.NewPRHelper(gc, ghgql, a.Config.CommitMarkup.Primary(), repoName),
+		Repo:             repo,
+		RepoPath:         a.Config.Downstream.LocalRepoPath,
+		UpstreamConfig:   a.Config.Upstream,
+	}
+
+	return m.Run(ctx)
+}
+
+func (a *App) rebase(c *cli.Context) error {
+	ctx := c.Context
+
+	token, err := getGitHubTokenFromEnv()
+	if err != nil {
+		return fmt.Errorf("could not create a GitHub client: %v", err)
+	}
+
+	gc := gh.NewGitHubClient(ctx, token)
+
+	ghgql, err := a.newGQLClient(token)
+	if err != nil {
+		return fmt.Errorf("could not create a new GraphQL client: %v", err)
+	}
+
+	repoName, err := gh.ParseRepoName(a.Config.Downstream.GitHubRepoName)
+	if err != nil {
+		return fmt.Errorf("%q: invalid repository name", a.Config.Downstream.GitHubRepoName)
+	}
+
+	repo, err := git.PlainOpenWithOptions(a.Config.Downstream.LocalRepoPath, &git.PlainOpenOptions{})
+	if err != nil {
+		return fmt.Errorf("could not open the downstream repo: %v", err)
+	}
+
+	finder, err := markup.NewFinder(a.Config.CommitMarkup...)
+	if err != nil {
+		return fmt.Errorf("could not create the markup finder: %v", err)
+	}
+
+	hr, err := hooks.NewRunner(a.Config.Sync, a.Config.Upstream.URL, a.Logger)
+	if err != nil {
+		return fmt.Errorf("could not create the hook runner: %v", err)
+	}
+
+	// Replayed carries must stay downstream-only, so they are not recorded with markup.
+	cp, err := a.newCherryPickerWithMarkup(hr, "")
+	if err != nil {
+		return err
+	}
+
+	r := gitstream.Rebase{
+		CherryPicker:     cp,
+		DownstreamConfig: a.Config.Downstream,
+		DryRun:           c.Bool("dry-run"),
+		Finder:           finder,
+		GitHelper:        gitutils.NewHelper(repo, a.Logger),
+		GitHubToken:      token,
+		Logger:           a.Logger,
+		PRHelper:         gh.NewPRHelper(gc, ghgql, a.Config.CommitMarkup.Primary(), repoName),
+		Repo:             repo,
+		RepoName:         repoName,
+		RepoPath:         a.Config.Downstream.LocalRepoPath,
+		Report:           &report.Report{},
+		UpstreamConfig:   a.Config.Upstream,
+	}
+
+	runErr := r.Run(ctx)
+
+	a.writeReports(c, r.Report)
+
+	return runErr
+}
+
+func (a *App) refresh(c *cli.Context) error {
+	ctx := c.Context
+
+	token, err := getGitHubTokenFromEnv()
+	if err != nil {
+		return fmt.Errorf("could not create a GitHub client: %v", err)
+	}
+
+	gc := gh.NewGitHubClient(ctx, token)
+
+	ghgql, err := a.newGQLClient(token)
+	if err != nil {
+		return fmt.Errorf("could not create a new GraphQL client: %v", err)
+	}
+
+	repoName, err := gh.ParseRepoName(a.Config.Downstream.GitHubRepoName)
+	if err != nil {
+		return fmt.Errorf("%q: invalid repository name", a.Config.Downstream.GitHubRepoName)
+	}
+
+	repo, err := git.PlainOpenWithOptions(a.Config.Downstream.LocalRepoPath, &git.PlainOpenOptions{})
+	if err != nil {
+		return fmt.Errorf("could not open the downstream repo: %v", err)
+	}
+
+	finder, err := markup.NewFinder(a.Config.CommitMarkup...)
+	if err != nil {
+		return fmt.Errorf("could not create the markup finder: %v", err)
+	}
+
+	hr, err := hooks.NewRunner(a.Config.Sync, a.Config.Upstream.URL, a.Logger)
+	if err != nil {
+		return fmt.Errorf("could not create the hook runner: %v", err)
+	}
+
+	cp, err := a.newCherryPicker(hr)
+	if err != nil {
+		return err
+	}
+
+	r := gitstream.Refresh{
+		CherryPicker:     cp,
+		DownstreamConfig: a.Config.Downstream,
+		DryRun:           c.Bool("dry-run"),
+		Finder:           finder,
+		GitHelper:        gitutils.NewHelper(repo, a.Logger),
+		GitHubToken:      token,
+		Logger:           a.Logger,
+		PRHelper:         gh.NewPRHelper(gc, ghgql, a.Config.CommitMarkup.Primary(), repoName),
+		Repo:             repo,
+		UpstreamConfig:   a.Config.Upstream,
+	}
+
+	return r.Run(ctx)
+}
+
+func (a *App) tags(c *cli.Context) error {
+	ctx := c.Context
+
+	token, err := getGitHubTokenFromEnv()
+	if err != nil {
+		return fmt.Errorf("could not create a GitHub client: %v", err)
+	}
 
-	finder, err := markup.NewFinder(a.Config.CommitMarkup)
+	gc := gh.NewGitHubClient(ctx, token)
+
+	repoName, err := gh.ParseRepoName(a.Config.Downstream.GitHubRepoName)
+	if err != nil {
+		return fmt.Errorf("%q: invalid repository name", a.Config.Downstream.GitHubRepoName)
+	}
+
+	repo, err := git.PlainOpenWithOptions(a.Config.Downstream.LocalRepoPath, &git.PlainOpenOptions{})
+	if err != nil {
+		return fmt.Errorf("could not open the downstream repo: %v", err)
+	}
+
+	finder, err := markup.NewFinder(a.Config.CommitMarkup...)
 	if err != nil {
 		return fmt.Errorf("could not create the markup finder: %v", err)
 	}
 
+	helper := gitutils.NewHelper(repo, a.Logger)
+
+	t := gitstream.Tags{
+		DiffConfig:           a.Config.Diff,
+		Differ:               gitutils.NewDiffer(helper, intents.NewDownstreamOnlyGetter(intents.NewIntentsGetter(finder, gc, a.Logger)), a.Logger),
+		DownstreamMainBranch: a.Config.Downstream.MainBranch,
+		DryRun:               c.Bool("dry-run"),
+		Finder:               finder,
+		GitHelper:            helper,
+		GitHubToken:          token,
+		Logger:               a.Logger,
+		Repo:                 repo,
+		RepoName:             repoName,
+		TagsConfig:           a.Config.Tags,
+		UpstreamConfig:       a.Config.Upstream,
+	}
+
+	return t.Run(ctx)
+}
+
+func (a *App) newCherryPicker(hr *hooks.Runner) (gitutils.CherryPicker, error) {
+	return a.newCherryPickerWithMarkup(hr, a.Config.CommitMarkup.Primary())
+}
+
+// newCherryPickerWithMarkup returns a CherryPicker that records picked commits with markup, or not at all if markup is
+// empty.
+func (a *App) newCherryPickerWithMarkup(hr *hooks.Runner, markup string) (gitutils.CherryPicker, error) {
+	opts, err := a.newCommitOptions(markup)
+	if err != nil {
+		return nil, err
+	}
+
+	switch backend := a.Config.Sync.Backend; backend {
+	case gitutils.BackendGit:
+		return gitutils.NewCherryPicker(opts, hr, a.Logger), nil
+	case gitutils.BackendGoGit:
+		return gitutils.NewNativeCherryPicker(opts, hr, a.Logger), nil
+	default:
+		return nil, fmt.Errorf("%q: invalid cherry-pick backend; valid values are %q and %q", backend, gitutils.BackendGit, gitutils.BackendGoGit)
+	}
+}
+
+// newMerger returns the Merger of the configured backend.
+func (a *App) newMerger() (gitutils.Merger, error) {
+	switch backend := a.Config.Sync.Backend; backend {
+	case gitutils.BackendGit:
+		return gitutils.NewMerger(a.Config.Sync.Committer), nil
+	case gitutils.BackendGoGit:
+		return gitutils.NewNativeMerger(), nil
+	default:
+		return nil, fmt.Errorf("%q: invalid merge backend; valid values are %q and %q", backend, gitutils.BackendGit, gitutils.BackendGoGit)
+	}
+}
+
+func (a *App) newCommitOptions(markup string) (gitutils.CommitOptions, error) {
+	cfg := a.Config.Sync
+
+	if cfg.SignOff && (cfg.Committer.Name == "" || cfg.Committer.Email == "") {
+		return gitutils.CommitOptions{}, errors.New("sign_off requires the committer name and email to be set")
+	}
+
+	signer, err := signing.NewSigner(cfg.Signing)
+	if err != nil {
+		return gitutils.CommitOptions{}, fmt.Errorf("could not load the signing key: %v", err)
+	}
+
+	opts := gitutils.CommitOptions{
+		Committer:    cfg.Committer,
+		Markup:       markup,
+		MergeCommits: a.Config.Upstream.MergeCommits,
+		SignOff:      cfg.SignOff,
+		Signer:       signer,
+	}
+
+	return opts, nil
+}
+
+// newUpstreamHelper returns nil if the upstream repository is not on GitHub.
+func (a *App) newUpstreamHelper(gc *github.Client) gh.UpstreamHelper {
+	upstreamRepoName, err := gh.ParseURL(a.Config.Upstream.URL)
+	if err != nil {
+		return nil
+	}
+
+	return gh.NewUpstreamHelper(gc, upstreamRepoName)
+}
+
+// newRules compiles the rules of the configuration. The upstream repository must be on GitHub if a rule has labels.
+func (a *App) newRules(uh gh.UpstreamHelper) (*rules.Engine, error) {
+	e, err := rules.New(a.Config.Rules, uh)
+	if err != nil {
+		return nil, fmt.Errorf("could not create the rules: %v", err)
+	}
+
+	return e, nil
+}
+
+func (a *App) newSync(c *cli.Context, token string) (*gitstream.Sync, error) {
+	ctx := c.Context
+
+	gc := gh.NewGitHubClient(ctx, token)
+
+	ghgql, err := a.newGQLClient(token)
+	if err != nil {
+		return nil, fmt.Errorf("could not create a new GraphQL client: %v", err)
+	}
+
+	repoName, err := gh.ParseRepoName(a.Config.Downstream.GitHubRepoName)
+	if err != nil {
+		return nil, fmt.Errorf("%q: invalid repository name", a.Config.Downstream.GitHubRepoName)
+	}
+
+	repo, err := git.PlainOpenWithOptions(a.Config.Downstream.LocalRepoPath, &git.PlainOpenOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("could not open the downstream repo: %v", err)
+	}
+
+	helper := gitutils.NewHelper(repo, a.Logger)
+
+	finder, err := markup.NewFinder(a.Config.CommitMarkup...)
+	if err != nil {
+		return nil, fmt.Errorf("could not create the markup finder: %v", err)
+	}
+
+	hr, err := hooks.NewRunner(a.Config.Sync, a.Config.Upstream.URL, a.Logger)
+	if err != nil {
+		return nil, fmt.Errorf("could not create the hook runner: %v", err)
+	}
+
+	cp, err := a.newCherryPicker(hr)
+	if err != nil {
+		return nil, err
+	}
+
+	uh := a.newUpstreamHelper(gc)
+
+	re, err := a.newRules(uh)
+	if err != nil {
+		return nil, err
+	}
+
 	s := gitstream.Sync{
-		CherryPicker: gitutils.NewCherryPicker(a.Config.CommitMarkup, a.Logger, a.Config.Sync.BeforeCommit...),
+		CherryPicker: cp,
 		Differ: gitutils.NewDiffer(
 			helper,
 			intents.NewIntentsGetter(finder, gc, a.Logger),
@@ -281,15 +895,93 @@ func (a *App) sync(c *cli.Context) error {
 		DryRun:           c.Bool("dry-run"),
 		GitHelper:        helper,
 		GitHubToken:      token,
-		IssueHelper:      gh.NewIssueHelper(gc, a.Config.CommitMarkup, repoName),
+		Hooks:            hr,
+		IssueHelper:      gh.NewIssueHelper(gc, a.Config.CommitMarkup.Primary(), repoName),
 		Logger:           a.Logger,
-		PRHelper:         gh.NewPRHelper(gc, ghgql, a.Config.CommitMarkup, repoName),
+		Metrics:          a.Metrics,
+		PRHelper:         gh.NewPRHelper(gc, ghgql, a.Config.CommitMarkup.Primary(), repoName),
 		Repo:             repo,
 		RepoName:         repoName,
+		Rules:            re,
+		SyncConfig:       a.Config.Sync,
+		UpstreamConfig:   a.Config.Upstream,
+		UpstreamHelper:   uh,
+		WorktreeManager:  gitutils.NewWorktreeManager(a.Config.Downstream.LocalRepoPath, a.Logger),
+	}
+
+	return &s, nil
+}
+
+func (a *App) serve(c *cli.Context) error {
+	token, err := getGitHubTokenFromEnv()
+	if err != nil {
+		return fmt.Errorf("could not create a GitHub client: %v", err)
+	}
+
+	secretEnv := a.Config.Serve.SecretEnv
+
+	secret, found := os.LookupEnv(secretEnv)
+	if !found || secret == "" {
+		return fmt.Errorf("%s: undefined or empty variable", secretEnv)
+	}
+
+	upstreamRepoName, err := gh.ParseURL(a.Config.Upstream.URL)
+	if err != nil {
+		return fmt.Errorf("%q: invalid URL", a.Config.Upstream.URL)
+	}
+
+	s, err := a.newSync(c, token)
+	if err != nil {
+		return err
+	}
+
+	srv := gitstream.Serve{
+		Logger:           a.Logger,
+		Metrics:          a.Metrics,
+		MetricsConfig:    a.Config.Metrics,
+		ServeConfig:      a.Config.Serve,
+		Sync:             s,
 		UpstreamConfig:   a.Config.Upstream,
+		UpstreamRepoName: upstreamRepoName,
+		WebhookSecret:    []byte(secret),
 	}
 
-	return s.Run(ctx)
+	return srv.Run(c.Context)
+}
+
+func (a *App) sync(c *cli.Context) error {
+	token, err := getGitHubTokenFromEnv()
+	if err != nil {
+		return fmt.Errorf("could not create a GitHub client: %v", err)
+	}
+
+	s, err := a.newSync(c, token)
+	if err != nil {
+		return err
+	}
+
+	s.Report = &report.Report{}
+
+	runErr := s.Run(c.Context)
+
+	a.writeReports(c, s.Report)
+
+	return runErr
+}
+
+// writeReports writes rep to the paths passed to the report flags, if any. Failures are logged.
+func (a *App) writeReports(c *cli.Context, rep *report.Report) {
+	if path := c.String(reportFlagName); path != "" {
+		if err := rep.WriteJSON(path); err != nil {
+			a.Logger.Error(err, "Could not write the report")
+		}
+	}
+
+	if path := c.String(junitReportFlagName); path != "" {
+		if err := rep.WriteJUnit(path); err != nil {
+			a.Logger.Error(err, "Could not write the JUnit report")
+		}
+	}
 }
 
 func getGitCommit() string {
@@ -305,6 +997,133 @@ func getGitCommit() string {
 	return ""
 }
 
+func (a *App) skip(c *cli.Context) error {
+	if c.NArg() == 0 {
+		return errors.New("expected at least one SHA")
+	}
+
+	s := gitstream.Skip{
+		Logger: a.Logger,
+		Path:   filepath.Join(a.Config.Downstream.LocalRepoPath, skiplist.Path),
+		Reason: c.String("reason"),
+		SHAs:   c.Args().Slice(),
+	}
+
+	if v := c.String("expires"); v != "" {
+		expires, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			return fmt.Errorf("%q: invalid expiry date; format is YYYY-MM-DD", v)
+		}
+
+		s.Expires = &expires
+	}
+
+	return s.Run(c.Context)
+}
+
+func (a *App) unskip(c *cli.Context) error {
+	if c.NArg() == 0 {
+		return errors.New("expected at least one SHA")
+	}
+
+	u := gitstream.Unskip{
+		Logger: a.Logger,
+		Path:   filepath.Join(a.Config.Downstream.LocalRepoPath, skiplist.Path),
+		SHAs:   c.Args().Slice(),
+	}
+
+	return u.Run(c.Context)
+}
+
+func (a *App) pick(c *cli.Context) error {
+	ctx := c.Context
+
+	shas := c.Args().Slice()
+	prNumber := c.Int("pr")
+
+	if (len(shas) == 0) == (prNumber == 0) {
+		return errors.New("expected either SHAs or --pr")
+	}
+
+	token, err := getGitHubTokenFromEnv()
+	if err != nil {
+		return fmt.Errorf("could not create a GitHub client: %v", err)
+	}
+
+	s, err := a.newSync(c, token)
+	if err != nil {
+		return err
+	}
+
+	gc := gh.NewGitHubClient(ctx, token)
+
+	finder, err := markup.NewFinder(a.Config.CommitMarkup...)
+	if err != nil {
+		return fmt.Errorf("could not create the markup finder: %v", err)
+	}
+
+	p := gitstream.Pick{
+		Force:         c.Bool("force"),
+		IntentsGetter: intents.NewIntentsGetter(finder, gc, a.Logger),
+		SHAs:          shas,
+		Sync:          s,
+		UpstreamPR:    prNumber,
+	}
+
+	if prNumber != 0 {
+		upstreamRepoName, err := gh.ParseURL(a.Config.Upstream.URL)
+		if err != nil {
+			return fmt.Errorf("%q: invalid URL", a.Config.Upstream.URL)
+		}
+
+		p.UpstreamHelper = gh.NewUpstreamHelper(gc, upstreamRepoName)
+	}
+
+	return p.Run(ctx)
+}
+
+func (a *App) plan(c *cli.Context) error {
+	token, err := getGitHubTokenFromEnv()
+	if err != nil {
+		return fmt.Errorf("could not create a GitHub client: %v", err)
+	}
+
+	s, err := a.newSync(c, token)
+	if err != nil {
+		return err
+	}
+
+	p, err := s.Plan(c.Context)
+	if err != nil {
+		return fmt.Errorf("could not make the plan: %v", err)
+	}
+
+	return p.Write(c.String("out"))
+}
+
+func (a *App) apply(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return errors.New("expected exactly one argument: the path of the plan")
+	}
+
+	p, err := plan.Read(c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	token, err := getGitHubTokenFromEnv()
+	if err != nil {
+		return fmt.Errorf("could not create a GitHub client: %v", err)
+	}
+
+	s, err := a.newSync(c, token)
+	if err != nil {
+		return err
+	}
+
+	return s.Apply(c.Context, p)
+}
+
 func (a *App) assign(c *cli.Context) error {
 	ctx := c.Context
 
@@ -330,7 +1149,7 @@ func (a *App) assign(c *cli.Context) error {
 		return fmt.Errorf("could not open the downstream repo: %v", err)
 	}
 
-	finder, err := markup.NewFinder(a.Config.CommitMarkup)
+	finder, err := markup.NewFinder(a.Config.CommitMarkup...)
 	if err != nil {
 		return fmt.Errorf("could not create the markup finder: %v", err)
 	}
@@ -341,7 +1160,7 @@ func (a *App) assign(c *cli.Context) error {
 		Finder:           finder,
 		GitHelper:        gitutils.NewHelper(repo, a.Logger),
 		Logger:           a.Logger,
-		IssueHelper:      gh.NewIssueHelper(gc, a.Config.CommitMarkup, repoName),
+		IssueHelper:      gh.NewIssueHelper(gc, a.Config.CommitMarkup.Primary(), repoName),
 		UserHelper:       gh.NewUserHelper(gc, repoName),
 		Repo:             repo,
 		RepoName:         upstreamRepoName,
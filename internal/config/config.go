@@ -1,63 +1,270 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"reflect"
 	"time"
 
 	"github.com/creasty/defaults"
 	"gopkg.in/yaml.v3"
 )
 
+const (
+	AutoMergeMethodMerge  = "merge"
+	AutoMergeMethodRebase = "rebase"
+	AutoMergeMethodSquash = "squash"
+)
+
+type AutoMerge struct {
+	Authors        []string `yaml:"authors"`
+	Enabled        bool     `yaml:"enabled"`
+	MaxDiffLines   int      `yaml:"max_diff_lines" default:"-1"`
+	Method         string   `yaml:"method" default:"merge"`
+	ProtectedPaths []string `yaml:"protected_paths"`
+}
+
 type Downstream struct {
-	CreateDraftPRs bool     `yaml:"create_draft_prs"`
-	GitHubRepoName string   `yaml:"github_repo_name"`
-	LocalRepoPath  string   `yaml:"local_repo_path" default:"."`
-	MainBranch     string   `yaml:"main_branch" default:"main"`
-	MaxOpenItems   int      `yaml:"max_open_items" default:"-1"`
-	IgnoreAuthors  []string `yaml:"ignore_authors"`
-	OwnersFile     string   `yaml:"owners_file" default:"OWNERS"`
+	AutoMerge      AutoMerge `yaml:"auto_merge"`
+	CreateDraftPRs bool      `yaml:"create_draft_prs"`
+	GitHubRepoName string    `yaml:"github_repo_name"`
+	LocalRepoPath  string    `yaml:"local_repo_path" default:"."`
+	MainBranch     string    `yaml:"main_branch" default:"main"`
+	MaxOpenItems   int       `yaml:"max_open_items" default:"-1"`
+	IgnoreAuthors  []string  `yaml:"ignore_authors"`
+	OwnersFile     string    `yaml:"owners_file" default:"OWNERS"`
 }
 
+type Committer struct {
+	Email string `yaml:"email"`
+	Name  string `yaml:"name"`
+}
+
+// Diff configures which upstream commits are considered. SinceRef is an upstream tag or SHA whose ancestors are all
+// considered handled, like git log SinceRef..upstream. CommitsSince additionally ignores commits committed before it.
 type Diff struct {
 	CommitsSince *time.Time `yaml:"commits_since"`
+	SinceRef     string     `yaml:"since_ref"`
+}
+
+const (
+	HookStageAfterCherryPick = "after-cherry-pick"
+	HookStageAfterCommit     = "after-commit"
+	HookStageAfterPush       = "after-push"
+	HookStageBeforeCommit    = "before-commit"
+	HookStageOnFailure       = "on-failure"
+)
+
+type Hook struct {
+	AllowFailure bool          `yaml:"allow_failure"`
+	Command      []string      `yaml:"command"`
+	Name         string        `yaml:"name"`
+	Paths        []string      `yaml:"paths"`
+	Stage        string        `yaml:"stage"`
+	Timeout      time.Duration `yaml:"timeout"`
+}
+
+// Markup is a list of trailer keys that record the upstream commit a downstream commit was cherry-picked from.
+// It can be written in YAML as a single string or as a list. The first element is the one written by gitstream.
+type Markup []string
+
+func (m *Markup) UnmarshalYAML(value *yaml.Node) error {
+	var list []string
+
+	if value.Kind == yaml.ScalarNode {
+		list = []string{value.Value}
+	} else if err := value.Decode(&list); err != nil {
+		return err
+	}
+
+	*m = list
+
+	return nil
+}
+
+// Primary returns the markup written by gitstream. ReadConfig ensures that there is at least one markup.
+func (m Markup) Primary() string {
+	return m[0]
+}
+
+type Metrics struct {
+	Path     string `yaml:"path" default:"/metrics"`
+	Textfile string `yaml:"textfile"`
+}
+
+const (
+	RuleActionExclude = "exclude"
+	RuleActionInclude = "include"
+)
+
+// Rule selects upstream commits. Rules are evaluated in order for every missing upstream commit, and the first rule
+// that matches decides if the commit is synced; commits that match no rule are synced. A commit matches a rule if it
+// meets all of the rule's conditions; conditions that are not set are met by every commit.
+type Rule struct {
+	// Action is either include or exclude.
+	Action string `yaml:"action"`
+	// Authors are regular expressions matched against the name and the email of the commit author.
+	Authors []string `yaml:"authors"`
+	// Bot matches commits authored by GitHub bot accounts if true, and other commits if false.
+	Bot *bool `yaml:"bot"`
+	// Labels matches commits whose upstream PR has at least one of these labels.
+	Labels []string `yaml:"labels"`
+	// Merge matches merge commits if true, and other commits if false.
+	Merge *bool `yaml:"merge"`
+	// Name identifies the rule in logs. Rules without a name are identified by their index.
+	Name string `yaml:"name"`
+	// NewerThan matches commits committed less than this duration ago.
+	NewerThan time.Duration `yaml:"newer_than"`
+	// OlderThan matches commits committed more than this duration ago.
+	OlderThan time.Duration `yaml:"older_than"`
+	// Paths matches commits that change at least one file matching these patterns. Patterns are matched one
+	// slash-separated segment at a time with path.Match syntax; a ** segment matches any number of directories, and a
+	// pattern ending with a slash matches everything under that directory.
+	Paths []string `yaml:"paths"`
+	// Subject is a regular expression matched against the first line of the commit message.
+	Subject string `yaml:"subject"`
+}
+
+type Serve struct {
+	Address   string        `yaml:"address" default:":8080"`
+	Debounce  time.Duration `yaml:"debounce" default:"30s"`
+	Path      string        `yaml:"path" default:"/webhook"`
+	SecretEnv string        `yaml:"secret_env" default:"GITSTREAM_WEBHOOK_SECRET"`
+}
+
+const (
+	SigningFormatGPG = "gpg"
+	SigningFormatSSH = "ssh"
+)
+
+type Signing struct {
+	// Format is either gpg or ssh. Commits are not signed if it is empty.
+	Format        string `yaml:"format"`
+	KeyFile       string `yaml:"key_file"`
+	PassphraseEnv string `yaml:"passphrase_env"`
 }
 
+// Tags configures the tags command. Upstream tags whose name matches one of Patterns are mirrored downstream under the
+// name rendered from NameTemplate, a Go template that receives the upstream tag name as .Name.
+type Tags struct {
+	NameTemplate string   `yaml:"name_template" default:"{{ .Name }}"`
+	Patterns     []string `yaml:"patterns"`
+}
+
+const (
+	SyncBackendGit   = "git"
+	SyncBackendGoGit = "go-git"
+)
+
 type Sync struct {
+	Backend      string     `yaml:"backend" default:"git"`
 	BeforeCommit [][]string `yaml:"before_commit"`
+	Committer    Committer  `yaml:"committer"`
+	Hooks        []Hook     `yaml:"hooks"`
+	SignOff      bool       `yaml:"sign_off"`
+	Signing      Signing    `yaml:"signing"`
+	Workers      int        `yaml:"workers" default:"1"`
 }
 
+const (
+	MergeCommitsAll         = "all"
+	MergeCommitsFirstParent = "first-parent"
+	MergeCommitsPR          = "pr"
+	MergeCommitsSkip        = "skip"
+)
+
 type Upstream struct {
-	Ref string `default:"main"`
-	URL string
+	// MergeCommits selects how upstream merge commits are handled:
+	//   - all: every commit reachable from Ref is picked, merge commits as their diff against their first parent;
+	//   - skip: merge commits are not picked, only the other commits reachable from Ref;
+	//   - first-parent: only the first-parent chain of Ref is walked, and merge commits are picked as a whole;
+	//   - pr: only the first-parent chain of Ref is walked, and each merge commit is picked as the commits it merged.
+	MergeCommits string `yaml:"merge_commits" default:"all"`
+	Ref          string `default:"main"`
+	URL          string
 }
 
 type Config struct {
-	CommitMarkup string `yaml:"commit_markup" default:"Upstream-Commit"`
+	CommitMarkup Markup `yaml:"commit_markup" default:"[\"Upstream-Commit\"]"`
 	Downstream   Downstream
 	Diff         Diff
 	LogLevel     int `yaml:"log_level"`
+	Metrics      Metrics
+	Rules        []Rule `yaml:"rules"`
+	Serve        Serve
 	Sync         Sync
+	Tags         Tags
 	Upstream     Upstream
 }
 
-func ReadConfig(rd io.Reader) (*Config, error) {
+// ReadConfig reads the configuration from rd. ${VAR} in the values of rd is replaced with the value of the environment
+// variable VAR. Values are then overridden by GITSTREAM_* environment variables, and finally by sets, in path=value form.
+// Unknown keys are rejected. If the configuration has unknown keys or invalid values, it returns a *ValidationError
+// listing all of them.
+func ReadConfig(rd io.Reader, sets ...string) (*Config, error) {
 	cfg := Config{}
 
 	if err := defaults.Set(&cfg); err != nil {
 		return nil, fmt.Errorf("could not set default values: %v", err)
 	}
 
-	return &cfg, yaml.NewDecoder(rd).Decode(&cfg)
+	b, err := io.ReadAll(rd)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the configuration: %v", err)
+	}
+
+	root := yaml.Node{}
+
+	if err = yaml.Unmarshal(b, &root); err != nil {
+		return nil, err
+	}
+
+	problems := interpolate(&root)
+	problems = append(problems, unknownKeys(&root, reflect.TypeOf(cfg))...)
+
+	if root.Kind == yaml.DocumentNode {
+		if err = root.Decode(&cfg); err != nil {
+			te := &yaml.TypeError{}
+
+			if !errors.As(err, &te) {
+				return nil, err
+			}
+
+			problems = append(problems, problemsFromTypeError(te)...)
+		}
+	}
+
+	paths := leafPaths(reflect.TypeOf(cfg))
+
+	setOverrides, setProblems := parseSets(sets)
+	problems = append(problems, setProblems...)
+
+	sources := make(map[string]string)
+
+	for _, o := range append(envOverrides(paths), setOverrides...) {
+		if err := o.apply(&cfg, paths); err != nil {
+			problems = append(problems, Problem{Message: fmt.Sprintf("%s: %v", o.source, err)})
+			continue
+		}
+
+		sources[o.path] = o.source
+	}
+
+	if problems = append(problems, validate(&cfg, &root, sources)...); len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+
+	return &cfg, nil
 }
 
-func ReadConfigFile(path string) (*Config, error) {
+func ReadConfigFile(path string, sets ...string) (*Config, error) {
 	fd, err := os.Open(path)
 	if err != nil {
 		return nil, fmt.Errorf("could not read the config file: %v", err)
 	}
 	defer fd.Close()
 
-	return ReadConfig(fd)
+	return ReadConfig(fd, sets...)
 }
@@ -12,28 +12,56 @@ import (
 func TestReadConfig(t *testing.T) {
 	// This test checks default values.
 	expected := Config{
-		CommitMarkup: "Upstream-Commit",
+		CommitMarkup: Markup{"Upstream-Commit"},
 		Downstream: Downstream{
-			LocalRepoPath: ".",
-			MainBranch:    "main",
-			MaxOpenItems:  -1,
-			OwnersFile:    "OWNERS",
+			AutoMerge: AutoMerge{
+				MaxDiffLines: -1,
+				Method:       "merge",
+			},
+			GitHubRepoName: "owner/repo",
+			LocalRepoPath:  ".",
+			MainBranch:     "main",
+			MaxOpenItems:   -1,
+			OwnersFile:     "OWNERS",
+		},
+		Metrics: Metrics{Path: "/metrics"},
+		Serve: Serve{
+			Address:   ":8080",
+			Debounce:  30 * time.Second,
+			Path:      "/webhook",
+			SecretEnv: "GITSTREAM_WEBHOOK_SECRET",
 		},
-		Upstream: Upstream{Ref: "main"},
+		Sync: Sync{
+			Backend: "git",
+			Workers: 1,
+		},
+		Tags:     Tags{NameTemplate: "{{ .Name }}"},
+		Upstream: Upstream{MergeCommits: MergeCommitsAll, Ref: "main"},
 	}
 
-	cfg, err := ReadConfig(strings.NewReader("---"))
+	cfg, err := ReadConfig(strings.NewReader("---"), "downstream.github_repo_name=owner/repo")
 	require.NoError(t, err)
 	assert.Equal(t, &expected, cfg)
+
+	_, err = ReadConfig(strings.NewReader("---"))
+	assert.EqualError(t, err, "1 problem(s): downstream.github_repo_name: required")
 }
 
 func TestReadConfigFile(t *testing.T) {
 	// This test overrides default values
 	since := time.Date(2022, 12, 1, 0, 0, 0, 0, time.UTC)
+	no := false
 
 	expected := Config{
-		CommitMarkup: "test",
+		CommitMarkup: Markup{"test"},
 		Downstream: Downstream{
+			AutoMerge: AutoMerge{
+				Authors:        []string{"some-author"},
+				Enabled:        true,
+				MaxDiffLines:   100,
+				Method:         "squash",
+				ProtectedPaths: []string{"some-dir/"},
+			},
 			GitHubRepoName: "owner/repo",
 			LocalRepoPath:  "some-path",
 			MainBranch:     "some-branch",
@@ -42,17 +70,68 @@ func TestReadConfigFile(t *testing.T) {
 		},
 		Diff: Diff{
 			CommitsSince: &since,
+			SinceRef:     "v1.2.3",
 		},
 		LogLevel: 1000,
+		Metrics: Metrics{
+			Path:     "/some-metrics",
+			Textfile: "/some/dir/gitstream.prom",
+		},
+		Rules: []Rule{
+			{Action: RuleActionExclude, Name: "docs", Subject: "^docs"},
+			{
+				Action:    RuleActionInclude,
+				Authors:   []string{`@example\.com$`},
+				Bot:       &no,
+				Labels:    []string{"kind/bug"},
+				Merge:     &no,
+				NewerThan: 30 * 24 * time.Hour,
+				OlderThan: time.Hour,
+				Paths:     []string{"pkg/"},
+			},
+		},
+		Serve: Serve{
+			Address:   "127.0.0.1:9000",
+			Debounce:  time.Minute,
+			Path:      "/hooks/github",
+			SecretEnv: "SOME_SECRET",
+		},
 		Sync: Sync{
+			Backend: "go-git",
 			BeforeCommit: [][]string{
 				{"command", "one"},
 				{"command", "two"},
 			},
+			Committer: Committer{
+				Email: "bot@example.com",
+				Name:  "Some Bot",
+			},
+			Hooks: []Hook{
+				{
+					AllowFailure: true,
+					Command:      []string{"make", "lint"},
+					Name:         "lint",
+					Paths:        []string{"pkg/"},
+					Stage:        "after-commit",
+					Timeout:      5 * time.Minute,
+				},
+			},
+			SignOff: true,
+			Signing: Signing{
+				Format:        "ssh",
+				KeyFile:       "/some/dir/id_ed25519",
+				PassphraseEnv: "SOME_PASSPHRASE",
+			},
+			Workers: 4,
+		},
+		Tags: Tags{
+			NameTemplate: "{{ .Name }}-downstream",
+			Patterns:     []string{"v*"},
 		},
 		Upstream: Upstream{
-			Ref: "some-ref",
-			URL: "https://url.to.some/git/repo",
+			MergeCommits: MergeCommitsPR,
+			Ref:          "some-ref",
+			URL:          "https://url.to.some/git/repo",
 		},
 	}
 
@@ -60,3 +139,149 @@ func TestReadConfigFile(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, &expected, cfg)
 }
+
+func TestMarkup_UnmarshalYAML(t *testing.T) {
+	t.Run("list", func(t *testing.T) {
+		cfg, err := ReadConfig(
+			strings.NewReader("commit_markup: [Upstream-Commit, Old-Upstream-Commit]"),
+			"downstream.github_repo_name=owner/repo",
+		)
+		require.NoError(t, err)
+		assert.Equal(t, Markup{"Upstream-Commit", "Old-Upstream-Commit"}, cfg.CommitMarkup)
+		assert.Equal(t, "Upstream-Commit", cfg.CommitMarkup.Primary())
+	})
+
+	t.Run("empty list", func(t *testing.T) {
+		_, err := ReadConfig(strings.NewReader("commit_markup: []"), "downstream.github_repo_name=owner/repo")
+		assert.EqualError(t, err, "1 problem(s): line 1: commit_markup: at least one markup is required")
+	})
+}
+
+func TestReadConfigFile_Invalid(t *testing.T) {
+	_, err := ReadConfigFile("testdata/invalid.yml")
+
+	ve := &ValidationError{}
+	require.ErrorAs(t, err, &ve)
+
+	lines := make([]int, 0, len(ve.Problems))
+
+	for _, p := range ve.Problems {
+		lines = append(lines, p.Line)
+	}
+
+	assert.Equal(t, []int{5, 1, 4, 7, 6, 9, 24, 27, 28, 38, 15, 12, 18, 20, 21, 31, 32}, lines)
+	assert.Equal(t, "line 5: field max_open_item not found in type config.Downstream", ve.Problems[0].String())
+	assert.Equal(t, `line 4: downstream.github_repo_name: "owner/repo/extra": format is owner/repo`, ve.Problems[2].String())
+	assert.Equal(t, `line 9: downstream.auto_merge.method: "fast-forward": must be one of merge, squash or rebase`, ve.Problems[5].String())
+	assert.Equal(t, `line 27: rules.0.action: "drop": must be include or exclude`, ve.Problems[7].String())
+	assert.Equal(t, `line 38: serve.path: "/hooks": must differ from metrics.path`, ve.Problems[9].String())
+	assert.Equal(t, `line 12: sync.backend: "libgit2": must be git or go-git`, ve.Problems[11].String())
+	assert.Equal(
+		t,
+		`line 18: sync.hooks.0.stage: "before-push": must be one of after-cherry-pick, before-commit, after-commit, after-push or on-failure`,
+		ve.Problems[12].String(),
+	)
+	assert.Equal(t, `line 20: sync.signing.format: "x509": must be gpg or ssh, or empty to not sign commits`, ve.Problems[13].String())
+}
+
+func TestReadConfig_Interpolation(t *testing.T) {
+	t.Setenv("SOME_REPO", "owner/repo")
+	t.Setenv("SOME_MAX", "5")
+
+	const yml = `downstream:
+  github_repo_name: ${SOME_REPO}
+  max_open_items: ${SOME_MAX}
+sync:
+  before_commit:
+    - [sh, -c, "echo $${HOME}"]
+`
+
+	cfg, err := ReadConfig(strings.NewReader(yml))
+	require.NoError(t, err)
+	assert.Equal(t, "owner/repo", cfg.Downstream.GitHubRepoName)
+	assert.Equal(t, 5, cfg.Downstream.MaxOpenItems)
+	assert.Equal(t, [][]string{{"sh", "-c", "echo ${HOME}"}}, cfg.Sync.BeforeCommit)
+
+	_, err = ReadConfig(strings.NewReader("downstream:\n  main_branch: ${SOME_UNDEFINED_VAR}\n  github_repo_name: owner/repo\n"))
+	assert.EqualError(t, err, "1 problem(s): line 2: ${SOME_UNDEFINED_VAR}: undefined variable")
+
+	t.Run("values cannot change the structure of the document", func(t *testing.T) {
+		t.Setenv("SOME_VALUE", "a: b # c\n- d\n*e")
+
+		const yml = `# ${SOME_UNDEFINED_VAR} is not interpolated in comments
+commit_markup: ${SOME_VALUE}
+downstream:
+  github_repo_name: owner/repo
+  main_branch: "${SOME_MAX}"
+`
+
+		cfg, err := ReadConfig(strings.NewReader(yml))
+		require.NoError(t, err)
+		assert.Equal(t, Markup{"a: b # c\n- d\n*e"}, cfg.CommitMarkup)
+		assert.Equal(t, "5", cfg.Downstream.MainBranch)
+	})
+}
+
+func TestReadConfig_Overrides(t *testing.T) {
+	const yml = `downstream:
+  create_draft_prs: false
+  github_repo_name: owner/repo
+  max_open_items: 3
+upstream:
+  ref: main
+`
+
+	t.Run("environment variables and sets", func(t *testing.T) {
+		t.Setenv("GITSTREAM_DOWNSTREAM_MAX_OPEN_ITEMS", "10")
+		t.Setenv("GITSTREAM_DOWNSTREAM_CREATE_DRAFT_PRS", "true")
+		t.Setenv("GITSTREAM_UPSTREAM_REF", "release-1.0")
+
+		cfg, err := ReadConfig(
+			strings.NewReader(yml),
+			"downstream.max_open_items=5",
+			"downstream.ignore_authors=[bot1, bot2]",
+			"commit_markup=Other-Commit",
+		)
+		require.NoError(t, err)
+
+		assert.Equal(t, 5, cfg.Downstream.MaxOpenItems)
+		assert.True(t, cfg.Downstream.CreateDraftPRs)
+		assert.Equal(t, "owner/repo", cfg.Downstream.GitHubRepoName)
+		assert.Equal(t, []string{"bot1", "bot2"}, cfg.Downstream.IgnoreAuthors)
+		assert.Equal(t, "release-1.0", cfg.Upstream.Ref)
+		assert.Equal(t, Markup{"Other-Commit"}, cfg.CommitMarkup)
+		assert.Equal(t, "OWNERS", cfg.Downstream.OwnersFile)
+	})
+
+	t.Run("invalid overrides", func(t *testing.T) {
+		t.Setenv("GITSTREAM_SYNC_WORKERS", "many")
+
+		_, err := ReadConfig(
+			strings.NewReader(yml),
+			"downstream.max_open_items=-2",
+			"downstream.unknown=1",
+			"no-equal-sign",
+		)
+
+		ve := &ValidationError{}
+		require.ErrorAs(t, err, &ve)
+
+		messages := make([]string, 0, len(ve.Problems))
+
+		for _, p := range ve.Problems {
+			assert.Zero(t, p.Line)
+			messages = append(messages, p.Message)
+		}
+
+		assert.Equal(
+			t,
+			[]string{
+				`--set "no-equal-sign": format is path=value`,
+				"GITSTREAM_SYNC_WORKERS: sync.workers: yaml: unmarshal errors:\n  line 1: cannot unmarshal !!str `many` into int",
+				"--set: downstream.unknown: unknown configuration key",
+				"downstream.max_open_items: -2: must be positive, or -1 for no limit (set by --set)",
+			},
+			messages,
+		)
+	})
+}
@@ -0,0 +1,261 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is the prefix of the environment variables that override configuration values. The rest of the name is
+// the path of the value in upper case, with dots replaced by underscores: GITSTREAM_DOWNSTREAM_MAX_OPEN_ITEMS
+// overrides downstream.max_open_items.
+const EnvPrefix = "GITSTREAM_"
+
+var interpolationRegexp = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// interpolate replaces ${VAR} with the value of the environment variable VAR in the scalar values of n and its
+// descendants. $${ is replaced with a literal ${. Values are replaced after parsing, so that they cannot change the
+// structure of the document; mapping keys and comments are left as they are. Undefined variables are reported as
+// problems.
+func interpolate(n *yaml.Node) []Problem {
+	var problems []Problem
+
+	switch n.Kind {
+	case yaml.ScalarNode:
+		problems = interpolateScalar(n)
+	case yaml.MappingNode:
+		for i := 1; i < len(n.Content); i += 2 {
+			problems = append(problems, interpolate(n.Content[i])...)
+		}
+	default:
+		for _, c := range n.Content {
+			problems = append(problems, interpolate(c)...)
+		}
+	}
+
+	return problems
+}
+
+func interpolateScalar(n *yaml.Node) []Problem {
+	var problems []Problem
+
+	value := interpolationRegexp.ReplaceAllStringFunc(n.Value, func(m string) string {
+		if m == "$${" {
+			return "${"
+		}
+
+		name := m[2 : len(m)-1]
+
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			problems = append(problems, Problem{
+				Line:    n.Line,
+				Message: fmt.Sprintf("${%s}: undefined variable", name),
+			})
+		}
+
+		return v
+	})
+
+	if value == n.Value {
+		return problems
+	}
+
+	n.Value = value
+
+	// Plain scalars without an explicit tag are resolved again, so that variables can hold numbers or booleans.
+	if n.Style&(yaml.TaggedStyle|yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle|yaml.LiteralStyle|yaml.FoldedStyle) == 0 {
+		n.Tag = ""
+	}
+
+	return problems
+}
+
+var unmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+
+// unknownKeys reports the mapping keys in n that match no field of t, like strict YAML decoding does.
+func unknownKeys(n *yaml.Node, t reflect.Type) []Problem {
+	if n.Kind == yaml.DocumentNode {
+		if len(n.Content) == 0 {
+			return nil
+		}
+
+		n = n.Content[0]
+	}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if reflect.PtrTo(t).Implements(unmarshalerType) {
+		return nil
+	}
+
+	var problems []Problem
+
+	switch {
+	case t.Kind() == reflect.Struct && t != timeType && n.Kind == yaml.MappingNode:
+		fields := make(map[string]reflect.Type)
+
+		for i := 0; i < t.NumField(); i++ {
+			if name := yamlName(t.Field(i)); name != "-" {
+				fields[name] = t.Field(i).Type
+			}
+		}
+
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key := n.Content[i]
+
+			ft, ok := fields[key.Value]
+			if !ok {
+				problems = append(problems, Problem{
+					Line:    key.Line,
+					Message: fmt.Sprintf("field %s not found in type %s", key.Value, t),
+				})
+
+				continue
+			}
+
+			problems = append(problems, unknownKeys(n.Content[i+1], ft)...)
+		}
+	case t.Kind() == reflect.Map && n.Kind == yaml.MappingNode:
+		for i := 1; i < len(n.Content); i += 2 {
+			problems = append(problems, unknownKeys(n.Content[i], t.Elem())...)
+		}
+	case (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && n.Kind == yaml.SequenceNode:
+		for _, c := range n.Content {
+			problems = append(problems, unknownKeys(c, t.Elem())...)
+		}
+	}
+
+	return problems
+}
+
+// override is a value set outside the configuration file.
+type override struct {
+	path   string
+	source string
+	value  string
+}
+
+// envOverrides returns the overrides found in the environment for each of paths, sorted by path.
+func envOverrides(paths map[string]bool) []override {
+	overrides := make([]override, 0)
+
+	for p := range paths {
+		name := EnvPrefix + strings.ToUpper(strings.ReplaceAll(p, ".", "_"))
+
+		if v, ok := os.LookupEnv(name); ok {
+			overrides = append(overrides, override{path: p, source: name, value: v})
+		}
+	}
+
+	sort.Slice(overrides, func(i, j int) bool {
+		return overrides[i].path < overrides[j].path
+	})
+
+	return overrides
+}
+
+// parseSets parses sets in path=value form.
+func parseSets(sets []string) ([]override, []Problem) {
+	var (
+		overrides []override
+		problems  []Problem
+	)
+
+	for _, s := range sets {
+		path, value, ok := strings.Cut(s, "=")
+		if !ok || path == "" {
+			problems = append(problems, Problem{Message: fmt.Sprintf("--set %q: format is path=value", s)})
+			continue
+		}
+
+		overrides = append(overrides, override{path: path, source: "--set", value: value})
+	}
+
+	return overrides, problems
+}
+
+// apply decodes the override on top of cfg. The value is parsed as YAML, so that lists and maps can be set.
+func (o override) apply(cfg *Config, paths map[string]bool) error {
+	if !paths[o.path] {
+		return fmt.Errorf("%s: unknown configuration key", o.path)
+	}
+
+	value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str"}
+
+	doc := yaml.Node{}
+
+	if err := yaml.Unmarshal([]byte(o.value), &doc); err != nil {
+		return fmt.Errorf("%s: could not parse %q: %v", o.path, o.value, err)
+	}
+
+	if len(doc.Content) > 0 {
+		value = doc.Content[0]
+	}
+
+	keys := strings.Split(o.path, ".")
+
+	for i := len(keys) - 1; i >= 0; i-- {
+		value = &yaml.Node{
+			Kind:    yaml.MappingNode,
+			Content: []*yaml.Node{{Kind: yaml.ScalarNode, Value: keys[i]}, value},
+		}
+	}
+
+	if err := value.Decode(cfg); err != nil {
+		return fmt.Errorf("%s: %v", o.path, err)
+	}
+
+	return nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// leafPaths returns the paths of all values in t that are not structs, using the same key names as YAML decoding.
+func leafPaths(t reflect.Type) map[string]bool {
+	paths := make(map[string]bool)
+
+	var walk func(t reflect.Type, prefix string)
+
+	walk = func(t reflect.Type, prefix string) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+
+			name := yamlName(f)
+
+			if name == "-" {
+				continue
+			}
+
+			if f.Type.Kind() == reflect.Struct && f.Type != timeType {
+				walk(f.Type, prefix+name+".")
+				continue
+			}
+
+			paths[prefix+name] = true
+		}
+	}
+
+	walk(t, "")
+
+	return paths
+}
+
+// yamlName returns the key of f in YAML documents, or - if f is not decoded.
+func yamlName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+
+	if name == "" {
+		name = strings.ToLower(f.Name)
+	}
+
+	return name
+}
@@ -0,0 +1,321 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/rh-ecosystem-edge/gitstream/internal/markup"
+	"gopkg.in/yaml.v3"
+)
+
+// Problem is an invalid value or an unknown key in the configuration.
+type Problem struct {
+	// Line is the line of the problem in the configuration file, or 0 if the value was not set in the file.
+	Line    int
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Line == 0 {
+		return p.Message
+	}
+
+	return fmt.Sprintf("line %d: %s", p.Line, p.Message)
+}
+
+// ValidationError is returned by ReadConfig when the configuration is invalid. It lists all problems found.
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	items := make([]string, 0, len(e.Problems))
+
+	for _, p := range e.Problems {
+		items = append(items, p.String())
+	}
+
+	return fmt.Sprintf("%d problem(s): %s", len(e.Problems), strings.Join(items, "; "))
+}
+
+var typeErrorRegexp = regexp.MustCompile(`^line (\d+): (.*)$`)
+
+// problemsFromTypeError converts the errors reported by strict YAML decoding into problems.
+func problemsFromTypeError(te *yaml.TypeError) []Problem {
+	problems := make([]Problem, 0, len(te.Errors))
+
+	for _, e := range te.Errors {
+		p := Problem{Message: e}
+
+		if m := typeErrorRegexp.FindStringSubmatch(e); m != nil {
+			p.Line, _ = strconv.Atoi(m[1])
+			p.Message = m[2]
+		}
+
+		problems = append(problems, p)
+	}
+
+	return problems
+}
+
+// validator checks the semantics of a decoded configuration, using the YAML document it was decoded from to find the
+// line of each problem.
+type validator struct {
+	problems []Problem
+	root     *yaml.Node
+	// sources maps the paths of values set outside the configuration file to where they were set.
+	sources map[string]string
+}
+
+// addf records a problem at path, which is a list of mapping keys or sequence indexes.
+func (v *validator) addf(path []string, format string, args ...interface{}) {
+	p := Problem{Message: strings.Join(path, ".") + ": " + fmt.Sprintf(format, args...)}
+
+	for i := len(path); i > 0; i-- {
+		if src, ok := v.sources[strings.Join(path[:i], ".")]; ok {
+			p.Message += " (set by " + src + ")"
+			v.problems = append(v.problems, p)
+
+			return
+		}
+	}
+
+	p.Line = lineOf(v.root, path)
+	v.problems = append(v.problems, p)
+}
+
+// lineOf returns the line of the node at path in root, or of its deepest ancestor present in root. It returns 0 if
+// no part of path is in root.
+func lineOf(root *yaml.Node, path []string) int {
+	n := root
+
+	if n != nil && n.Kind == yaml.DocumentNode && len(n.Content) > 0 {
+		n = n.Content[0]
+	}
+
+	line := 0
+
+	for _, key := range path {
+		if n == nil {
+			break
+		}
+
+		var next *yaml.Node
+
+		switch n.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				if n.Content[i].Value == key {
+					next = n.Content[i+1]
+					break
+				}
+			}
+		case yaml.SequenceNode:
+			if i, err := strconv.Atoi(key); err == nil && i < len(n.Content) {
+				next = n.Content[i]
+			}
+		}
+
+		if next == nil {
+			break
+		}
+
+		n = next
+		line = n.Line
+	}
+
+	return line
+}
+
+func validate(cfg *Config, root *yaml.Node, sources map[string]string) []Problem {
+	v := validator{root: root, sources: sources}
+
+	if len(cfg.CommitMarkup) == 0 {
+		v.addf([]string{"commit_markup"}, "at least one markup is required")
+	}
+
+	for i, m := range cfg.CommitMarkup {
+		path := []string{"commit_markup"}
+
+		if len(cfg.CommitMarkup) > 1 {
+			path = append(path, strconv.Itoa(i))
+		}
+
+		if m == "" {
+			v.addf(path, "empty markup")
+		} else if _, err := markup.NewFinder(m); err != nil {
+			v.addf(path, "%v", err)
+		}
+	}
+
+	ds := cfg.Downstream
+
+	if name := ds.GitHubRepoName; name == "" {
+		v.addf([]string{"downstream", "github_repo_name"}, "required")
+	} else if items := strings.Split(name, "/"); len(items) != 2 || items[0] == "" || items[1] == "" {
+		v.addf([]string{"downstream", "github_repo_name"}, "%q: format is owner/repo", name)
+	}
+
+	if f := ds.OwnersFile; f == "" {
+		v.addf([]string{"downstream", "owners_file"}, "empty path")
+	} else if filepath.IsAbs(f) || strings.HasPrefix(filepath.Clean(f), "..") {
+		v.addf([]string{"downstream", "owners_file"}, "%q: must be a path relative to the repository root", f)
+	}
+
+	if ds.MaxOpenItems < -1 {
+		v.addf([]string{"downstream", "max_open_items"}, "%d: must be positive, or -1 for no limit", ds.MaxOpenItems)
+	}
+
+	if ds.AutoMerge.MaxDiffLines < -1 {
+		v.addf([]string{"downstream", "auto_merge", "max_diff_lines"}, "%d: must be positive, or -1 for no limit", ds.AutoMerge.MaxDiffLines)
+	}
+
+	switch m := ds.AutoMerge.Method; m {
+	case AutoMergeMethodMerge, AutoMergeMethodRebase, AutoMergeMethodSquash:
+	default:
+		v.addf(
+			[]string{"downstream", "auto_merge", "method"},
+			"%q: must be one of %s, %s or %s",
+			m,
+			AutoMergeMethodMerge,
+			AutoMergeMethodSquash,
+			AutoMergeMethodRebase,
+		)
+	}
+
+	switch m := cfg.Upstream.MergeCommits; m {
+	case MergeCommitsAll, MergeCommitsFirstParent, MergeCommitsPR, MergeCommitsSkip:
+	default:
+		v.addf(
+			[]string{"upstream", "merge_commits"},
+			"%q: must be one of %s, %s, %s or %s",
+			m,
+			MergeCommitsAll,
+			MergeCommitsFirstParent,
+			MergeCommitsPR,
+			MergeCommitsSkip,
+		)
+	}
+
+	if u := cfg.Upstream.URL; u != "" {
+		if _, err := transport.NewEndpoint(u); err != nil {
+			v.addf([]string{"upstream", "url"}, "%q: invalid URL: %v", u, err)
+		}
+	}
+
+	for i, r := range cfg.Rules {
+		rulePath := []string{"rules", strconv.Itoa(i)}
+
+		if r.Action != RuleActionInclude && r.Action != RuleActionExclude {
+			v.addf(append(rulePath, "action"), "%q: must be %s or %s", r.Action, RuleActionInclude, RuleActionExclude)
+		}
+
+		for j, a := range r.Authors {
+			if _, err := regexp.Compile(a); err != nil {
+				v.addf(append(rulePath, "authors", strconv.Itoa(j)), "%q: invalid regular expression: %v", a, err)
+			}
+		}
+
+		if r.NewerThan < 0 {
+			v.addf(append(rulePath, "newer_than"), "%v: must not be negative", r.NewerThan)
+		}
+
+		if r.OlderThan < 0 {
+			v.addf(append(rulePath, "older_than"), "%v: must not be negative", r.OlderThan)
+		}
+
+		for j, pattern := range r.Paths {
+			if _, err := path.Match(strings.TrimSuffix(pattern, "/"), ""); err != nil {
+				v.addf(append(rulePath, "paths", strconv.Itoa(j)), "%q: invalid pattern: %v", pattern, err)
+			}
+		}
+
+		if _, err := regexp.Compile(r.Subject); err != nil {
+			v.addf(append(rulePath, "subject"), "%q: invalid regular expression: %v", r.Subject, err)
+		}
+	}
+
+	if cfg.Serve.Debounce < 0 {
+		v.addf([]string{"serve", "debounce"}, "%v: must not be negative", cfg.Serve.Debounce)
+	}
+
+	// Both paths are served by the same mux, which rejects empty and duplicate patterns.
+	if cfg.Metrics.Path == "" {
+		v.addf([]string{"metrics", "path"}, "empty path")
+	}
+
+	if p := cfg.Serve.Path; p == "" {
+		v.addf([]string{"serve", "path"}, "empty path")
+	} else if p == cfg.Metrics.Path {
+		v.addf([]string{"serve", "path"}, "%q: must differ from metrics.path", p)
+	}
+
+	for i, cmd := range cfg.Sync.BeforeCommit {
+		if len(cmd) == 0 || cmd[0] == "" {
+			v.addf([]string{"sync", "before_commit", strconv.Itoa(i)}, "empty command")
+		}
+	}
+
+	switch b := cfg.Sync.Backend; b {
+	case SyncBackendGit, SyncBackendGoGit:
+	default:
+		v.addf([]string{"sync", "backend"}, "%q: must be %s or %s", b, SyncBackendGit, SyncBackendGoGit)
+	}
+
+	for i, h := range cfg.Sync.Hooks {
+		if len(h.Command) == 0 || h.Command[0] == "" {
+			v.addf([]string{"sync", "hooks", strconv.Itoa(i), "command"}, "empty command")
+		}
+
+		switch h.Stage {
+		case HookStageAfterCherryPick, HookStageAfterCommit, HookStageAfterPush, HookStageBeforeCommit, HookStageOnFailure:
+		default:
+			v.addf(
+				[]string{"sync", "hooks", strconv.Itoa(i), "stage"},
+				"%q: must be one of %s, %s, %s, %s or %s",
+				h.Stage,
+				HookStageAfterCherryPick,
+				HookStageBeforeCommit,
+				HookStageAfterCommit,
+				HookStageAfterPush,
+				HookStageOnFailure,
+			)
+		}
+
+		if h.Timeout < 0 {
+			v.addf([]string{"sync", "hooks", strconv.Itoa(i), "timeout"}, "%v: must not be negative", h.Timeout)
+		}
+	}
+
+	switch f := cfg.Sync.Signing.Format; f {
+	case "":
+	case SigningFormatGPG, SigningFormatSSH:
+		if cfg.Sync.Signing.KeyFile == "" {
+			v.addf([]string{"sync", "signing", "key_file"}, "required when signing.format is set")
+		}
+	default:
+		v.addf([]string{"sync", "signing", "format"}, "%q: must be %s or %s, or empty to not sign commits", f, SigningFormatGPG, SigningFormatSSH)
+	}
+
+	if cfg.Sync.Workers < 1 {
+		v.addf([]string{"sync", "workers"}, "%d: must be at least 1", cfg.Sync.Workers)
+	}
+
+	if _, err := template.New("").Parse(cfg.Tags.NameTemplate); err != nil {
+		v.addf([]string{"tags", "name_template"}, "%q: invalid template: %v", cfg.Tags.NameTemplate, err)
+	}
+
+	for i, pattern := range cfg.Tags.Patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			v.addf([]string{"tags", "patterns", strconv.Itoa(i)}, "%q: invalid pattern: %v", pattern, err)
+		}
+	}
+
+	return v.problems
+}
@@ -2,25 +2,48 @@ package github
 
 import (
 	"errors"
+	"time"
 
+	"github.com/rh-ecosystem-edge/gitstream/internal/hooks"
+	"github.com/rh-ecosystem-edge/gitstream/internal/merge"
 	"github.com/rh-ecosystem-edge/gitstream/internal/process"
 )
 
+// Content is the rendered title and body of a PR or an issue.
+type Content struct {
+	Body  string `json:"body"`
+	Title string `json:"title"`
+}
+
 type Commit struct {
 	Message string
 	SHA     string
 }
 
+// PullRequest is the upstream pull request that introduced a commit.
+type PullRequest struct {
+	// Author is the GitHub login of the author of the PR.
+	Author   string
+	Labels   []string
+	MergedAt *time.Time
+	Number   int
+	Title    string
+	URL      string
+}
+
 type BaseData struct {
-	AppName     string
-	Commit      Commit
-	Markup      string
+	AppName string
+	Commit  Commit
+	Markup  string
+	// UpstreamPR is nil if the upstream PR of the commit is unknown.
+	UpstreamPR  *PullRequest
 	UpstreamURL string
 }
 
 type IssueData struct {
 	BaseData
-	Error error
+	Error       error
+	HookResults []hooks.Result
 }
 
 func (is *IssueData) ProcessError() *process.Error {
@@ -33,4 +56,56 @@ func (is *IssueData) ProcessError() *process.Error {
 	return nil
 }
 
-type PRData BaseData
+func (is *IssueData) ConflictError() *merge.ConflictError {
+	ce := &merge.ConflictError{}
+
+	if errors.As(is.Error, &ce) {
+		return ce
+	}
+
+	return nil
+}
+
+type PRData struct {
+	BaseData
+	HookResults []hooks.Result
+}
+
+// MergeCommit is an upstream commit newly included by a merge PR.
+type MergeCommit struct {
+	SHA     string
+	Subject string
+}
+
+// MergeData describes the merge of the upstream ref into the downstream main branch. Conflicts is only set when the
+// merge could not be done.
+type MergeData struct {
+	AppName     string
+	BaseBranch  string
+	Commits     []MergeCommit
+	Conflicts   []merge.Conflict
+	UpstreamRef string
+	UpstreamSHA string
+	UpstreamURL string
+}
+
+// RebaseCarry is a downstream-only commit replayed in a rebase PR. Outcome is applied, conflicted or empty.
+type RebaseCarry struct {
+	Outcome string
+	SHA     string
+	Subject string
+}
+
+type RebaseData struct {
+	AppName     string
+	BaseBranch  string
+	Carries     []RebaseCarry
+	UpstreamRef string
+	UpstreamSHA string
+	UpstreamURL string
+}
+
+type RefreshData struct {
+	IssueData
+	BaseBranch string
+}
@@ -1,21 +1,23 @@
 package github
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/google/go-github/v47/github"
 	"github.com/rh-ecosystem-edge/gitstream/internal"
+	"github.com/rh-ecosystem-edge/gitstream/internal/hooks"
 )
 
 //go:generate mockgen -source=issue.go -package=github -destination=mock_issue.go
 
 type IssueHelper interface {
-	Create(ctx context.Context, err error, upstreamURL string, commit *object.Commit) (*github.Issue, error)
+	Create(ctx context.Context, err error, upstreamURL string, commit *object.Commit, upstreamPR *PullRequest, hookResults []hooks.Result) (*github.Issue, error)
+	CreateFromContent(ctx context.Context, content *Content) (*github.Issue, error)
 	ListAllOpen(ctx context.Context, includePRs bool) ([]*github.Issue, error)
 	Assign(ctx context.Context, issue *github.Issue, usersLogin ...string) error
+	Render(err error, upstreamURL string, commit *object.Commit, upstreamPR *PullRequest, hookResults []hooks.Result) (*Content, error)
 }
 
 type IssueHelperImpl struct {
@@ -32,35 +34,20 @@ func NewIssueHelper(gc *github.Client, markup string, name *RepoName) *IssueHelp
 	}
 }
 
-func (ih *IssueHelperImpl) Create(ctx context.Context, err error, upstreamURL string, commit *object.Commit) (*github.Issue, error) {
-	sha := commit.Hash.String()
-
-	data := IssueData{
-		BaseData: BaseData{
-			AppName: internal.AppName,
-			Commit: Commit{
-				Message: commit.Message,
-				SHA:     sha,
-			},
-			Markup:      ih.markup,
-			UpstreamURL: upstreamURL,
-		},
-		Error: err,
+func (ih *IssueHelperImpl) Create(ctx context.Context, err error, upstreamURL string, commit *object.Commit, upstreamPR *PullRequest, hookResults []hooks.Result) (*github.Issue, error) {
+	content, renderErr := ih.Render(err, upstreamURL, commit, upstreamPR, hookResults)
+	if renderErr != nil {
+		return nil, renderErr
 	}
 
-	var buf bytes.Buffer
-
-	if err := templates.ExecuteTemplate(&buf, "issue.tmpl", &data); err != nil {
-		return nil, fmt.Errorf("could not execute issue template: %v", err)
-	}
+	return ih.CreateFromContent(ctx, content)
+}
 
+// CreateFromContent creates a labeled issue with a title and a body that were rendered beforehand.
+func (ih *IssueHelperImpl) CreateFromContent(ctx context.Context, content *Content) (*github.Issue, error) {
 	req := github.IssueRequest{
-		Title: github.String(
-			fmt.Sprintf("Cherry-picking error for `%s`", sha),
-		),
-		Body: github.String(
-			buf.String(),
-		),
+		Title:  github.String(content.Title),
+		Body:   github.String(content.Body),
 		Labels: &[]string{internal.GitStreamLabel},
 	}
 
@@ -72,6 +59,26 @@ func (ih *IssueHelperImpl) Create(ctx context.Context, err error, upstreamURL st
 	return issue, err
 }
 
+// Render returns the title and the body of the issue that Create would open for commit. upstreamPR may be nil.
+func (ih *IssueHelperImpl) Render(err error, upstreamURL string, commit *object.Commit, upstreamPR *PullRequest, hookResults []hooks.Result) (*Content, error) {
+	data := IssueData{
+		BaseData: BaseData{
+			AppName: internal.AppName,
+			Commit: Commit{
+				Message: commit.Message,
+				SHA:     commit.Hash.String(),
+			},
+			Markup:      ih.markup,
+			UpstreamPR:  upstreamPR,
+			UpstreamURL: upstreamURL,
+		},
+		Error:       err,
+		HookResults: hookResults,
+	}
+
+	return render("issue_title.tmpl", "issue.tmpl", &data)
+}
+
 func (ih *IssueHelperImpl) ListAllOpen(ctx context.Context, includePRs bool) ([]*github.Issue, error) {
 	i := make([]*github.Issue, 0)
 
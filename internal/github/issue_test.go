@@ -14,6 +14,8 @@ import (
 	"github.com/google/go-github/v47/github"
 	"github.com/migueleliasweb/go-github-mock/src/mock"
 	gh "github.com/rh-ecosystem-edge/gitstream/internal/github"
+	"github.com/rh-ecosystem-edge/gitstream/internal/hooks"
+	"github.com/rh-ecosystem-edge/gitstream/internal/merge"
 	"github.com/rh-ecosystem-edge/gitstream/internal/process"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -76,12 +78,155 @@ func TestIssueHelper_Create(t *testing.T) {
 			errors.New("random error"),
 			"some-upstream-url",
 			commit,
+			nil,
+			nil,
 		)
 
 		assert.NoError(t, err)
 		assert.Equal(t, issue, res)
 	})
 
+	t.Run("conflict error", func(t *testing.T) {
+		const expectedBody = "gitstream tried to cherry-pick commit `e3229f3c533ed51070beff092e5c7694a8ee81f0` from `some-upstream-url` but was unable to do so.\n" +
+			"\n" +
+			"Commit message:\n" +
+			"```\n" +
+			"Some commit message\n" +
+			"spanning over two lines.\n" +
+			"```\n\n" +
+			"Please cherry-pick the commit manually.\n\n" +
+			"---\n\n" +
+			"**Error**:\n" +
+			"```\n" +
+			"could not cherry-pick: 2 conflict(s): a.txt: content modified upstream and downstream; b.txt: deleted upstream and modified downstream\n" +
+			"```\n" +
+			"---\n\n" +
+			"**Conflicts**:\n\n" +
+			"- `a.txt`: content modified upstream and downstream\n" +
+			"- `b.txt`: deleted upstream and modified downstream\n\n" +
+			"<details><summary><code>a.txt</code></summary>\n\n" +
+			"```\n" +
+			"<<<<<<< downstream\nours\n=======\ntheirs\n>>>>>>> upstream\n" +
+			"```\n\n" +
+			"</details>\n\n\n" +
+			"---\n\n" +
+			"Markup: e3229f3c533ed51070beff092e5c7694a8ee81f0"
+
+		c := mock.NewMockedHTTPClient(
+			mock.WithRequestMatchHandler(
+				mock.PostReposIssuesByOwnerByRepo,
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					m := make(map[string]interface{})
+
+					assert.NoError(
+						t,
+						json.NewDecoder(r.Body).Decode(&m),
+					)
+
+					assert.Equal(t, expectedBody, m["body"])
+					assert.NoError(
+						t,
+						json.NewEncoder(w).Encode(issue),
+					)
+				}),
+			),
+		)
+
+		gc := github.NewClient(c)
+
+		ce := &merge.ConflictError{
+			Conflicts: []merge.Conflict{
+				{
+					Details: "<<<<<<< downstream\nours\n=======\ntheirs\n>>>>>>> upstream\n",
+					Path:    "a.txt",
+					Reason:  "content modified upstream and downstream",
+				},
+				{Path: "b.txt", Reason: "deleted upstream and modified downstream"},
+			},
+		}
+
+		_, err := gh.NewIssueHelper(gc, "Markup", repoName).Create(
+			context.Background(),
+			fmt.Errorf("could not cherry-pick: %w", ce),
+			"some-upstream-url",
+			commit,
+			nil,
+			nil,
+		)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("hook results", func(t *testing.T) {
+		const expectedBody = "gitstream tried to cherry-pick commit `e3229f3c533ed51070beff092e5c7694a8ee81f0` from `some-upstream-url` but was unable to do so.\n" +
+			"\n" +
+			"Commit message:\n" +
+			"```\n" +
+			"Some commit message\n" +
+			"spanning over two lines.\n" +
+			"```\n\n" +
+			"Please cherry-pick the commit manually.\n\n" +
+			"---\n\n" +
+			"**Error**:\n" +
+			"```\n" +
+			"random error\n" +
+			"```\n" +
+			"---\n\n" +
+			"**Hooks**:\n\n" +
+			"<details><summary><code>lint</code> (after-cherry-pick): lint error</summary>\n\n" +
+			"**Command**: `make lint`\n\n" +
+			"```\n" +
+			"lint output\n" +
+			"```\n\n" +
+			"</details>\n\n" +
+			"<details><summary><code>collect</code> (on-failure)</summary>\n\n" +
+			"**Command**: `collect-logs`\n\n" +
+			"```\n" +
+			"collected\n" +
+			"```\n\n" +
+			"</details>\n\n\n" +
+			"---\n\n" +
+			"Markup: e3229f3c533ed51070beff092e5c7694a8ee81f0"
+
+		c := mock.NewMockedHTTPClient(
+			mock.WithRequestMatchHandler(
+				mock.PostReposIssuesByOwnerByRepo,
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					m := make(map[string]interface{})
+
+					assert.NoError(
+						t,
+						json.NewDecoder(r.Body).Decode(&m),
+					)
+
+					assert.Equal(t, expectedBody, m["body"])
+					assert.NoError(
+						t,
+						json.NewEncoder(w).Encode(issue),
+					)
+				}),
+			),
+		)
+
+		gc := github.NewClient(c)
+
+		results := []hooks.Result{
+			{Command: "make lint", Err: errors.New("lint error"), Name: "lint", Output: "lint output", Stage: hooks.StageAfterCherryPick},
+			{Command: "collect-logs", Name: "collect", Output: "collected", Stage: hooks.StageOnFailure},
+		}
+
+		_, err := gh.NewIssueHelper(gc, "Markup", repoName).Create(
+			context.Background(),
+			errors.New("random error"),
+			"some-upstream-url",
+			commit,
+			nil,
+			results,
+		)
+
+		assert.NoError(t, err)
+	})
+
 	t.Run("process error", func(t *testing.T) {
 		const bodyFmt = "gitstream tried to cherry-pick commit `e3229f3c533ed51070beff092e5c7694a8ee81f0` from `some-upstream-url` but was unable to do so.\n" +
 			"\n" +
@@ -138,7 +283,7 @@ func TestIssueHelper_Create(t *testing.T) {
 
 		ctx2, cancel := context.WithCancel(context.Background())
 
-		cmd := exec.CommandContext(ctx2, "cat")
+		cmd := exec.CommandContext(ctx2, "sleep", "10")
 		err := cmd.Start()
 
 		require.NoError(t, err)
@@ -154,6 +299,8 @@ func TestIssueHelper_Create(t *testing.T) {
 			process.NewError(ee, []byte("some output"), "some-command"),
 			"some-upstream-url",
 			commit,
+			nil,
+			nil,
 		)
 
 		assert.NoError(t, err)
@@ -161,6 +308,27 @@ func TestIssueHelper_Create(t *testing.T) {
 	})
 }
 
+func TestIssueHelper_Render(t *testing.T) {
+	commit := &object.Commit{
+		Hash:    plumbing.NewHash("e3229f3c533ed51070beff092e5c7694a8ee81f0"),
+		Message: "Some commit message",
+	}
+
+	upstreamPR := &gh.PullRequest{Author: "some-user", Number: 123, Title: "Fix the thing", URL: "some-pr-url"}
+
+	content, err := gh.NewIssueHelper(nil, "Markup", &gh.RepoName{Owner: "owner", Repo: "repo"}).Render(
+		errors.New("random error"),
+		"some-upstream-url",
+		commit,
+		upstreamPR,
+		nil,
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Cherry-picking error for `e3229f3c533ed51070beff092e5c7694a8ee81f0`: Fix the thing (#123)", content.Title)
+	assert.Contains(t, content.Body, "but was unable to do so.\n\nUpstream PR: [#123](some-pr-url) Fix the thing\n- **Author**: `some-user`\n\nCommit message:")
+}
+
 func TestIssueHelper_Assign(t *testing.T) {
 
 	issue := &github.Issue{Number: github.Int(456)}
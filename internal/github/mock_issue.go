@@ -11,6 +11,7 @@ import (
 	object "github.com/go-git/go-git/v5/plumbing/object"
 	gomock "github.com/golang/mock/gomock"
 	github "github.com/google/go-github/v47/github"
+	hooks "github.com/rh-ecosystem-edge/gitstream/internal/hooks"
 )
 
 // MockIssueHelper is a mock of IssueHelper interface.
@@ -56,18 +57,33 @@ func (mr *MockIssueHelperMockRecorder) Assign(ctx, issue interface{}, usersLogin
 }
 
 // Create mocks base method.
-func (m *MockIssueHelper) Create(ctx context.Context, err error, upstreamURL string, commit *object.Commit) (*github.Issue, error) {
+func (m *MockIssueHelper) Create(ctx context.Context, err error, upstreamURL string, commit *object.Commit, upstreamPR *PullRequest, hookResults []hooks.Result) (*github.Issue, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", ctx, err, upstreamURL, commit)
+	ret := m.ctrl.Call(m, "Create", ctx, err, upstreamURL, commit, upstreamPR, hookResults)
 	ret0, _ := ret[0].(*github.Issue)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockIssueHelperMockRecorder) Create(ctx, err, upstreamURL, commit interface{}) *gomock.Call {
+func (mr *MockIssueHelperMockRecorder) Create(ctx, err, upstreamURL, commit, upstreamPR, hookResults interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockIssueHelper)(nil).Create), ctx, err, upstreamURL, commit)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockIssueHelper)(nil).Create), ctx, err, upstreamURL, commit, upstreamPR, hookResults)
+}
+
+// CreateFromContent mocks base method.
+func (m *MockIssueHelper) CreateFromContent(ctx context.Context, content *Content) (*github.Issue, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFromContent", ctx, content)
+	ret0, _ := ret[0].(*github.Issue)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateFromContent indicates an expected call of CreateFromContent.
+func (mr *MockIssueHelperMockRecorder) CreateFromContent(ctx, content interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFromContent", reflect.TypeOf((*MockIssueHelper)(nil).CreateFromContent), ctx, content)
 }
 
 // ListAllOpen mocks base method.
@@ -84,3 +100,18 @@ func (mr *MockIssueHelperMockRecorder) ListAllOpen(ctx, includePRs interface{})
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAllOpen", reflect.TypeOf((*MockIssueHelper)(nil).ListAllOpen), ctx, includePRs)
 }
+
+// Render mocks base method.
+func (m *MockIssueHelper) Render(err error, upstreamURL string, commit *object.Commit, upstreamPR *PullRequest, hookResults []hooks.Result) (*Content, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Render", err, upstreamURL, commit, upstreamPR, hookResults)
+	ret0, _ := ret[0].(*Content)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Render indicates an expected call of Render.
+func (mr *MockIssueHelperMockRecorder) Render(err, upstreamURL, commit, upstreamPR, hookResults interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Render", reflect.TypeOf((*MockIssueHelper)(nil).Render), err, upstreamURL, commit, upstreamPR, hookResults)
+}
@@ -11,6 +11,7 @@ import (
 	object "github.com/go-git/go-git/v5/plumbing/object"
 	gomock "github.com/golang/mock/gomock"
 	github "github.com/google/go-github/v47/github"
+	hooks "github.com/rh-ecosystem-edge/gitstream/internal/hooks"
 )
 
 // MockPRHelper is a mock of PRHelper interface.
@@ -36,19 +37,90 @@ func (m *MockPRHelper) EXPECT() *MockPRHelperMockRecorder {
 	return m.recorder
 }
 
+// Close mocks base method.
+func (m *MockPRHelper) Close(ctx context.Context, pr *github.PullRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close", ctx, pr)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockPRHelperMockRecorder) Close(ctx, pr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockPRHelper)(nil).Close), ctx, pr)
+}
+
+// CommentError mocks base method.
+func (m *MockPRHelper) CommentError(ctx context.Context, pr *github.PullRequest, err error, upstreamURL string, commit *object.Commit) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CommentError", ctx, pr, err, upstreamURL, commit)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CommentError indicates an expected call of CommentError.
+func (mr *MockPRHelperMockRecorder) CommentError(ctx, pr, err, upstreamURL, commit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommentError", reflect.TypeOf((*MockPRHelper)(nil).CommentError), ctx, pr, err, upstreamURL, commit)
+}
+
+// ConvertToDraft mocks base method.
+func (m *MockPRHelper) ConvertToDraft(ctx context.Context, pr *github.PullRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConvertToDraft", ctx, pr)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConvertToDraft indicates an expected call of ConvertToDraft.
+func (mr *MockPRHelperMockRecorder) ConvertToDraft(ctx, pr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConvertToDraft", reflect.TypeOf((*MockPRHelper)(nil).ConvertToDraft), ctx, pr)
+}
+
 // Create mocks base method.
-func (m *MockPRHelper) Create(ctx context.Context, branch, base, upstreamURL string, commit *object.Commit, draft bool) (*github.PullRequest, error) {
+func (m *MockPRHelper) Create(ctx context.Context, branch, base, upstreamURL string, commit *object.Commit, upstreamPR *PullRequest, draft bool, hookResults []hooks.Result) (*github.PullRequest, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", ctx, branch, base, upstreamURL, commit, draft)
+	ret := m.ctrl.Call(m, "Create", ctx, branch, base, upstreamURL, commit, upstreamPR, draft, hookResults)
 	ret0, _ := ret[0].(*github.PullRequest)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockPRHelperMockRecorder) Create(ctx, branch, base, upstreamURL, commit, draft interface{}) *gomock.Call {
+func (mr *MockPRHelperMockRecorder) Create(ctx, branch, base, upstreamURL, commit, upstreamPR, draft, hookResults interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockPRHelper)(nil).Create), ctx, branch, base, upstreamURL, commit, draft)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockPRHelper)(nil).Create), ctx, branch, base, upstreamURL, commit, upstreamPR, draft, hookResults)
+}
+
+// CreateFromContent mocks base method.
+func (m *MockPRHelper) CreateFromContent(ctx context.Context, branch, base string, content *Content, draft bool) (*github.PullRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFromContent", ctx, branch, base, content, draft)
+	ret0, _ := ret[0].(*github.PullRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateFromContent indicates an expected call of CreateFromContent.
+func (mr *MockPRHelperMockRecorder) CreateFromContent(ctx, branch, base, content, draft interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFromContent", reflect.TypeOf((*MockPRHelper)(nil).CreateFromContent), ctx, branch, base, content, draft)
+}
+
+// EnableAutoMerge mocks base method.
+func (m *MockPRHelper) EnableAutoMerge(ctx context.Context, pr *github.PullRequest, method string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableAutoMerge", ctx, pr, method)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnableAutoMerge indicates an expected call of EnableAutoMerge.
+func (mr *MockPRHelperMockRecorder) EnableAutoMerge(ctx, pr, method interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableAutoMerge", reflect.TypeOf((*MockPRHelper)(nil).EnableAutoMerge), ctx, pr, method)
 }
 
 // ListAllOpen mocks base method.
@@ -79,3 +151,32 @@ func (mr *MockPRHelperMockRecorder) MakeReady(ctx, pr interface{}) *gomock.Call
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MakeReady", reflect.TypeOf((*MockPRHelper)(nil).MakeReady), ctx, pr)
 }
+
+// Render mocks base method.
+func (m *MockPRHelper) Render(upstreamURL string, commit *object.Commit, upstreamPR *PullRequest, hookResults []hooks.Result) (*Content, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Render", upstreamURL, commit, upstreamPR, hookResults)
+	ret0, _ := ret[0].(*Content)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Render indicates an expected call of Render.
+func (mr *MockPRHelperMockRecorder) Render(upstreamURL, commit, upstreamPR, hookResults interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Render", reflect.TypeOf((*MockPRHelper)(nil).Render), upstreamURL, commit, upstreamPR, hookResults)
+}
+
+// UpdateContent mocks base method.
+func (m *MockPRHelper) UpdateContent(ctx context.Context, pr *github.PullRequest, content *Content) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateContent", ctx, pr, content)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateContent indicates an expected call of UpdateContent.
+func (mr *MockPRHelperMockRecorder) UpdateContent(ctx, pr, content interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateContent", reflect.TypeOf((*MockPRHelper)(nil).UpdateContent), ctx, pr, content)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/google/go-github/v47/github"
 	"github.com/rh-ecosystem-edge/gitstream/internal"
+	"github.com/rh-ecosystem-edge/gitstream/internal/hooks"
 	"github.com/shurcooL/githubv4"
 )
 
@@ -18,9 +19,16 @@ type PRFilterFunc = func(*github.PullRequest) bool
 //go:generate mockgen -source=pr.go -package=github -destination=mock_pr.go
 
 type PRHelper interface {
-	Create(ctx context.Context, branch, base, upstreamURL string, commit *object.Commit, draft bool) (*github.PullRequest, error)
+	Close(ctx context.Context, pr *github.PullRequest) error
+	CommentError(ctx context.Context, pr *github.PullRequest, err error, upstreamURL string, commit *object.Commit) error
+	ConvertToDraft(ctx context.Context, pr *github.PullRequest) error
+	Create(ctx context.Context, branch, base, upstreamURL string, commit *object.Commit, upstreamPR *PullRequest, draft bool, hookResults []hooks.Result) (*github.PullRequest, error)
+	CreateFromContent(ctx context.Context, branch, base string, content *Content, draft bool) (*github.PullRequest, error)
+	EnableAutoMerge(ctx context.Context, pr *github.PullRequest, method string) error
 	ListAllOpen(ctx context.Context, filter PRFilterFunc) ([]*github.PullRequest, error)
 	MakeReady(ctx context.Context, pr *github.PullRequest) error
+	Render(upstreamURL string, commit *object.Commit, upstreamPR *PullRequest, hookResults []hooks.Result) (*Content, error)
+	UpdateContent(ctx context.Context, pr *github.PullRequest, content *Content) error
 }
 
 type PRHelperImpl struct {
@@ -39,32 +47,89 @@ func NewPRHelper(gc *github.Client, ghgql api.GQLClient, markup string, repoName
 	}
 }
 
-func (ph *PRHelperImpl) Create(ctx context.Context, branch, base, upstreamURL string, commit *object.Commit, draft bool) (*github.PullRequest, error) {
-	sha := commit.Hash.String()
+func (ph *PRHelperImpl) Close(ctx context.Context, pr *github.PullRequest) error {
+	req := github.PullRequest{State: github.String("closed")}
 
-	data := PRData{
-		AppName: internal.AppName,
-		Commit: Commit{
-			Message: commit.Message,
-			SHA:     sha,
+	if _, _, err := ph.gc.PullRequests.Edit(ctx, ph.repoName.Owner, ph.repoName.Repo, pr.GetNumber(), &req); err != nil {
+		return fmt.Errorf("could not close PR %d: %v", pr.GetNumber(), err)
+	}
+
+	return nil
+}
+
+func (ph *PRHelperImpl) CommentError(ctx context.Context, pr *github.PullRequest, err error, upstreamURL string, commit *object.Commit) error {
+	data := RefreshData{
+		IssueData: IssueData{
+			BaseData: BaseData{
+				AppName: internal.AppName,
+				Commit: Commit{
+					Message: commit.Message,
+					SHA:     commit.Hash.String(),
+				},
+				Markup:      ph.markup,
+				UpstreamURL: upstreamURL,
+			},
+			Error: err,
 		},
-		Markup:      ph.markup,
-		UpstreamURL: upstreamURL,
+		BaseBranch: pr.GetBase().GetRef(),
 	}
 
 	var buf bytes.Buffer
 
-	if err := templates.ExecuteTemplate(&buf, "pr.tmpl", data); err != nil {
-		return nil, fmt.Errorf("could not execute template: %v", err)
+	if err := templates.ExecuteTemplate(&buf, "refresh.tmpl", &data); err != nil {
+		return fmt.Errorf("could not execute template: %v", err)
 	}
 
-	req := github.NewPullRequest{
-		Title: github.String(
-			fmt.Sprintf("Cherry-pick `%s` from upstream", sha),
-		),
+	comment := github.IssueComment{
 		Body: github.String(
 			buf.String(),
 		),
+	}
+
+	if _, _, err := ph.gc.Issues.CreateComment(ctx, ph.repoName.Owner, ph.repoName.Repo, *pr.Number, &comment); err != nil {
+		return fmt.Errorf("could not comment on PR %d: %v", *pr.Number, err)
+	}
+
+	return nil
+}
+
+func (ph *PRHelperImpl) ConvertToDraft(ctx context.Context, pr *github.PullRequest) error {
+	if *pr.Draft {
+		return errors.New("PR is already a draft")
+	}
+
+	// Use GraphQL as the REST API does not support converting a PR to draft
+	var mutation struct {
+		ConvertPullRequestToDraft struct {
+			PullRequest struct {
+				ID githubv4.ID
+			}
+		} `graphql:"convertPullRequestToDraft(input: $input)"`
+	}
+
+	variables := map[string]interface{}{
+		"input": githubv4.ConvertPullRequestToDraftInput{
+			PullRequestID: pr.NodeID,
+		},
+	}
+
+	return ph.ghgql.MutateWithContext(ctx, "ConvertPullRequestToDraft", &mutation, variables)
+}
+
+func (ph *PRHelperImpl) Create(ctx context.Context, branch, base, upstreamURL string, commit *object.Commit, upstreamPR *PullRequest, draft bool, hookResults []hooks.Result) (*github.PullRequest, error) {
+	content, err := ph.Render(upstreamURL, commit, upstreamPR, hookResults)
+	if err != nil {
+		return nil, err
+	}
+
+	return ph.CreateFromContent(ctx, branch, base, content, draft)
+}
+
+// CreateFromContent creates a labeled PR with a title and a body that were rendered beforehand.
+func (ph *PRHelperImpl) CreateFromContent(ctx context.Context, branch, base string, content *Content, draft bool) (*github.PullRequest, error) {
+	req := github.NewPullRequest{
+		Title: github.String(content.Title),
+		Body:  github.String(content.Body),
 		Head:  github.String(branch),
 		Base:  github.String(base),
 		Draft: &draft,
@@ -83,6 +148,66 @@ func (ph *PRHelperImpl) Create(ctx context.Context, branch, base, upstreamURL st
 	return pr, nil
 }
 
+// Render returns the title and the body of the PR that Create would open for commit. upstreamPR may be nil.
+func (ph *PRHelperImpl) Render(upstreamURL string, commit *object.Commit, upstreamPR *PullRequest, hookResults []hooks.Result) (*Content, error) {
+	data := PRData{
+		BaseData: BaseData{
+			AppName: internal.AppName,
+			Commit: Commit{
+				Message: commit.Message,
+				SHA:     commit.Hash.String(),
+			},
+			Markup:      ph.markup,
+			UpstreamPR:  upstreamPR,
+			UpstreamURL: upstreamURL,
+		},
+		HookResults: hookResults,
+	}
+
+	return render("pr_title.tmpl", "pr.tmpl", data)
+}
+
+// RenderRebase returns the title and the body of a PR that replays downstream-only commits on top of upstream.
+func RenderRebase(data RebaseData) (*Content, error) {
+	return render("rebase_title.tmpl", "rebase.tmpl", data)
+}
+
+// RenderMerge returns the title and the body of a PR that merges the upstream ref into the downstream main branch.
+func RenderMerge(data MergeData) (*Content, error) {
+	return render("merge_title.tmpl", "merge.tmpl", data)
+}
+
+// RenderMergeConflict returns the title and the body of an issue reporting that the upstream ref could not be merged
+// into the downstream main branch.
+func RenderMergeConflict(data MergeData) (*Content, error) {
+	return render("merge_conflict_title.tmpl", "merge_conflict.tmpl", data)
+}
+
+func (ph *PRHelperImpl) EnableAutoMerge(ctx context.Context, pr *github.PullRequest, method string) error {
+	mergeMethod, err := ParseMergeMethod(method)
+	if err != nil {
+		return err
+	}
+
+	// Use GraphQL as the REST API does not support enabling auto-merge
+	var mutation struct {
+		EnablePullRequestAutoMerge struct {
+			PullRequest struct {
+				ID githubv4.ID
+			}
+		} `graphql:"enablePullRequestAutoMerge(input: $input)"`
+	}
+
+	variables := map[string]interface{}{
+		"input": githubv4.EnablePullRequestAutoMergeInput{
+			PullRequestID: pr.NodeID,
+			MergeMethod:   &mergeMethod,
+		},
+	}
+
+	return ph.ghgql.MutateWithContext(ctx, "EnablePullRequestAutoMerge", &mutation, variables)
+}
+
 func (ph *PRHelperImpl) ListAllOpen(ctx context.Context, filter PRFilterFunc) ([]*github.PullRequest, error) {
 	p := make([]*github.PullRequest, 0)
 
@@ -139,6 +264,35 @@ func (ph *PRHelperImpl) MakeReady(ctx context.Context, pr *github.PullRequest) e
 	return ph.ghgql.MutateWithContext(ctx, "PullRequestReadyForReview", &mutation, variables)
 }
 
+// UpdateContent replaces the title and the body of pr.
+func (ph *PRHelperImpl) UpdateContent(ctx context.Context, pr *github.PullRequest, content *Content) error {
+	req := github.PullRequest{
+		Body:  github.String(content.Body),
+		Title: github.String(content.Title),
+	}
+
+	if _, _, err := ph.gc.PullRequests.Edit(ctx, ph.repoName.Owner, ph.repoName.Repo, pr.GetNumber(), &req); err != nil {
+		return fmt.Errorf("could not update PR %d: %v", pr.GetNumber(), err)
+	}
+
+	return nil
+}
+
+// ParseMergeMethod converts a merge method name as found in the configuration (merge, squash or rebase) into its
+// GraphQL counterpart.
+func ParseMergeMethod(s string) (githubv4.PullRequestMergeMethod, error) {
+	switch s {
+	case "merge":
+		return githubv4.PullRequestMergeMethodMerge, nil
+	case "squash":
+		return githubv4.PullRequestMergeMethodSquash, nil
+	case "rebase":
+		return githubv4.PullRequestMergeMethodRebase, nil
+	default:
+		return "", fmt.Errorf("%q: invalid merge method; expected one of merge, squash or rebase", s)
+	}
+}
+
 func PRHasLabel(pr *github.PullRequest, label string) bool {
 	for _, l := range pr.Labels {
 		if *l.Name == label {
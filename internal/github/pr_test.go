@@ -3,16 +3,20 @@ package github_test
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/google/go-github/v47/github"
 	"github.com/migueleliasweb/go-github-mock/src/mock"
 	gh "github.com/rh-ecosystem-edge/gitstream/internal/github"
+	"github.com/rh-ecosystem-edge/gitstream/internal/merge"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPRHelperImpl_Create(t *testing.T) {
@@ -89,9 +93,233 @@ func TestPRHelperImpl_Create(t *testing.T) {
 			Hash:    plumbing.NewHash("e3229f3c533ed51070beff092e5c7694a8ee81f0"),
 			Message: "Some commit message\nspreading over two lines.",
 		},
+		nil,
 		draft,
+		nil,
 	)
 
 	assert.NoError(t, err)
 	assert.Equal(t, pr, res)
 }
+
+func TestPRHelperImpl_Render(t *testing.T) {
+	mergedAt := time.Date(2023, 5, 4, 3, 2, 1, 0, time.UTC)
+
+	upstreamPR := &gh.PullRequest{
+		Author:   "some-user",
+		Labels:   []string{"kind/bug", "lgtm"},
+		MergedAt: &mergedAt,
+		Number:   123,
+		Title:    "Fix the thing",
+		URL:      "https://github.com/owner/upstream/pull/123",
+	}
+
+	commit := &object.Commit{
+		Hash:    plumbing.NewHash("e3229f3c533ed51070beff092e5c7694a8ee81f0"),
+		Message: "Some commit message",
+	}
+
+	content, err := gh.NewPRHelper(nil, nil, "Markup", &gh.RepoName{Owner: "owner", Repo: "repo"}).Render("some-upstream-url", commit, upstreamPR, nil)
+	require.NoError(t, err)
+
+	expected := &gh.Content{
+		Body: "This is an automated cherry-pick by gitstream of `e3229f3c533ed51070beff092e5c7694a8ee81f0` from `some-upstream-url`.\n\n" +
+			"Upstream PR: [#123](https://github.com/owner/upstream/pull/123) Fix the thing\n" +
+			"- **Author**: `some-user`\n" +
+			"- **Merged**: 2023-05-04 03:02 UTC\n" +
+			"- **Labels**: `kind/bug`, `lgtm`\n\n" +
+			"Commit message:\n" +
+			"```\n" +
+			"Some commit message\n" +
+			"```\n\n" +
+			"---\n\n" +
+			"Markup: e3229f3c533ed51070beff092e5c7694a8ee81f0",
+		Title: "Cherry-pick `e3229f3c533ed51070beff092e5c7694a8ee81f0` from upstream: Fix the thing (#123)",
+	}
+
+	assert.Equal(t, expected, content)
+}
+
+func TestPRHelperImpl_CommentError(t *testing.T) {
+	const (
+		expectedBody = "gitstream tried to refresh this pull request by cherry-picking commit `e3229f3c533ed51070beff092e5c7694a8ee81f0` " +
+			"from `some-upstream-url` onto the latest `main`, but was unable to do so.\n\n" +
+			"The pull request was converted to draft. Please rebase it manually.\n\n" +
+			"---\n\n" +
+			"**Error**:\n" +
+			"```\n" +
+			"random error\n" +
+			"```"
+		owner    = "owner"
+		prNumber = 456
+		repo     = "repo"
+	)
+
+	c := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.PostReposIssuesCommentsByOwnerByRepoByIssueNumber,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				m := make(map[string]interface{})
+
+				assert.NoError(
+					t,
+					json.NewDecoder(r.Body).Decode(&m),
+				)
+
+				assert.Equal(
+					t,
+					fmt.Sprintf("/repos/%s/%s/issues/%d/comments", owner, repo, prNumber),
+					r.RequestURI,
+				)
+
+				assert.Equal(t, expectedBody, m["body"])
+			}),
+		),
+	)
+
+	gc := github.NewClient(c)
+
+	pr := &github.PullRequest{
+		Base:   &github.PullRequestBranch{Ref: github.String("main")},
+		Number: github.Int(prNumber),
+	}
+
+	err := gh.NewPRHelper(gc, nil, "Markup", &gh.RepoName{Owner: owner, Repo: repo}).CommentError(
+		context.Background(),
+		pr,
+		errors.New("random error"),
+		"some-upstream-url",
+		&object.Commit{
+			Hash:    plumbing.NewHash("e3229f3c533ed51070beff092e5c7694a8ee81f0"),
+			Message: "Some commit message",
+		},
+	)
+
+	assert.NoError(t, err)
+}
+
+func TestRenderRebase(t *testing.T) {
+	data := gh.RebaseData{
+		AppName:    "gitstream",
+		BaseBranch: "main",
+		Carries: []gh.RebaseCarry{
+			{Outcome: "applied", SHA: "e3229f3c533ed51070beff092e5c7694a8ee81f0", Subject: "Some carry"},
+			{Outcome: "conflicted", SHA: "a13e0e6a2ae8e52c1d4e8ac68fc2a1a6d0a1e2b4", Subject: "Other carry"},
+		},
+		UpstreamRef: "us-main",
+		UpstreamSHA: "1234567890123456789012345678901234567890",
+		UpstreamURL: "some-upstream-url",
+	}
+
+	content, err := gh.RenderRebase(data)
+	require.NoError(t, err)
+
+	expected := &gh.Content{
+		Body: "This is an automated rebase by gitstream of the downstream-only commits of `main` on top of `us-main` at " +
+			"`1234567890123456789012345678901234567890` from `some-upstream-url`.\n\n" +
+			"This branch is meant to replace `main`: once approved, `main` should be reset to it rather than merged.\n\n" +
+			"| Commit | Subject | Outcome |\n" +
+			"|--------|---------|---------|\n" +
+			"| `e3229f3c533ed51070beff092e5c7694a8ee81f0` | Some carry | applied |\n" +
+			"| `a13e0e6a2ae8e52c1d4e8ac68fc2a1a6d0a1e2b4` | Other carry | conflicted |\n",
+		Title: "Rebase `main` on upstream `us-main` at `1234567890123456789012345678901234567890`",
+	}
+
+	assert.Equal(t, expected, content)
+
+	data.Carries = nil
+
+	content, err = gh.RenderRebase(data)
+	require.NoError(t, err)
+	assert.Contains(t, content.Body, "There are no downstream-only commits.\n")
+}
+
+func TestRenderMerge(t *testing.T) {
+	data := gh.MergeData{
+		AppName:    "gitstream",
+		BaseBranch: "main",
+		Commits: []gh.MergeCommit{
+			{SHA: "e3229f3c533ed51070beff092e5c7694a8ee81f0", Subject: "Some commit"},
+		},
+		UpstreamRef: "us-main",
+		UpstreamSHA: "1234567890123456789012345678901234567890",
+		UpstreamURL: "some-upstream-url",
+	}
+
+	content, err := gh.RenderMerge(data)
+	require.NoError(t, err)
+
+	expected := &gh.Content{
+		Body: "This is an automated merge by gitstream of `us-main` at `1234567890123456789012345678901234567890` from " +
+			"`some-upstream-url` into `main`.\n\n" +
+			"This PR must be merged with a merge commit, so that upstream SHAs are kept in the history of `main`.\n\n" +
+			"It brings the following upstream commits:\n\n" +
+			"| Commit | Subject |\n" +
+			"|--------|---------|\n" +
+			"| `e3229f3c533ed51070beff092e5c7694a8ee81f0` | Some commit |\n",
+		Title: "Merge upstream `us-main` at `1234567890123456789012345678901234567890` into `main`",
+	}
+
+	assert.Equal(t, expected, content)
+
+	data.Conflicts = []merge.Conflict{
+		{Path: "a.txt", Reason: "content"},
+		{Path: "b.txt", Reason: "modify/delete"},
+	}
+
+	content, err = gh.RenderMergeConflict(data)
+	require.NoError(t, err)
+
+	expected = &gh.Content{
+		Body: "gitstream tried to merge `us-main` at `1234567890123456789012345678901234567890` from `some-upstream-url` " +
+			"into `main` but was unable to do so.\n\n" +
+			"Please merge it manually.\n\n" +
+			"---\n\n" +
+			"**Conflicts**:\n\n" +
+			"- `a.txt`: content\n" +
+			"- `b.txt`: modify/delete\n",
+		Title: "Could not merge upstream `us-main` at `1234567890123456789012345678901234567890` into `main`",
+	}
+
+	assert.Equal(t, expected, content)
+}
+
+func TestPRHelperImpl_UpdateContent(t *testing.T) {
+	const (
+		owner    = "owner"
+		prNumber = 456
+		repo     = "repo"
+	)
+
+	c := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.PatchReposPullsByOwnerByRepoByPullNumber,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				m := make(map[string]interface{})
+
+				assert.NoError(
+					t,
+					json.NewDecoder(r.Body).Decode(&m),
+				)
+
+				assert.Equal(
+					t,
+					fmt.Sprintf("/repos/%s/%s/pulls/%d", owner, repo, prNumber),
+					r.RequestURI,
+				)
+
+				assert.Equal(t, map[string]interface{}{"body": "some body", "title": "some title"}, m)
+			}),
+		),
+	)
+
+	gc := github.NewClient(c)
+
+	err := gh.NewPRHelper(gc, nil, "Markup", &gh.RepoName{Owner: owner, Repo: repo}).UpdateContent(
+		context.Background(),
+		&github.PullRequest{Number: github.Int(prNumber)},
+		&gh.Content{Body: "some body", Title: "some title"},
+	)
+
+	assert.NoError(t, err)
+}
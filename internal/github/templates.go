@@ -1,7 +1,10 @@
 package github
 
 import (
+	"bytes"
 	"embed"
+	"fmt"
+	"strings"
 	"text/template"
 )
 
@@ -13,3 +16,23 @@ var (
 		template.ParseFS(tmplFS, "templates/*.tmpl"),
 	)
 )
+
+// render executes the title and the body templates with data.
+func render(titleTemplate, bodyTemplate string, data interface{}) (*Content, error) {
+	var title, body bytes.Buffer
+
+	if err := templates.ExecuteTemplate(&title, titleTemplate, data); err != nil {
+		return nil, fmt.Errorf("could not execute template %s: %v", titleTemplate, err)
+	}
+
+	if err := templates.ExecuteTemplate(&body, bodyTemplate, data); err != nil {
+		return nil, fmt.Errorf("could not execute template %s: %v", bodyTemplate, err)
+	}
+
+	content := Content{
+		Body:  body.String(),
+		Title: strings.TrimSpace(title.String()),
+	}
+
+	return &content, nil
+}
@@ -0,0 +1,128 @@
+package github
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v47/github"
+)
+
+//go:generate mockgen -source=upstream.go -package=github -destination=mock_upstream.go
+
+// UpstreamPR is a pull request on the upstream repository.
+type UpstreamPR struct {
+	// CommitSHAs are the commits of the PR, oldest first.
+	CommitSHAs []string
+	// MergeCommitSHA is the commit created in the base branch when the PR was merged, or an empty string if the PR is
+	// not merged.
+	MergeCommitSHA string
+	Number         int
+}
+
+type UpstreamHelper interface {
+	GetPR(ctx context.Context, number int) (*UpstreamPR, error)
+	GetCommitPR(ctx context.Context, sha string) (*PullRequest, error)
+}
+
+type UpstreamHelperImpl struct {
+	gc       *github.Client
+	repoName *RepoName
+}
+
+func NewUpstreamHelper(gc *github.Client, repoName *RepoName) *UpstreamHelperImpl {
+	return &UpstreamHelperImpl{
+		gc:       gc,
+		repoName: repoName,
+	}
+}
+
+func (uh *UpstreamHelperImpl) GetPR(ctx context.Context, number int) (*UpstreamPR, error) {
+	pr, _, err := uh.gc.PullRequests.Get(ctx, uh.repoName.Owner, uh.repoName.Repo, number)
+	if err != nil {
+		return nil, fmt.Errorf("could not get PR %d: %v", number, err)
+	}
+
+	res := UpstreamPR{
+		CommitSHAs: make([]string, 0, pr.GetCommits()),
+		Number:     number,
+	}
+
+	if pr.GetMerged() {
+		res.MergeCommitSHA = pr.GetMergeCommitSHA()
+	}
+
+	opts := &github.ListOptions{PerPage: 100}
+
+	for {
+		commits, resp, err := uh.gc.PullRequests.ListCommits(ctx, uh.repoName.Owner, uh.repoName.Repo, number, opts)
+		if err != nil {
+			return nil, fmt.Errorf("could not list the commits of PR %d: %v", number, err)
+		}
+
+		for _, c := range commits {
+			res.CommitSHAs = append(res.CommitSHAs, c.GetSHA())
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opts.Page = resp.NextPage
+	}
+
+	return &res, nil
+}
+
+// GetCommitPR returns the upstream PR that introduced the commit, or nil if the commit is not associated with any PR.
+// Merged PRs are preferred over other PRs that contain the commit.
+func (uh *UpstreamHelperImpl) GetCommitPR(ctx context.Context, sha string) (*PullRequest, error) {
+	var found *github.PullRequest
+
+	opts := &github.PullRequestListOptions{ListOptions: github.ListOptions{PerPage: 100}}
+
+	for {
+		prs, resp, err := uh.gc.PullRequests.ListPullRequestsWithCommit(ctx, uh.repoName.Owner, uh.repoName.Repo, sha, opts)
+		if err != nil {
+			return nil, fmt.Errorf("could not list the PRs of commit %s: %v", sha, err)
+		}
+
+		for _, pr := range prs {
+			if pr.MergedAt != nil {
+				return newPullRequest(pr), nil
+			}
+
+			if found == nil {
+				found = pr
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opts.Page = resp.NextPage
+	}
+
+	if found == nil {
+		return nil, nil
+	}
+
+	return newPullRequest(found), nil
+}
+
+func newPullRequest(pr *github.PullRequest) *PullRequest {
+	res := PullRequest{
+		Author:   pr.GetUser().GetLogin(),
+		Labels:   make([]string, 0, len(pr.Labels)),
+		MergedAt: pr.MergedAt,
+		Number:   pr.GetNumber(),
+		Title:    pr.GetTitle(),
+		URL:      pr.GetHTMLURL(),
+	}
+
+	for _, l := range pr.Labels {
+		res.Labels = append(res.Labels, l.GetName())
+	}
+
+	return &res
+}
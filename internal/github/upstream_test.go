@@ -0,0 +1,103 @@
+package github_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v47/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	gh "github.com/rh-ecosystem-edge/gitstream/internal/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpstreamHelperImpl_GetPR(t *testing.T) {
+	ctx := context.Background()
+	repoName := &gh.RepoName{Owner: "some-owner", Repo: "some-repo"}
+
+	t.Run("merged PR", func(t *testing.T) {
+		c := mock.NewMockedHTTPClient(
+			mock.WithRequestMatch(
+				mock.GetReposPullsByOwnerByRepoByPullNumber,
+				github.PullRequest{
+					Commits:        github.Int(2),
+					Merged:         github.Bool(true),
+					MergeCommitSHA: github.String("merge-sha"),
+				},
+			),
+			mock.WithRequestMatch(
+				mock.GetReposPullsCommitsByOwnerByRepoByPullNumber,
+				[]github.RepositoryCommit{{SHA: github.String("sha1")}, {SHA: github.String("sha2")}},
+			),
+		)
+
+		pr, err := gh.NewUpstreamHelper(github.NewClient(c), repoName).GetPR(ctx, 123)
+		require.NoError(t, err)
+		assert.Equal(t, &gh.UpstreamPR{CommitSHAs: []string{"sha1", "sha2"}, MergeCommitSHA: "merge-sha", Number: 123}, pr)
+	})
+
+	t.Run("API error", func(t *testing.T) {
+		c := mock.NewMockedHTTPClient(
+			mock.WithRequestMatchHandler(
+				mock.GetReposPullsByOwnerByRepoByPullNumber,
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusNotFound)
+				}),
+			),
+		)
+
+		_, err := gh.NewUpstreamHelper(github.NewClient(c), repoName).GetPR(ctx, 123)
+		assert.ErrorContains(t, err, "could not get PR 123")
+	})
+}
+
+func TestUpstreamHelperImpl_GetCommitPR(t *testing.T) {
+	ctx := context.Background()
+	repoName := &gh.RepoName{Owner: "some-owner", Repo: "some-repo"}
+	mergedAt := time.Date(2023, 5, 4, 3, 2, 1, 0, time.UTC)
+
+	t.Run("merged PR is preferred", func(t *testing.T) {
+		c := mock.NewMockedHTTPClient(
+			mock.WithRequestMatch(
+				mock.GetReposCommitsPullsByOwnerByRepoByCommitSha,
+				[]github.PullRequest{
+					{Number: github.Int(1), Title: github.String("Open PR")},
+					{
+						HTMLURL:  github.String("https://github.com/some-owner/some-repo/pull/2"),
+						Labels:   []*github.Label{{Name: github.String("kind/bug")}, {Name: github.String("lgtm")}},
+						MergedAt: &mergedAt,
+						Number:   github.Int(2),
+						Title:    github.String("Merged PR"),
+						User:     &github.User{Login: github.String("some-user")},
+					},
+				},
+			),
+		)
+
+		pr, err := gh.NewUpstreamHelper(github.NewClient(c), repoName).GetCommitPR(ctx, "some-sha")
+		require.NoError(t, err)
+
+		expected := &gh.PullRequest{
+			Author:   "some-user",
+			Labels:   []string{"kind/bug", "lgtm"},
+			MergedAt: &mergedAt,
+			Number:   2,
+			Title:    "Merged PR",
+			URL:      "https://github.com/some-owner/some-repo/pull/2",
+		}
+
+		assert.Equal(t, expected, pr)
+	})
+
+	t.Run("no PR", func(t *testing.T) {
+		c := mock.NewMockedHTTPClient(
+			mock.WithRequestMatch(mock.GetReposCommitsPullsByOwnerByRepoByCommitSha, []github.PullRequest{}),
+		)
+
+		pr, err := gh.NewUpstreamHelper(github.NewClient(c), repoName).GetCommitPR(ctx, "some-sha")
+		require.NoError(t, err)
+		assert.Nil(t, pr)
+	})
+}
@@ -62,7 +62,7 @@ func (a *Assign) filterApproversFromCommitAuthors(commitAuthors []string, owners
 	return filteredCommitAuthors
 }
 
-func (a *Assign) handleIssue(ctx context.Context, issue *github.Issue, owners *owners.Owners) error {
+func (a *Assign) handleIssue(ctx context.Context, issue *github.Issue, owners *owners.Owners, upstream *upstreamSHAs) error {
 
 	logger := a.Logger.WithValues("url", *issue.HTMLURL, "issue", *issue.Number)
 
@@ -79,10 +79,15 @@ func (a *Assign) handleIssue(ctx context.Context, issue *github.Issue, owners *o
 
 	commitAuthors := make([]string, 0, len(shas))
 	for _, s := range shas {
-		user, err := a.UserHelper.GetCommitAuthor(ctx, s.String())
+		hash, err := upstream.resolve(s)
+		if err != nil {
+			return fmt.Errorf("could not resolve the SHAs of issue %d: %v", *issue.Number, err)
+		}
+
+		user, err := a.UserHelper.GetCommitAuthor(ctx, hash.String())
 		if err != nil {
 			return fmt.Errorf("failed to get commit author from GitHub for issue %d in commit %s: %v",
-				*issue.Number, s.String(), err)
+				*issue.Number, hash.String(), err)
 		}
 		commitAuthors = append(commitAuthors, *user.Login)
 	}
@@ -118,9 +123,11 @@ func (a *Assign) assignIssues(ctx context.Context) error {
 		return fmt.Errorf("could not list open issues: %v", err)
 	}
 
+	upstream := newUpstreamSHAs(a.Repo, a.UpstreamConfig.Ref)
+
 	var multiErr error
 	for _, issue := range issues {
-		if err := a.handleIssue(ctx, issue, owners); err != nil {
+		if err := a.handleIssue(ctx, issue, owners, upstream); err != nil {
 			multiErr = multierror.Append(multiErr, err)
 		}
 	}
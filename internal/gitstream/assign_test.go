@@ -5,7 +5,6 @@ import (
 	"errors"
 	"testing"
 
-	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-logr/logr"
 	"github.com/golang/mock/gomock"
 	"github.com/google/go-github/v47/github"
@@ -88,7 +87,8 @@ func TestAssign_filterApproversFromCommitAuthors(t *testing.T) {
 func TestAssign_handleIssue(t *testing.T) {
 
 	var (
-		ctx = context.Background()
+		ctx      = context.Background()
+		upstream = newUpstreamSHAs(nil, "")
 	)
 
 	o := &owners.Owners{
@@ -119,7 +119,7 @@ func TestAssign_handleIssue(t *testing.T) {
 			},
 		}
 
-		err := a.handleIssue(ctx, issue, o)
+		err := a.handleIssue(ctx, issue, o, upstream)
 		assert.NoError(t, err)
 	})
 
@@ -148,10 +148,10 @@ func TestAssign_handleIssue(t *testing.T) {
 		}
 
 		gomock.InOrder(
-			mockFinder.EXPECT().FindSHAs(gomock.Any()).Return([]plumbing.Hash{}, errors.New("some error")),
+			mockFinder.EXPECT().FindSHAs(gomock.Any()).Return([]string{}, errors.New("some error")),
 		)
 
-		err := a.handleIssue(ctx, issue, o)
+		err := a.handleIssue(ctx, issue, o, upstream)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "error while looking for SHAs")
 	})
@@ -188,11 +188,11 @@ func TestAssign_handleIssue(t *testing.T) {
 		sha, _ := test.AddEmptyCommit(t, repo, "empty")
 
 		gomock.InOrder(
-			mockFinder.EXPECT().FindSHAs(body).Return([]plumbing.Hash{sha}, nil),
+			mockFinder.EXPECT().FindSHAs(body).Return([]string{sha.String()}, nil),
 			mockUserHelper.EXPECT().GetCommitAuthor(ctx, sha.String()).Return(nil, errors.New("some API error")),
 		)
 
-		err := a.handleIssue(ctx, issue, o)
+		err := a.handleIssue(ctx, issue, o, upstream)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "failed to get commit author from GitHub for issue")
 	})
@@ -233,13 +233,13 @@ func TestAssign_handleIssue(t *testing.T) {
 		}
 
 		gomock.InOrder(
-			mockFinder.EXPECT().FindSHAs(body).Return([]plumbing.Hash{sha}, nil),
+			mockFinder.EXPECT().FindSHAs(body).Return([]string{sha.String()}, nil),
 			mockUserHelper.EXPECT().GetCommitAuthor(ctx, sha.String()).Return(user, nil),
 			mockOwnersHelper.EXPECT().IsApprover(o, *user.Login).Return(false),
 			mockOwnersHelper.EXPECT().GetRandomApprover(o).Return("", errors.New("some error")),
 		)
 
-		err := a.handleIssue(ctx, issue, o)
+		err := a.handleIssue(ctx, issue, o, upstream)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "could not get a random approver")
 	})
@@ -281,13 +281,13 @@ func TestAssign_handleIssue(t *testing.T) {
 		}
 
 		gomock.InOrder(
-			mockFinder.EXPECT().FindSHAs(body).Return([]plumbing.Hash{sha}, nil),
+			mockFinder.EXPECT().FindSHAs(body).Return([]string{sha.String()}, nil),
 			mockUserHelper.EXPECT().GetCommitAuthor(ctx, sha.String()).Return(user, nil),
 			mockOwnersHelper.EXPECT().IsApprover(o, *user.Login).Return(true),
 			mockIssueHelper.EXPECT().Assign(ctx, issue, *user.Login).Return(errors.New("error")),
 		)
 
-		err := a.handleIssue(ctx, issue, o)
+		err := a.handleIssue(ctx, issue, o, upstream)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "could not assign issue")
 	})
@@ -329,13 +329,13 @@ func TestAssign_handleIssue(t *testing.T) {
 		}
 
 		gomock.InOrder(
-			mockFinder.EXPECT().FindSHAs(body).Return([]plumbing.Hash{sha}, nil),
+			mockFinder.EXPECT().FindSHAs(body).Return([]string{sha.String()}, nil),
 			mockUserHelper.EXPECT().GetCommitAuthor(ctx, sha.String()).Return(user, nil),
 			mockOwnersHelper.EXPECT().IsApprover(o, *user.Login).Return(true),
 			mockIssueHelper.EXPECT().Assign(ctx, issue, *user.Login).Return(nil),
 		)
 
-		err := a.handleIssue(ctx, issue, o)
+		err := a.handleIssue(ctx, issue, o, upstream)
 		assert.NoError(t, err)
 	})
 
@@ -377,14 +377,14 @@ func TestAssign_handleIssue(t *testing.T) {
 		}
 
 		gomock.InOrder(
-			mockFinder.EXPECT().FindSHAs(body).Return([]plumbing.Hash{sha}, nil),
+			mockFinder.EXPECT().FindSHAs(body).Return([]string{sha.String()}, nil),
 			mockUserHelper.EXPECT().GetCommitAuthor(ctx, sha.String()).Return(user, nil),
 			mockOwnersHelper.EXPECT().IsApprover(o, *user.Login).Return(false),
 			mockOwnersHelper.EXPECT().GetRandomApprover(o).Return(*user.Login, nil),
 			mockIssueHelper.EXPECT().Assign(ctx, issue, *user.Login).Return(nil),
 		)
 
-		err := a.handleIssue(ctx, issue, o)
+		err := a.handleIssue(ctx, issue, o, upstream)
 		assert.NoError(t, err)
 	})
 }
@@ -576,13 +576,13 @@ func TestAssign_assignIssues(t *testing.T) {
 			mockIssueHelper.EXPECT().ListAllOpen(ctx, true).Return(issues, nil),
 
 			// issue #1
-			mockFinder.EXPECT().FindSHAs(body).Return([]plumbing.Hash{sha}, nil),
+			mockFinder.EXPECT().FindSHAs(body).Return([]string{sha.String()}, nil),
 			mockUserHelper.EXPECT().GetCommitAuthor(ctx, sha.String()).Return(user, nil),
 			mockOwnersHelper.EXPECT().IsApprover(o, *user.Login).Return(true),
 			mockIssueHelper.EXPECT().Assign(ctx, issues[0], o.Approvers[0]).Return(errors.New("some error")),
 
 			// issue #2
-			mockFinder.EXPECT().FindSHAs(body).Return([]plumbing.Hash{sha}, nil),
+			mockFinder.EXPECT().FindSHAs(body).Return([]string{sha.String()}, nil),
 			mockUserHelper.EXPECT().GetCommitAuthor(ctx, sha.String()).Return(user, nil),
 			mockOwnersHelper.EXPECT().IsApprover(o, *user.Login).Return(true),
 			mockIssueHelper.EXPECT().Assign(ctx, issues[1], o.Approvers[0]).Return(nil),
@@ -663,13 +663,13 @@ func TestAssign_assignIssues(t *testing.T) {
 			mockIssueHelper.EXPECT().ListAllOpen(ctx, true).Return(issues, nil),
 
 			// issue #1
-			mockFinder.EXPECT().FindSHAs(body).Return([]plumbing.Hash{sha}, nil),
+			mockFinder.EXPECT().FindSHAs(body).Return([]string{sha.String()}, nil),
 			mockUserHelper.EXPECT().GetCommitAuthor(ctx, sha.String()).Return(user, nil),
 			mockOwnersHelper.EXPECT().IsApprover(o, *user.Login).Return(true),
 			mockIssueHelper.EXPECT().Assign(ctx, issues[0], o.Approvers[0]).Return(errors.New("some error")),
 
 			// issue #2
-			mockFinder.EXPECT().FindSHAs(body).Return([]plumbing.Hash{sha}, nil),
+			mockFinder.EXPECT().FindSHAs(body).Return([]string{sha.String()}, nil),
 			mockUserHelper.EXPECT().GetCommitAuthor(ctx, sha.String()).Return(user, nil),
 			mockOwnersHelper.EXPECT().IsApprover(o, *user.Login).Return(true),
 			mockIssueHelper.EXPECT().Assign(ctx, issues[1], o.Approvers[0]).Return(errors.New("some error")),
@@ -751,13 +751,13 @@ func TestAssign_assignIssues(t *testing.T) {
 			mockIssueHelper.EXPECT().ListAllOpen(ctx, true).Return(issues, nil),
 
 			// issue #1
-			mockFinder.EXPECT().FindSHAs(body).Return([]plumbing.Hash{sha}, nil),
+			mockFinder.EXPECT().FindSHAs(body).Return([]string{sha.String()}, nil),
 			mockUserHelper.EXPECT().GetCommitAuthor(ctx, sha.String()).Return(user, nil),
 			mockOwnersHelper.EXPECT().IsApprover(o, *user.Login).Return(true),
 			mockIssueHelper.EXPECT().Assign(ctx, issues[0], o.Approvers[0]).Return(nil),
 
 			// issue #2
-			mockFinder.EXPECT().FindSHAs(body).Return([]plumbing.Hash{sha}, nil),
+			mockFinder.EXPECT().FindSHAs(body).Return([]string{sha.String()}, nil),
 			mockUserHelper.EXPECT().GetCommitAuthor(ctx, sha.String()).Return(user, nil),
 			mockOwnersHelper.EXPECT().IsApprover(o, *user.Login).Return(true),
 			mockIssueHelper.EXPECT().Assign(ctx, issues[1], o.Approvers[0]).Return(nil),
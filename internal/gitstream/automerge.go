@@ -0,0 +1,53 @@
+package gitstream
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/rh-ecosystem-edge/gitstream/internal/config"
+	"github.com/rh-ecosystem-edge/gitstream/internal/paths"
+)
+
+// canAutoMerge checks the upstream commit against the auto-merge restrictions.
+// If auto-merge is not allowed, it returns a human-readable reason.
+func canAutoMerge(cfg config.AutoMerge, commit *object.Commit) (bool, string, error) {
+	if len(cfg.Authors) > 0 {
+		authors := makeStringSet(cfg.Authors)
+
+		_, nameOK := authors[commit.Author.Name]
+		_, emailOK := authors[commit.Author.Email]
+
+		if !nameOK && !emailOK {
+			return false, fmt.Sprintf("author %q is not allowed", commit.Author.Name), nil
+		}
+	}
+
+	if cfg.MaxDiffLines == -1 && len(cfg.ProtectedPaths) == 0 {
+		return true, "", nil
+	}
+
+	stats, err := commit.Stats()
+	if err != nil {
+		return false, "", fmt.Errorf("could not get stats for commit %s: %v", commit.Hash, err)
+	}
+
+	lines := 0
+
+	for _, s := range stats {
+		lines += s.Addition + s.Deletion
+
+		// renames are reported as "old => new"
+		for _, name := range strings.Split(s.Name, " => ") {
+			if p := paths.Match(cfg.ProtectedPaths, name); p != "" {
+				return false, fmt.Sprintf("%s matches protected path %q", name, p), nil
+			}
+		}
+	}
+
+	if cfg.MaxDiffLines != -1 && lines > cfg.MaxDiffLines {
+		return false, fmt.Sprintf("%d changed lines exceed the limit of %d", lines, cfg.MaxDiffLines), nil
+	}
+
+	return true, "", nil
+}
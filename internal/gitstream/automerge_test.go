@@ -0,0 +1,101 @@
+package gitstream
+
+import (
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/rh-ecosystem-edge/gitstream/internal/config"
+	"github.com/rh-ecosystem-edge/gitstream/internal/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanAutoMerge(t *testing.T) {
+	repo, fs := test.NewRepoWithFS(t)
+
+	contents := "line 1\nline 2\n"
+
+	test.AddCommit(t, repo, fs, "initial commit", map[string]*string{"README.md": &contents})
+
+	updated := "line 1\nline 2\nline 3\n"
+
+	_, commit := test.AddCommit(t, repo, fs, "upstream commit", map[string]*string{
+		"README.md":     &updated,
+		"docs/guide.md": &updated,
+	})
+
+	cases := []struct {
+		name           string
+		cfg            config.AutoMerge
+		commit         *object.Commit
+		expectedResult bool
+	}{
+		{
+			name:           "no restriction",
+			cfg:            config.AutoMerge{Enabled: true, MaxDiffLines: -1},
+			commit:         commit,
+			expectedResult: true,
+		},
+		{
+			name:           "allowed author name",
+			cfg:            config.AutoMerge{Authors: []string{"Unit tests"}, MaxDiffLines: -1},
+			commit:         commit,
+			expectedResult: true,
+		},
+		{
+			name:           "allowed author email",
+			cfg:            config.AutoMerge{Authors: []string{"unit.tests@example.com"}, MaxDiffLines: -1},
+			commit:         commit,
+			expectedResult: true,
+		},
+		{
+			name:           "author not allowed",
+			cfg:            config.AutoMerge{Authors: []string{"someone-else"}, MaxDiffLines: -1},
+			commit:         commit,
+			expectedResult: false,
+		},
+		{
+			name:           "diff under the limit",
+			cfg:            config.AutoMerge{MaxDiffLines: 4},
+			commit:         commit,
+			expectedResult: true,
+		},
+		{
+			name:           "diff over the limit",
+			cfg:            config.AutoMerge{MaxDiffLines: 3},
+			commit:         commit,
+			expectedResult: false,
+		},
+		{
+			name:           "protected directory",
+			cfg:            config.AutoMerge{MaxDiffLines: -1, ProtectedPaths: []string{"docs/"}},
+			commit:         commit,
+			expectedResult: false,
+		},
+		{
+			name:           "protected glob",
+			cfg:            config.AutoMerge{MaxDiffLines: -1, ProtectedPaths: []string{"*.md"}},
+			commit:         commit,
+			expectedResult: false,
+		},
+		{
+			name:           "unrelated protected path",
+			cfg:            config.AutoMerge{MaxDiffLines: -1, ProtectedPaths: []string{"vendor/", "go.*"}},
+			commit:         commit,
+			expectedResult: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ok, reason, err := canAutoMerge(c.cfg, c.commit)
+			require.NoError(t, err)
+
+			assert.Equal(t, c.expectedResult, ok)
+
+			if !ok {
+				assert.NotEmpty(t, reason)
+			}
+		})
+	}
+}
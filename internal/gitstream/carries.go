@@ -0,0 +1,194 @@
+package gitstream
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-logr/logr"
+	"github.com/rh-ecosystem-edge/gitstream/internal"
+	"github.com/rh-ecosystem-edge/gitstream/internal/config"
+	"github.com/rh-ecosystem-edge/gitstream/internal/gitutils"
+	"github.com/rh-ecosystem-edge/gitstream/internal/hooks"
+	"github.com/rh-ecosystem-edge/gitstream/internal/markup"
+)
+
+// Carries lists the downstream-only commits, or carries: the commits of the downstream main branch since its merge
+// base with upstream that have no markup. If CheckUpstream is true, it also looks for upstream commits since the merge
+// base that make the same change as a carry, in which case the carry can be dropped.
+type Carries struct {
+	CheckUpstream        bool
+	DownstreamMainBranch string
+	Finder               markup.Finder
+	GitHelper            gitutils.Helper
+	Logger               logr.Logger
+	Repo                 *git.Repository
+	UpstreamConfig       config.Upstream
+}
+
+// carry is a downstream-only commit.
+type carry struct {
+	commit *object.Commit
+	files  []string
+	// upstream is the SHA of an upstream commit that makes the same change, if any.
+	upstream *plumbing.Hash
+}
+
+func (c *Carries) Run(ctx context.Context) error {
+	_, carries, err := c.find(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, ca := range carries {
+		logger := c.Logger.WithValues(
+			"sha", ca.commit.Hash,
+			"subject", strings.SplitN(ca.commit.Message, "\n", 2)[0],
+			"files", ca.files)
+
+		switch {
+		case !c.CheckUpstream:
+			logger.Info("Downstream-only commit")
+		case ca.upstream != nil:
+			logger.Info("Downstream-only commit with an equivalent upstream commit; it can be dropped", "upstream sha", *ca.upstream)
+		default:
+			logger.Info("Downstream-only commit with no equivalent upstream commit")
+		}
+	}
+
+	c.Logger.Info("Found downstream-only commits", "count", len(carries))
+
+	return nil
+}
+
+// find returns the upstream commit and the carries, newest first.
+func (c *Carries) find(ctx context.Context) (*object.Commit, []carry, error) {
+	const remoteName = internal.UpstreamRemoteName
+
+	dsRef, err := c.GitHelper.GetBranchRef(ctx, c.DownstreamMainBranch)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not get the tip of branch %q: %v", c.DownstreamMainBranch, err)
+	}
+
+	if _, err = c.GitHelper.RecreateRemote(ctx, remoteName, c.UpstreamConfig.URL); err != nil {
+		return nil, nil, fmt.Errorf("could not recreate remote: %v", err)
+	}
+
+	usRef, err := c.GitHelper.GetRemoteRef(ctx, remoteName, c.UpstreamConfig.Ref)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not get the ref for %s/%s: %v", remoteName, c.UpstreamConfig.Ref, err)
+	}
+
+	dsCommit, err := c.Repo.CommitObject(dsRef.Hash())
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not get commit %s: %v", dsRef.Hash(), err)
+	}
+
+	usCommit, err := c.Repo.CommitObject(usRef.Hash())
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not get commit %s: %v", usRef.Hash(), err)
+	}
+
+	mbs, err := dsCommit.MergeBase(usCommit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not get the merge base of %s and %s: %v", dsCommit.Hash, usCommit.Hash, err)
+	}
+
+	bases := make([]plumbing.Hash, 0, len(mbs))
+
+	for _, mb := range mbs {
+		bases = append(bases, mb.Hash)
+	}
+
+	if len(bases) == 0 {
+		c.Logger.Info("Downstream and upstream have no common history; considering all downstream commits")
+	} else {
+		c.Logger.Info("Considering downstream commits since the merge base with upstream", "merge base", bases)
+	}
+
+	// Downstream may have merged upstream before, so commits reachable from the merge bases are excluded even if they
+	// are older than the merge bases.
+	dsCommits, err := gitutils.CommitsBetween(mbs, dsCommit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not walk the downstream history: %v", err)
+	}
+
+	commits := make([]*object.Commit, 0)
+
+	for _, commit := range dsCommits {
+		// Merge commits bring changes that are already in their other parents.
+		if commit.NumParents() > 1 {
+			continue
+		}
+
+		matches, err := c.Finder.Find(commit.Message)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error while finding SHAs in commit %s: %v", commit.Hash, err)
+		}
+
+		if len(matches) == 0 {
+			commits = append(commits, commit)
+		}
+	}
+
+	var upstreamPatchIDs map[plumbing.Hash]plumbing.Hash
+
+	if c.CheckUpstream && len(commits) > 0 {
+		if upstreamPatchIDs, err = patchIDs(usCommit, mbs); err != nil {
+			return nil, nil, fmt.Errorf("could not compute the patch IDs of upstream commits: %v", err)
+		}
+	}
+
+	carries := make([]carry, 0, len(commits))
+
+	for _, commit := range commits {
+		ca := carry{commit: commit}
+
+		if ca.files, err = hooks.ChangedFiles(commit); err != nil {
+			return nil, nil, fmt.Errorf("could not get the files changed by %s: %v", commit.Hash, err)
+		}
+
+		if c.CheckUpstream {
+			id, err := gitutils.PatchID(commit)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if upstream, ok := upstreamPatchIDs[id]; ok {
+				ca.upstream = &upstream
+			}
+		}
+
+		carries = append(carries, ca)
+	}
+
+	return usCommit, carries, nil
+}
+
+// patchIDs maps the patch IDs of the non-merge commits reachable from from, but not from bases, to their SHA.
+func patchIDs(from *object.Commit, bases []*object.Commit) (map[plumbing.Hash]plumbing.Hash, error) {
+	commits, err := gitutils.CommitsBetween(bases, from)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(map[plumbing.Hash]plumbing.Hash)
+
+	for _, commit := range commits {
+		if commit.NumParents() > 1 {
+			continue
+		}
+
+		id, err := gitutils.PatchID(commit)
+		if err != nil {
+			return nil, err
+		}
+
+		ids[id] = commit.Hash
+	}
+
+	return ids, nil
+}
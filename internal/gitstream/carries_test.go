@@ -0,0 +1,109 @@
+package gitstream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-logr/logr"
+	"github.com/golang/mock/gomock"
+	"github.com/rh-ecosystem-edge/gitstream/internal"
+	"github.com/rh-ecosystem-edge/gitstream/internal/config"
+	"github.com/rh-ecosystem-edge/gitstream/internal/gitutils"
+	"github.com/rh-ecosystem-edge/gitstream/internal/markup"
+	"github.com/rh-ecosystem-edge/gitstream/internal/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCarries_find(t *testing.T) {
+	const (
+		downstreamMainBranch = "main"
+		markupName           = "Upstream-Commit"
+		upstreamMainBranch   = "us-main"
+		upstreamURL          = "some-upstream-url"
+	)
+
+	ctx := context.Background()
+
+	finder, err := markup.NewFinder(markupName)
+	require.NoError(t, err)
+
+	repo, fs := test.NewRepoWithFS(t)
+
+	one, fixed, picked, ds := "one\n", "ONE\n", "picked\n", "ds\n"
+
+	ancient, _ := test.AddCommit(t, repo, fs, "ancient", map[string]*string{"ancient.txt": &one})
+	base, _ := test.AddCommit(t, repo, fs, "base", map[string]*string{"upstream.txt": &one})
+	upstreamSHA, _ := test.AddCommit(t, repo, fs, "upstream fix", map[string]*string{"upstream.txt": &fixed})
+
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+
+	require.NoError(
+		t,
+		wt.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("downstream"), Create: true, Hash: base, Force: true}),
+	)
+
+	// Downstream forked from ancient, then merged upstream at base. Ancient is not a carry, even though it is older
+	// than the merge base.
+	_, err = wt.Commit("Merge upstream", &git.CommitOptions{
+		Author:  &object.Signature{Name: "Unit tests", Email: "unit.tests@example.com", When: time.Now()},
+		Parents: []plumbing.Hash{ancient, base},
+	})
+	require.NoError(t, err)
+
+	test.AddCommit(t, repo, fs, "picked\n\n"+markupName+": "+upstreamSHA.String(), map[string]*string{"picked.txt": &picked})
+	_, carried := test.AddCommit(t, repo, fs, "downstream only", map[string]*string{"downstream.txt": &ds})
+	dsMainSHA, backport := test.AddCommit(t, repo, fs, "backport of the upstream fix", map[string]*string{"upstream.txt": &fixed})
+
+	for _, checkUpstream := range []bool{false, true} {
+		ctrl := gomock.NewController(t)
+
+		mockHelper := gitutils.NewMockHelper(ctrl)
+
+		gomock.InOrder(
+			mockHelper.
+				EXPECT().
+				GetBranchRef(ctx, downstreamMainBranch).
+				Return(plumbing.NewHashReference(plumbing.NewBranchReferenceName(downstreamMainBranch), dsMainSHA), nil),
+			mockHelper.EXPECT().RecreateRemote(ctx, internal.UpstreamRemoteName, upstreamURL),
+			mockHelper.
+				EXPECT().
+				GetRemoteRef(ctx, internal.UpstreamRemoteName, upstreamMainBranch).
+				Return(plumbing.NewHashReference(plumbing.NewRemoteReferenceName(internal.UpstreamRemoteName, upstreamMainBranch), upstreamSHA), nil),
+		)
+
+		c := Carries{
+			CheckUpstream:        checkUpstream,
+			DownstreamMainBranch: downstreamMainBranch,
+			Finder:               finder,
+			GitHelper:            mockHelper,
+			Logger:               logr.Discard(),
+			Repo:                 repo,
+			UpstreamConfig:       config.Upstream{Ref: upstreamMainBranch, URL: upstreamURL},
+		}
+
+		upstream, carries, err := c.find(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, upstreamSHA, upstream.Hash)
+
+		var equivalent *plumbing.Hash
+
+		if checkUpstream {
+			equivalent = &upstreamSHA
+		}
+
+		assert.Equal(
+			t,
+			[]carry{
+				{commit: backport, files: []string{"upstream.txt"}, upstream: equivalent},
+				{commit: carried, files: []string{"downstream.txt"}},
+			},
+			carries,
+		)
+	}
+}
@@ -3,12 +3,17 @@ package gitstream
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-logr/logr"
 	"github.com/rh-ecosystem-edge/gitstream/internal/config"
 	gh "github.com/rh-ecosystem-edge/gitstream/internal/github"
 	"github.com/rh-ecosystem-edge/gitstream/internal/gitutils"
+	"github.com/rh-ecosystem-edge/gitstream/internal/metrics"
+	"github.com/rh-ecosystem-edge/gitstream/internal/rules"
+	"github.com/rh-ecosystem-edge/gitstream/internal/skiplist"
 )
 
 type Diff struct {
@@ -16,23 +21,87 @@ type Diff struct {
 	DiffConfig           config.Diff
 	DownstreamMainBranch string
 	Logger               logr.Logger
+	Metrics              *metrics.Metrics
 	Repo                 *git.Repository
 	RepoName             *gh.RepoName
-	UpstreamConfig       config.Upstream
+	Rules                *rules.Engine
+	// ShowSkipped lists the entries of the skip list on the downstream main branch, including expired ones.
+	ShowSkipped    bool
+	UpstreamConfig config.Upstream
 }
 
 func (d *Diff) Run(ctx context.Context) error {
-	diff, err := d.Differ.GetMissingCommits(ctx, d.Repo, d.RepoName, d.DiffConfig.CommitsSince, d.DownstreamMainBranch, d.UpstreamConfig)
+	diff, unresolved, err := d.Differ.GetMissingCommits(ctx, d.Repo, d.RepoName, d.DiffConfig, d.DownstreamMainBranch, d.UpstreamConfig)
 	if err != nil {
 		return fmt.Errorf("could not get commits not present in downstream: %v", err)
 	}
 
+	d.Metrics.SetMissingCommits(len(diff), oldestCommitTime(diff))
+
 	for _, c := range diff {
+		dec, err := d.Rules.Evaluate(ctx, c)
+		if err != nil {
+			return fmt.Errorf("could not evaluate the rules for commit %s: %v", c.Hash, err)
+		}
+
+		if !dec.Include {
+			d.Logger.Info(
+				"Commit present upstream but not downstream, excluded by rule",
+				"sha", c.Hash,
+				"message", c.Message,
+				"rule", dec.Rule)
+
+			continue
+		}
+
 		d.Logger.Info(
 			"Commit present upstream but not downstream",
 			"sha", c.Hash,
 			"message", c.Message)
 	}
 
+	for _, u := range unresolved {
+		d.Logger.Info(
+			"Abbreviated SHA could not be resolved",
+			"sha", u.SHA,
+			"candidates", u.Candidates,
+			"origin", u.Origin,
+			"markup", u.Markup)
+	}
+
+	if d.ShowSkipped {
+		return d.showSkipped()
+	}
+
+	return nil
+}
+
+func (d *Diff) showSkipped() error {
+	ref, err := d.Repo.Reference(plumbing.NewBranchReferenceName(d.DownstreamMainBranch), true)
+	if err != nil {
+		return fmt.Errorf("could not get the tip of branch %q: %v", d.DownstreamMainBranch, err)
+	}
+
+	commit, err := d.Repo.CommitObject(ref.Hash())
+	if err != nil {
+		return fmt.Errorf("could not get commit %s: %v", ref.Hash(), err)
+	}
+
+	l, err := skiplist.ReadFromCommit(commit)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	for _, e := range l.Commits {
+		d.Logger.Info(
+			"Commit in the skip list",
+			"sha", e.SHA,
+			"reason", e.Reason,
+			"expires", e.Expires,
+			"expired", e.Expired(now))
+	}
+
 	return nil
 }
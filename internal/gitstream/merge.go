@@ -0,0 +1,275 @@
+package gitstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-logr/logr"
+	"github.com/google/go-github/v47/github"
+	"github.com/rh-ecosystem-edge/gitstream/internal"
+	"github.com/rh-ecosystem-edge/gitstream/internal/config"
+	gh "github.com/rh-ecosystem-edge/gitstream/internal/github"
+	"github.com/rh-ecosystem-edge/gitstream/internal/gitutils"
+	"github.com/rh-ecosystem-edge/gitstream/internal/hooks"
+	"github.com/rh-ecosystem-edge/gitstream/internal/merge"
+)
+
+// Merge merges the upstream ref into the downstream main branch on a gs-merge-<upstream sha> branch, keeping upstream
+// SHAs in the downstream history, and opens a single PR for it. Open merge PRs for older upstream SHAs are closed, as
+// the new merge supersedes them. If the merge conflicts, an issue listing the conflicting files is opened instead.
+type Merge struct {
+	// CommitOptions sets the committer, the sign-off and the signer of the merge commit. Markup and MergeCommits are
+	// ignored.
+	CommitOptions    gitutils.CommitOptions
+	DownstreamConfig config.Downstream
+	DryRun           bool
+	GitHelper        gitutils.Helper
+	GitHubToken      string
+	Hooks            *hooks.Runner
+	IssueHelper      gh.IssueHelper
+	Logger           logr.Logger
+	// Merger applies the upstream changes to the gs-merge branch.
+	Merger         gitutils.Merger
+	PRHelper       gh.PRHelper
+	Repo           *git.Repository
+	RepoPath       string
+	UpstreamConfig config.Upstream
+}
+
+func (m *Merge) Run(ctx context.Context) error {
+	const remoteName = internal.UpstreamRemoteName
+
+	dsRef, err := m.GitHelper.GetBranchRef(ctx, m.DownstreamConfig.MainBranch)
+	if err != nil {
+		return fmt.Errorf("could not get the tip of branch %q: %v", m.DownstreamConfig.MainBranch, err)
+	}
+
+	if _, err = m.GitHelper.RecreateRemote(ctx, remoteName, m.UpstreamConfig.URL); err != nil {
+		return fmt.Errorf("could not recreate remote: %v", err)
+	}
+
+	usRef, err := m.GitHelper.GetRemoteRef(ctx, remoteName, m.UpstreamConfig.Ref)
+	if err != nil {
+		return fmt.Errorf("could not get the ref for %s/%s: %v", remoteName, m.UpstreamConfig.Ref, err)
+	}
+
+	dsCommit, err := m.Repo.CommitObject(dsRef.Hash())
+	if err != nil {
+		return fmt.Errorf("could not get commit %s: %v", dsRef.Hash(), err)
+	}
+
+	usCommit, err := m.Repo.CommitObject(usRef.Hash())
+	if err != nil {
+		return fmt.Errorf("could not get commit %s: %v", usRef.Hash(), err)
+	}
+
+	branchName := internal.GitStreamPrefix + "merge-" + usCommit.Hash.String()
+
+	logger := m.Logger.WithValues("branch", branchName, "upstream sha", usCommit.Hash)
+
+	mbs, err := dsCommit.MergeBase(usCommit)
+	if err != nil {
+		return fmt.Errorf("could not get the merge base of %s and %s: %v", dsCommit.Hash, usCommit.Hash, err)
+	}
+
+	for _, mb := range mbs {
+		if mb.Hash == usCommit.Hash {
+			logger.Info("The upstream ref is already merged into the downstream main branch")
+			return nil
+		}
+	}
+
+	data := gh.MergeData{
+		AppName:     internal.AppName,
+		BaseBranch:  m.DownstreamConfig.MainBranch,
+		UpstreamRef: m.UpstreamConfig.Ref,
+		UpstreamSHA: usCommit.Hash.String(),
+		UpstreamURL: m.UpstreamConfig.URL,
+	}
+
+	newCommits, err := gitutils.CommitsBetween(mbs, usCommit)
+	if err != nil {
+		return fmt.Errorf("could not list the upstream commits since the merge base: %v", err)
+	}
+
+	for _, c := range newCommits {
+		data.Commits = append(data.Commits, gh.MergeCommit{
+			SHA:     c.Hash.String(),
+			Subject: strings.ReplaceAll(strings.SplitN(c.Message, "\n", 2)[0], "|", `\|`),
+		})
+	}
+
+	logger.Info("Merging the upstream ref", "commits", len(data.Commits))
+
+	wt, err := m.Repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("could not get the worktree: %v", err)
+	}
+
+	if err = createBranchAt(m.Repo, wt, branchName, dsCommit.Hash); err != nil {
+		return err
+	}
+
+	ce := &merge.ConflictError{}
+
+	if err = m.Merger.Merge(ctx, logger, m.Repo, m.RepoPath, usCommit); errors.As(err, &ce) {
+		data.Conflicts = ce.Conflicts
+		return m.reportConflicts(ctx, logger, data)
+	} else if err != nil {
+		return fmt.Errorf("could not merge %s: %v", usCommit.Hash, err)
+	}
+
+	if _, err = m.Hooks.Run(ctx, hooks.StageBeforeCommit, m.RepoPath, usCommit); err != nil {
+		return fmt.Errorf("error while running the %s hooks: %v", hooks.StageBeforeCommit, err)
+	}
+
+	if err = m.commit(wt, dsCommit, usCommit); err != nil {
+		return err
+	}
+
+	if m.DryRun {
+		logger.Info("Dry run: not pushing the branch nor creating a PR")
+		return nil
+	}
+
+	logger.Info("Pushing branch")
+
+	if err = m.GitHelper.PushBranchContextWithAuth(ctx, m.GitHubToken, branchName); err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("could not push branch %s: %v", branchName, err)
+	}
+
+	return m.openPR(ctx, logger, branchName, data)
+}
+
+// commit records the merge of upstream into downstream, with downstream as the first parent.
+func (m *Merge) commit(wt *git.Worktree, downstream, upstream *object.Commit) error {
+	msg := fmt.Sprintf("Merge upstream %s at %s\n\nFrom %s.", m.UpstreamConfig.Ref, upstream.Hash, m.UpstreamConfig.URL)
+
+	opts := git.CommitOptions{
+		All: true,
+		// The merge is recorded even if upstream brings no change to the downstream tree.
+		AllowEmptyCommits: true,
+		Parents:           []plumbing.Hash{downstream.Hash, upstream.Hash},
+		Signer:            m.CommitOptions.Signer,
+	}
+
+	if committer := m.CommitOptions.Committer; committer.Name != "" && committer.Email != "" {
+		sig := &object.Signature{
+			Name:  committer.Name,
+			Email: committer.Email,
+			When:  time.Now(),
+		}
+
+		opts.Author = sig
+		opts.Committer = sig
+
+		if m.CommitOptions.SignOff {
+			msg += fmt.Sprintf("\n\nSigned-off-by: %s <%s>", committer.Name, committer.Email)
+		}
+	}
+
+	if _, err := wt.Commit(msg, &opts); err != nil {
+		return fmt.Errorf("could not commit the merge of %s: %v", upstream.Hash, err)
+	}
+
+	return nil
+}
+
+// openPR creates the merge PR for branchName, or updates it if it exists, and closes the merge PRs of other branches.
+func (m *Merge) openPR(ctx context.Context, logger logr.Logger, branchName string, data gh.MergeData) error {
+	prefix := internal.GitStreamPrefix + "merge-"
+
+	prs, err := m.PRHelper.ListAllOpen(ctx, func(pr *github.PullRequest) bool {
+		return strings.HasPrefix(pr.GetHead().GetRef(), prefix)
+	})
+	if err != nil {
+		return fmt.Errorf("could not list open PRs: %v", err)
+	}
+
+	content, err := gh.RenderMerge(data)
+	if err != nil {
+		return err
+	}
+
+	var existing *github.PullRequest
+
+	for _, pr := range prs {
+		if pr.GetHead().GetRef() == branchName {
+			existing = pr
+			continue
+		}
+
+		logger.Info("Closing superseded merge PR", "url", pr.GetHTMLURL())
+
+		if err = m.PRHelper.Close(ctx, pr); err != nil {
+			return err
+		}
+	}
+
+	if existing != nil {
+		if err = m.PRHelper.UpdateContent(ctx, existing, content); err != nil {
+			return err
+		}
+
+		logger.Info("Updated PR", "url", existing.GetHTMLURL())
+
+		return nil
+	}
+
+	pr, err := m.PRHelper.CreateFromContent(ctx, branchName, m.DownstreamConfig.MainBranch, content, false)
+	if err != nil {
+		return fmt.Errorf("could not create the merge PR: %v", err)
+	}
+
+	logger.Info("Created PR", "url", pr.GetHTMLURL())
+
+	return nil
+}
+
+// reportConflicts opens an issue listing the conflicts in data, unless one is already open for the same upstream SHA.
+func (m *Merge) reportConflicts(ctx context.Context, logger logr.Logger, data gh.MergeData) error {
+	paths := make([]string, 0, len(data.Conflicts))
+
+	for _, c := range data.Conflicts {
+		paths = append(paths, c.Path)
+	}
+
+	logger.Info("The merge conflicted", "files", paths)
+
+	content, err := gh.RenderMergeConflict(data)
+	if err != nil {
+		return err
+	}
+
+	issues, err := m.IssueHelper.ListAllOpen(ctx, false)
+	if err != nil {
+		return fmt.Errorf("could not list open issues: %v", err)
+	}
+
+	for _, issue := range issues {
+		if issue.GetTitle() == content.Title {
+			logger.Info("An issue already exists for the conflicts", "url", issue.GetHTMLURL())
+			return nil
+		}
+	}
+
+	if m.DryRun {
+		logger.Info("Dry run: not creating an issue")
+		return nil
+	}
+
+	issue, err := m.IssueHelper.CreateFromContent(ctx, content)
+	if err != nil {
+		return fmt.Errorf("could not create an issue for the merge conflicts: %v", err)
+	}
+
+	logger.Info("Created issue", "url", issue.GetHTMLURL())
+
+	return nil
+}
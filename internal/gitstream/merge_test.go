@@ -0,0 +1,300 @@
+package gitstream
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-logr/logr"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-github/v47/github"
+	"github.com/rh-ecosystem-edge/gitstream/internal"
+	"github.com/rh-ecosystem-edge/gitstream/internal/config"
+	gh "github.com/rh-ecosystem-edge/gitstream/internal/github"
+	"github.com/rh-ecosystem-edge/gitstream/internal/gitutils"
+	"github.com/rh-ecosystem-edge/gitstream/internal/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerge_Run(t *testing.T) {
+	const (
+		downstreamMainBranch = "main"
+		githubToken          = "github-token"
+		upstreamMainBranch   = "us-main"
+		upstreamURL          = "some-upstream-url"
+	)
+
+	ctx := context.Background()
+
+	one, two, upper1, lower1, added := "one\n", "two\n", "ONE\n", "uno\n", "added\n"
+
+	// setup creates a repository where upstream changes one.txt, adds new.txt and merges a side branch that forked
+	// before the merge base, while downstream writes dsOne to one.txt and changes two.txt.
+	setup := func(t *testing.T, dsOne *string) (*git.Repository, plumbing.Hash, plumbing.Hash, plumbing.Hash) {
+		t.Helper()
+
+		repo, fs := test.NewRepoWithFS(t)
+
+		ancient, _ := test.AddCommit(t, repo, fs, "ancient", map[string]*string{"one.txt": &one})
+		base, _ := test.AddCommit(t, repo, fs, "base", map[string]*string{"one.txt": &one, "two.txt": &two})
+		test.AddCommit(t, repo, fs, "upstream 1", map[string]*string{"one.txt": &upper1})
+		upstream2, _ := test.AddCommit(t, repo, fs, "upstream | 2", map[string]*string{"new.txt": &added})
+
+		wt, err := repo.Worktree()
+		require.NoError(t, err)
+
+		commitWithParents := func(msg string, parents ...plumbing.Hash) plumbing.Hash {
+			sha, err := wt.Commit(msg, &git.CommitOptions{
+				AllowEmptyCommits: true,
+				Author:            &object.Signature{Name: "Unit tests", Email: "unit.tests@example.com", When: time.Now()},
+				Parents:           parents,
+			})
+			require.NoError(t, err)
+
+			return sha
+		}
+
+		side := commitWithParents("side", ancient)
+		upstreamSHA := commitWithParents("Merge side", upstream2, side)
+
+		require.NoError(
+			t,
+			wt.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName(downstreamMainBranch), Create: true, Hash: base, Force: true}),
+		)
+
+		dsMainSHA, _ := test.AddCommit(t, repo, fs, "downstream", map[string]*string{"one.txt": dsOne, "two.txt": &added})
+
+		return repo, base, dsMainSHA, upstreamSHA
+	}
+
+	newMerge := func(t *testing.T, repo *git.Repository, dsMainSHA, upstreamSHA plumbing.Hash) (*Merge, *gitutils.MockHelper, *gh.MockPRHelper, *gh.MockIssueHelper) {
+		t.Helper()
+
+		ctrl := gomock.NewController(t)
+
+		mockHelper := gitutils.NewMockHelper(ctrl)
+
+		gomock.InOrder(
+			mockHelper.
+				EXPECT().
+				GetBranchRef(ctx, downstreamMainBranch).
+				Return(plumbing.NewHashReference(plumbing.NewBranchReferenceName(downstreamMainBranch), dsMainSHA), nil),
+			mockHelper.EXPECT().RecreateRemote(ctx, internal.UpstreamRemoteName, upstreamURL),
+			mockHelper.
+				EXPECT().
+				GetRemoteRef(ctx, internal.UpstreamRemoteName, upstreamMainBranch).
+				Return(plumbing.NewHashReference(plumbing.NewRemoteReferenceName(internal.UpstreamRemoteName, upstreamMainBranch), upstreamSHA), nil),
+		)
+
+		m := &Merge{
+			CommitOptions: gitutils.CommitOptions{
+				Committer: config.Committer{Email: "bot@example.com", Name: "Some Bot"},
+			},
+			DownstreamConfig: config.Downstream{MainBranch: downstreamMainBranch},
+			GitHelper:        mockHelper,
+			GitHubToken:      githubToken,
+			IssueHelper:      gh.NewMockIssueHelper(ctrl),
+			Logger:           logr.Discard(),
+			Merger:           gitutils.NewNativeMerger(),
+			PRHelper:         gh.NewMockPRHelper(ctrl),
+			Repo:             repo,
+			UpstreamConfig:   config.Upstream{Ref: upstreamMainBranch, URL: upstreamURL},
+		}
+
+		return m, mockHelper, m.PRHelper.(*gh.MockPRHelper), m.IssueHelper.(*gh.MockIssueHelper)
+	}
+
+	t.Run("merge PR is created and older ones are closed", func(t *testing.T) {
+		repo, _, dsMainSHA, upstreamSHA := setup(t, &one)
+
+		m, mockHelper, mockPRHelper, _ := newMerge(t, repo, dsMainSHA, upstreamSHA)
+
+		branchName := "gs-merge-" + upstreamSHA.String()
+		oldPR := &github.PullRequest{Head: &github.PullRequestBranch{Ref: github.String("gs-merge-old")}}
+
+		gomock.InOrder(
+			mockHelper.EXPECT().PushBranchContextWithAuth(ctx, githubToken, branchName),
+			mockPRHelper.EXPECT().ListAllOpen(ctx, gomock.Any()).Return([]*github.PullRequest{oldPR}, nil),
+			mockPRHelper.EXPECT().Close(ctx, oldPR),
+			mockPRHelper.
+				EXPECT().
+				CreateFromContent(ctx, branchName, downstreamMainBranch, gomock.Any(), false).
+				DoAndReturn(func(_ context.Context, _, _ string, content *gh.Content, _ bool) (*github.PullRequest, error) {
+					assert.Contains(t, content.Body, "| `"+upstreamSHA.String()+"` | Merge side |")
+					assert.Contains(t, content.Body, "| upstream \\| 2 |")
+					assert.Contains(t, content.Body, "| upstream 1 |")
+					assert.Contains(t, content.Body, "| side |")
+					assert.NotContains(t, content.Body, "| base |")
+					assert.NotContains(t, content.Body, "| ancient |")
+
+					return &github.PullRequest{}, nil
+				}),
+		)
+
+		require.NoError(t, m.Run(ctx))
+
+		head, err := repo.Head()
+		require.NoError(t, err)
+		assert.Equal(t, plumbing.NewBranchReferenceName(branchName), head.Name())
+
+		headCommit, err := repo.CommitObject(head.Hash())
+		require.NoError(t, err)
+		assert.Equal(t, []plumbing.Hash{dsMainSHA, upstreamSHA}, headCommit.ParentHashes)
+		assert.Equal(t, "Some Bot", headCommit.Committer.Name)
+
+		for path, expected := range map[string]string{"one.txt": upper1, "two.txt": added, "new.txt": added} {
+			f, err := headCommit.File(path)
+			require.NoError(t, err)
+
+			contents, err := f.Contents()
+			require.NoError(t, err)
+			assert.Equal(t, expected, contents)
+		}
+	})
+
+	t.Run("existing merge PR is updated", func(t *testing.T) {
+		repo, _, dsMainSHA, upstreamSHA := setup(t, &one)
+
+		m, mockHelper, mockPRHelper, _ := newMerge(t, repo, dsMainSHA, upstreamSHA)
+
+		branchName := "gs-merge-" + upstreamSHA.String()
+		pr := &github.PullRequest{Head: &github.PullRequestBranch{Ref: github.String(branchName)}}
+
+		gomock.InOrder(
+			mockHelper.EXPECT().PushBranchContextWithAuth(ctx, githubToken, branchName),
+			mockPRHelper.EXPECT().ListAllOpen(ctx, gomock.Any()).Return([]*github.PullRequest{pr}, nil),
+			mockPRHelper.EXPECT().UpdateContent(ctx, pr, gomock.Any()),
+		)
+
+		require.NoError(t, m.Run(ctx))
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		repo, _, dsMainSHA, upstreamSHA := setup(t, &one)
+
+		m, _, _, _ := newMerge(t, repo, dsMainSHA, upstreamSHA)
+		m.DryRun = true
+
+		require.NoError(t, m.Run(ctx))
+	})
+
+	t.Run("upstream already merged", func(t *testing.T) {
+		repo, base, dsMainSHA, _ := setup(t, &one)
+
+		m, _, _, _ := newMerge(t, repo, dsMainSHA, base)
+
+		require.NoError(t, m.Run(ctx))
+
+		head, err := repo.Head()
+		require.NoError(t, err)
+		assert.Equal(t, dsMainSHA, head.Hash())
+	})
+
+	t.Run("submodule changes are merged with the git backend", func(t *testing.T) {
+		if _, err := exec.LookPath("git"); err != nil {
+			t.Skip("git is not available")
+		}
+
+		repoPath := t.TempDir()
+
+		repo, err := git.PlainInit(repoPath, false)
+		require.NoError(t, err)
+
+		wt, err := repo.Worktree()
+		require.NoError(t, err)
+
+		base, _ := test.AddCommit(t, repo, wt.Filesystem, "base", map[string]*string{"one.txt": &one})
+
+		idx, err := repo.Storer.Index()
+		require.NoError(t, err)
+
+		subSHA := plumbing.NewHash("0123456789abcdef0123456789abcdef01234567")
+
+		idx.Entries = append(idx.Entries, &index.Entry{Hash: subSHA, Mode: filemode.Submodule, Name: "sub"})
+		require.NoError(t, repo.Storer.SetIndex(idx))
+
+		gitmodules := "[submodule \"sub\"]\n\tpath = sub\n\turl = https://example.com/sub.git\n"
+
+		upstreamSHA, _ := test.AddCommit(t, repo, wt.Filesystem, "add submodule", map[string]*string{".gitmodules": &gitmodules})
+
+		require.NoError(
+			t,
+			wt.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName(downstreamMainBranch), Create: true, Hash: base, Force: true}),
+		)
+
+		dsMainSHA, _ := test.AddCommit(t, repo, wt.Filesystem, "downstream", map[string]*string{"two.txt": &two})
+
+		m, _, _, _ := newMerge(t, repo, dsMainSHA, upstreamSHA)
+		m.DryRun = true
+		m.Merger = gitutils.NewMerger(m.CommitOptions.Committer)
+		m.RepoPath = repoPath
+
+		require.NoError(t, m.Run(ctx))
+
+		head, err := repo.Head()
+		require.NoError(t, err)
+
+		headCommit, err := repo.CommitObject(head.Hash())
+		require.NoError(t, err)
+		assert.Equal(t, []plumbing.Hash{dsMainSHA, upstreamSHA}, headCommit.ParentHashes)
+
+		tree, err := headCommit.Tree()
+		require.NoError(t, err)
+
+		e, err := tree.FindEntry("sub")
+		require.NoError(t, err)
+		assert.Equal(t, filemode.Submodule, e.Mode)
+		assert.Equal(t, subSHA, e.Hash)
+
+		_, err = tree.FindEntry("two.txt")
+		assert.NoError(t, err)
+	})
+
+	t.Run("conflicts are reported in an issue", func(t *testing.T) {
+		repo, _, dsMainSHA, upstreamSHA := setup(t, &lower1)
+
+		m, _, _, mockIssueHelper := newMerge(t, repo, dsMainSHA, upstreamSHA)
+
+		gomock.InOrder(
+			mockIssueHelper.EXPECT().ListAllOpen(ctx, false),
+			mockIssueHelper.
+				EXPECT().
+				CreateFromContent(ctx, gomock.Any()).
+				DoAndReturn(func(_ context.Context, content *gh.Content) (*github.Issue, error) {
+					assert.Contains(t, content.Title, upstreamSHA.String())
+					assert.Contains(t, content.Body, "- `one.txt`: ")
+					assert.NotContains(t, content.Body, "new.txt")
+
+					return &github.Issue{}, nil
+				}),
+		)
+
+		require.NoError(t, m.Run(ctx))
+
+		// The same issue is not opened twice.
+		m, _, _, mockIssueHelper = newMerge(t, repo, dsMainSHA, upstreamSHA)
+
+		content, err := gh.RenderMergeConflict(gh.MergeData{
+			AppName:     internal.AppName,
+			BaseBranch:  downstreamMainBranch,
+			UpstreamRef: upstreamMainBranch,
+			UpstreamSHA: upstreamSHA.String(),
+			UpstreamURL: upstreamURL,
+		})
+		require.NoError(t, err)
+
+		mockIssueHelper.
+			EXPECT().
+			ListAllOpen(ctx, false).
+			Return([]*github.Issue{{Title: github.String(content.Title)}}, nil)
+
+		require.NoError(t, m.Run(ctx))
+	})
+}
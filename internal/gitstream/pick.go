@@ -0,0 +1,268 @@
+package gitstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/rh-ecosystem-edge/gitstream/internal"
+	gh "github.com/rh-ecosystem-edge/gitstream/internal/github"
+	"github.com/rh-ecosystem-edge/gitstream/internal/intents"
+)
+
+// Pick cherry-picks specific upstream commits, or the commits of an upstream PR, the same way Sync does. The maximum
+// number of open items is not enforced.
+type Pick struct {
+	// Force picks commits even if they already have an intent downstream, and the commits of upstream PRs that are not
+	// merged.
+	Force          bool
+	IntentsGetter  intents.Getter
+	SHAs           []string
+	Sync           *Sync
+	UpstreamHelper gh.UpstreamHelper
+	// UpstreamPR is the number of the upstream PR to pick. It is ignored if SHAs is not empty.
+	UpstreamPR int
+}
+
+func (p *Pick) Run(ctx context.Context) (err error) {
+	var (
+		rs    runState
+		s     = p.Sync
+		start = time.Now()
+	)
+
+	rep := s.beginReport()
+
+	defer func() {
+		rep.Finish(err)
+		s.Metrics.ObserveRun(time.Since(start), rs.prsCreated, rs.issuesCreated, err)
+	}()
+
+	if _, err = s.GitHelper.RecreateRemote(ctx, internal.UpstreamRemoteName, s.UpstreamConfig.URL); err != nil {
+		return fmt.Errorf("could not recreate remote: %v", err)
+	}
+
+	upstreamRef, err := s.GitHelper.GetRemoteRef(ctx, internal.UpstreamRemoteName, s.UpstreamConfig.Ref)
+	if err != nil {
+		return fmt.Errorf("could not get the ref for %s/%s: %v", internal.UpstreamRemoteName, s.UpstreamConfig.Ref, err)
+	}
+
+	tip, err := s.Repo.CommitObject(upstreamRef.Hash())
+	if err != nil {
+		return fmt.Errorf("could not get the upstream commit %s: %v", upstreamRef.Hash(), err)
+	}
+
+	var commits []*object.Commit
+
+	if len(p.SHAs) > 0 {
+		commits, err = p.upstreamCommits(tip, p.SHAs)
+	} else {
+		commits, err = p.prCommits(ctx, tip)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err = p.checkIntents(ctx, commits); err != nil {
+		return err
+	}
+
+	jobs := make([]*pickJob, 0, len(commits))
+
+	for _, c := range commits {
+		jobs = append(jobs, newPickJob(c, rep.AddCommit(c), s.Logger))
+	}
+
+	return s.runJobs(ctx, jobs, func(ctx context.Context, j *pickJob, r pickResult, push pushFunc) (bool, error) {
+		return s.publish(ctx, j, r, push, &rs)
+	})
+}
+
+// upstreamCommits resolves shas, which may be abbreviated, to commits reachable from the upstream tip.
+func (p *Pick) upstreamCommits(tip *object.Commit, shas []string) ([]*object.Commit, error) {
+	commits := make([]*object.Commit, 0, len(shas))
+
+	for _, sha := range shas {
+		h, err := p.Sync.Repo.ResolveRevision(plumbing.Revision(sha))
+		if err != nil {
+			return nil, fmt.Errorf("could not resolve %s: %v", sha, err)
+		}
+
+		c, err := p.Sync.Repo.CommitObject(*h)
+		if err != nil {
+			return nil, fmt.Errorf("could not get commit %s: %v", h, err)
+		}
+
+		if c.Hash != tip.Hash {
+			ok, err := c.IsAncestor(tip)
+			if err != nil {
+				return nil, fmt.Errorf("could not check if %s is in %s: %v", c.Hash, p.Sync.UpstreamConfig.Ref, err)
+			}
+
+			if !ok {
+				return nil, fmt.Errorf("commit %s is not in upstream %s", c.Hash, p.Sync.UpstreamConfig.Ref)
+			}
+		}
+
+		commits = append(commits, c)
+	}
+
+	return commits, nil
+}
+
+// prCommits returns the commits of the upstream PR. For merged PRs, those are the commits that the PR added to the
+// upstream history: its own commits if it was merged with a merge commit, or the commits created by GitHub if it was
+// squashed or rebased. The commits of PRs that are not merged, which may still change or never land upstream, are
+// only returned if p.Force is set.
+func (p *Pick) prCommits(ctx context.Context, tip *object.Commit) ([]*object.Commit, error) {
+	s := p.Sync
+
+	if p.UpstreamPR <= 0 {
+		return nil, errors.New("no commit or PR to pick")
+	}
+
+	pr, err := p.UpstreamHelper.GetPR(ctx, p.UpstreamPR)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pr.CommitSHAs) == 0 {
+		return nil, fmt.Errorf("PR %d has no commits", pr.Number)
+	}
+
+	if pr.MergeCommitSHA == "" {
+		if !p.Force {
+			return nil, fmt.Errorf("PR %d is not merged; use --force to pick its commits anyway", pr.Number)
+		}
+
+		s.Logger.Info("PR is not merged; picking its commits", "number", pr.Number)
+
+		if _, err := s.GitHelper.FetchPullRequestHeadContext(ctx, internal.UpstreamRemoteName, pr.Number); err != nil {
+			return nil, err
+		}
+
+		return commitsFromSHAs(s, pr.CommitSHAs)
+	}
+
+	mergeCommits, err := p.upstreamCommits(tip, []string{pr.MergeCommitSHA})
+	if err != nil {
+		return nil, fmt.Errorf("PR %d: merge commit: %v", pr.Number, err)
+	}
+
+	merge := mergeCommits[0]
+
+	if merge.NumParents() > 1 {
+		s.Logger.Info("PR was merged with a merge commit; picking its commits", "number", pr.Number)
+		return p.upstreamCommits(tip, pr.CommitSHAs)
+	}
+
+	prCommits, err := commitsFromSHAs(s, pr.CommitSHAs)
+	if err != nil {
+		// The original commits of squashed or rebased PRs may not be in the upstream history.
+		s.Logger.V(1).Info("Could not get the original commits of the PR", "error", err)
+		prCommits = nil
+	}
+
+	// A rebased PR adds one commit with the same message for each of its commits; a squashed PR adds a single commit.
+	rebased := []*object.Commit{merge}
+
+	for c := merge; len(rebased) < len(pr.CommitSHAs) && c.NumParents() == 1; {
+		parent, err := c.Parent(0)
+		if err != nil {
+			return nil, fmt.Errorf("could not get the parent of %s: %v", c.Hash, err)
+		}
+
+		rebased = append([]*object.Commit{parent}, rebased...)
+		c = parent
+	}
+
+	if len(prCommits) > 1 && sameMessages(rebased, prCommits) {
+		s.Logger.Info("PR was rebased; picking the rebased commits", "number", pr.Number)
+		return rebased, nil
+	}
+
+	s.Logger.Info("PR was squashed; picking the squashed commit", "number", pr.Number)
+
+	return []*object.Commit{merge}, nil
+}
+
+func commitsFromSHAs(s *Sync, shas []string) ([]*object.Commit, error) {
+	commits := make([]*object.Commit, 0, len(shas))
+
+	for _, sha := range shas {
+		c, err := s.Repo.CommitObject(plumbing.NewHash(sha))
+		if err != nil {
+			return nil, fmt.Errorf("could not get commit %s: %v", sha, err)
+		}
+
+		commits = append(commits, c)
+	}
+
+	return commits, nil
+}
+
+func sameMessages(a, b []*object.Commit) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if strings.TrimSpace(a[i].Message) != strings.TrimSpace(b[i].Message) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// checkIntents returns an error if any of commits already has an intent downstream, unless p.Force is set.
+func (p *Pick) checkIntents(ctx context.Context, commits []*object.Commit) error {
+	s := p.Sync
+
+	dsRef, err := s.GitHelper.GetBranchRef(ctx, s.DownstreamConfig.MainBranch)
+	if err != nil {
+		return fmt.Errorf("could not get the tip of branch %q: %v", s.DownstreamConfig.MainBranch, err)
+	}
+
+	logIntents, err := p.IntentsGetter.FromLocalGitRepo(ctx, s.Repo, dsRef.Hash(), s.DiffConfig.CommitsSince)
+	if err != nil {
+		return fmt.Errorf("could not get hashes from commits: %v", err)
+	}
+
+	issueIntents, err := p.IntentsGetter.FromGitHubIssues(ctx, s.RepoName)
+	if err != nil {
+		return fmt.Errorf("could not get hashes from issues: %v", err)
+	}
+
+	skipIntents, err := p.IntentsGetter.FromSkipList(ctx, s.Repo, dsRef.Hash())
+	if err != nil {
+		return fmt.Errorf("could not get hashes from the skip list: %v", err)
+	}
+
+	cis := intents.MergeCommitIntents(logIntents, issueIntents, skipIntents)
+
+	for _, c := range commits {
+		sha := c.Hash.String()
+
+		for prefix, intent := range cis {
+			if !strings.HasPrefix(sha, prefix) {
+				continue
+			}
+
+			if !p.Force {
+				return fmt.Errorf("commit %s already exists downstream (%s); use --force to pick it anyway", sha, intent.Origin)
+			}
+
+			s.Logger.Info("Picking commit that already exists downstream", "sha", sha, "origin", intent.Origin)
+
+			break
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,264 @@
+package gitstream
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-logr/logr"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-github/v47/github"
+	"github.com/rh-ecosystem-edge/gitstream/internal"
+	"github.com/rh-ecosystem-edge/gitstream/internal/config"
+	gh "github.com/rh-ecosystem-edge/gitstream/internal/github"
+	"github.com/rh-ecosystem-edge/gitstream/internal/gitutils"
+	"github.com/rh-ecosystem-edge/gitstream/internal/intents"
+	"github.com/rh-ecosystem-edge/gitstream/internal/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func checkoutNewBranch(t *testing.T, repo *git.Repository, name string, hash plumbing.Hash) {
+	t.Helper()
+
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+
+	co := git.CheckoutOptions{
+		Branch: plumbing.NewBranchReferenceName(name),
+		Create: true,
+		Hash:   hash,
+	}
+
+	require.NoError(t, wt.Checkout(&co))
+}
+
+func TestPick_Run(t *testing.T) {
+	const (
+		downstreamMainBranch = "main"
+		githubToken          = "github-token"
+		repoPath             = "/repo/path"
+		upstreamRef          = "us-main"
+		upstreamURL          = "some-upstream-url"
+	)
+
+	ctx := context.Background()
+
+	setup := func(t *testing.T) (*Pick, *gitutils.MockCherryPicker, *intents.MockGetter, *gh.MockPRHelper, *object.Commit, *object.Commit) {
+		t.Helper()
+
+		ctrl := gomock.NewController(t)
+
+		mockCP := gitutils.NewMockCherryPicker(ctrl)
+		mockHelper := gitutils.NewMockHelper(ctrl)
+		mockIntentsGetter := intents.NewMockGetter(ctrl)
+		mockPRHelper := gh.NewMockPRHelper(ctrl)
+
+		repo := test.NewRepo(t)
+		baseSHA, _ := test.AddEmptyCommit(t, repo, "base")
+		_, upstreamCommit := test.AddEmptyCommit(t, repo, "upstream")
+
+		checkoutNewBranch(t, repo, downstreamMainBranch, baseSHA)
+		_, downstreamCommit := test.AddEmptyCommit(t, repo, "downstream")
+
+		upstream := plumbing.NewHashReference(plumbing.NewRemoteReferenceName(internal.UpstreamRemoteName, upstreamRef), upstreamCommit.Hash)
+		downstream := plumbing.NewHashReference(plumbing.NewBranchReferenceName(downstreamMainBranch), downstreamCommit.Hash)
+
+		mockHelper.EXPECT().RecreateRemote(ctx, internal.UpstreamRemoteName, upstreamURL).AnyTimes()
+		mockHelper.EXPECT().GetRemoteRef(ctx, internal.UpstreamRemoteName, upstreamRef).Return(upstream, nil).AnyTimes()
+		mockHelper.EXPECT().GetBranchRef(ctx, downstreamMainBranch).Return(downstream, nil).AnyTimes()
+		mockHelper.EXPECT().PushContextWithAuth(ctx, githubToken).AnyTimes()
+
+		p := &Pick{
+			IntentsGetter: mockIntentsGetter,
+			Sync: &Sync{
+				CherryPicker: mockCP,
+				DownstreamConfig: config.Downstream{
+					LocalRepoPath: repoPath,
+					MainBranch:    downstreamMainBranch,
+					MaxOpenItems:  0,
+				},
+				GitHelper:      mockHelper,
+				GitHubToken:    githubToken,
+				Logger:         logr.Discard(),
+				PRHelper:       mockPRHelper,
+				Repo:           repo,
+				RepoName:       &gh.RepoName{Owner: "owner", Repo: "repo"},
+				UpstreamConfig: config.Upstream{Ref: upstreamRef, URL: upstreamURL},
+			},
+		}
+
+		return p, mockCP, mockIntentsGetter, mockPRHelper, upstreamCommit, downstreamCommit
+	}
+
+	t.Run("abbreviated SHA", func(t *testing.T) {
+		p, mockCP, mockIntentsGetter, mockPRHelper, upstreamCommit, downstreamCommit := setup(t)
+
+		p.SHAs = []string{upstreamCommit.Hash.String()[:7]}
+
+		mockUpstreamHelper := gh.NewMockUpstreamHelper(gomock.NewController(t))
+		p.Sync.UpstreamHelper = mockUpstreamHelper
+
+		upstreamPR := &gh.PullRequest{Number: 123, Title: "Some upstream PR"}
+
+		gomock.InOrder(
+			mockIntentsGetter.EXPECT().FromLocalGitRepo(ctx, p.Sync.Repo, downstreamCommit.Hash, nil),
+			mockIntentsGetter.EXPECT().FromGitHubIssues(ctx, p.Sync.RepoName),
+			mockIntentsGetter.EXPECT().FromSkipList(ctx, p.Sync.Repo, downstreamCommit.Hash),
+			mockCP.EXPECT().Run(ctx, p.Sync.Repo, repoPath, upstreamCommit),
+			mockUpstreamHelper.EXPECT().GetCommitPR(ctx, upstreamCommit.Hash.String()).Return(upstreamPR, nil),
+			mockPRHelper.
+				EXPECT().
+				Create(ctx, "gs-"+upstreamCommit.Hash.String(), downstreamMainBranch, upstreamURL, upstreamCommit, upstreamPR, false, gomock.Nil()).
+				Return(&github.PullRequest{HTMLURL: github.String("some-pr-url")}, nil),
+		)
+
+		assert.NoError(t, p.Run(ctx))
+	})
+
+	t.Run("commit already exists downstream", func(t *testing.T) {
+		p, mockCP, mockIntentsGetter, mockPRHelper, upstreamCommit, downstreamCommit := setup(t)
+
+		p.SHAs = []string{upstreamCommit.Hash.String()}
+
+		existing := intents.CommitIntents{
+			upstreamCommit.Hash.String()[:8]: intents.Intent{Origin: "some-origin"},
+		}
+
+		mockIntentsGetter.EXPECT().FromLocalGitRepo(ctx, p.Sync.Repo, downstreamCommit.Hash, nil).Return(existing, nil).Times(2)
+		mockIntentsGetter.EXPECT().FromGitHubIssues(ctx, p.Sync.RepoName).Times(2)
+		mockIntentsGetter.EXPECT().FromSkipList(ctx, p.Sync.Repo, downstreamCommit.Hash).Times(2)
+
+		assert.EqualError(
+			t,
+			p.Run(ctx),
+			"commit "+upstreamCommit.Hash.String()+" already exists downstream (some-origin); use --force to pick it anyway",
+		)
+
+		p.Force = true
+
+		gomock.InOrder(
+			mockCP.EXPECT().Run(ctx, p.Sync.Repo, repoPath, upstreamCommit),
+			mockPRHelper.
+				EXPECT().
+				Create(ctx, "gs-"+upstreamCommit.Hash.String(), downstreamMainBranch, upstreamURL, upstreamCommit, nil, false, gomock.Nil()).
+				Return(&github.PullRequest{HTMLURL: github.String("some-pr-url")}, nil),
+		)
+
+		assert.NoError(t, p.Run(ctx))
+	})
+
+	t.Run("commit not in upstream", func(t *testing.T) {
+		p, _, _, _, _, downstreamCommit := setup(t)
+
+		p.SHAs = []string{downstreamCommit.Hash.String()}
+
+		assert.EqualError(t, p.Run(ctx), "commit "+downstreamCommit.Hash.String()+" is not in upstream "+upstreamRef)
+	})
+}
+
+func TestPick_prCommits(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T) (*Pick, *gh.MockUpstreamHelper, *git.Repository, plumbing.Hash) {
+		t.Helper()
+
+		ctrl := gomock.NewController(t)
+
+		mockUpstreamHelper := gh.NewMockUpstreamHelper(ctrl)
+
+		repo := test.NewRepo(t)
+		baseSHA, _ := test.AddEmptyCommit(t, repo, "base")
+
+		p := &Pick{
+			Sync: &Sync{
+				Logger:         logr.Discard(),
+				Repo:           repo,
+				UpstreamConfig: config.Upstream{Ref: "main"},
+			},
+			UpstreamHelper: mockUpstreamHelper,
+			UpstreamPR:     123,
+		}
+
+		return p, mockUpstreamHelper, repo, baseSHA
+	}
+
+	// addPRCommits adds the original commits of a PR on a side branch.
+	addPRCommits := func(t *testing.T, repo *git.Repository, base plumbing.Hash) []string {
+		t.Helper()
+
+		checkoutNewBranch(t, repo, "pr", base)
+
+		a, _ := test.AddEmptyCommit(t, repo, "first")
+		b, _ := test.AddEmptyCommit(t, repo, "second")
+
+		return []string{a.String(), b.String()}
+	}
+
+	t.Run("unmerged PR", func(t *testing.T) {
+		p, mockUpstreamHelper, repo, baseSHA := setup(t)
+
+		prSHAs := addPRCommits(t, repo, baseSHA)
+
+		mockUpstreamHelper.
+			EXPECT().
+			GetPR(ctx, 123).
+			Return(&gh.UpstreamPR{CommitSHAs: prSHAs, Number: 123}, nil).
+			Times(2)
+
+		_, err := p.prCommits(ctx, nil)
+		assert.EqualError(t, err, "PR 123 is not merged; use --force to pick its commits anyway")
+
+		mockHelper := gitutils.NewMockHelper(gomock.NewController(t))
+		mockHelper.EXPECT().FetchPullRequestHeadContext(ctx, internal.UpstreamRemoteName, 123)
+
+		p.Force = true
+		p.Sync.GitHelper = mockHelper
+
+		commits, err := p.prCommits(ctx, nil)
+		require.NoError(t, err)
+		assert.Equal(t, prSHAs, []string{commits[0].Hash.String(), commits[1].Hash.String()})
+	})
+
+	t.Run("rebased PR", func(t *testing.T) {
+		p, mockUpstreamHelper, repo, baseSHA := setup(t)
+
+		prSHAs := addPRCommits(t, repo, baseSHA)
+
+		// Rebased commits have another parent than the original ones.
+		checkoutNewBranch(t, repo, "upstream", baseSHA)
+		test.AddEmptyCommit(t, repo, "other")
+		_, first := test.AddEmptyCommit(t, repo, "first")
+		_, second := test.AddEmptyCommit(t, repo, "second")
+
+		mockUpstreamHelper.
+			EXPECT().
+			GetPR(ctx, 123).
+			Return(&gh.UpstreamPR{CommitSHAs: prSHAs, MergeCommitSHA: second.Hash.String(), Number: 123}, nil)
+
+		commits, err := p.prCommits(ctx, second)
+		require.NoError(t, err)
+		assert.Equal(t, []*object.Commit{first, second}, commits)
+	})
+
+	t.Run("squashed PR", func(t *testing.T) {
+		p, mockUpstreamHelper, repo, baseSHA := setup(t)
+
+		prSHAs := addPRCommits(t, repo, baseSHA)
+
+		checkoutNewBranch(t, repo, "upstream", baseSHA)
+		_, squashed := test.AddEmptyCommit(t, repo, "squashed")
+		_, tip := test.AddEmptyCommit(t, repo, "tip")
+
+		mockUpstreamHelper.
+			EXPECT().
+			GetPR(ctx, 123).
+			Return(&gh.UpstreamPR{CommitSHAs: prSHAs, MergeCommitSHA: squashed.Hash.String(), Number: 123}, nil)
+
+		commits, err := p.prCommits(ctx, tip)
+		require.NoError(t, err)
+		assert.Equal(t, []*object.Commit{squashed}, commits)
+	})
+}
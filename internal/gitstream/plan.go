@@ -0,0 +1,238 @@
+package gitstream
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/rh-ecosystem-edge/gitstream/internal"
+	"github.com/rh-ecosystem-edge/gitstream/internal/plan"
+	"github.com/rh-ecosystem-edge/gitstream/internal/report"
+)
+
+// Plan computes the actions that Run would take, without pushing branches or creating PRs and issues. Commits are
+// cherry-picked locally to predict whether they apply cleanly.
+func (s *Sync) Plan(ctx context.Context) (*plan.Plan, error) {
+	commits, err := s.missingCommits(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	upstreamSHA, downstreamSHA, err := s.tips()
+	if err != nil {
+		return nil, err
+	}
+
+	p := plan.Plan{
+		Actions:   make([]*plan.Action, 0, len(commits)),
+		CreatedAt: time.Now(),
+		Downstream: plan.Downstream{
+			MainBranch: s.DownstreamConfig.MainBranch,
+			Repo:       s.RepoName.String(),
+			SHA:        downstreamSHA.String(),
+		},
+		Upstream: plan.Upstream{
+			Ref: s.UpstreamConfig.Ref,
+			SHA: upstreamSHA.String(),
+			URL: s.UpstreamConfig.URL,
+		},
+		Version: plan.Version,
+	}
+
+	// The report is only used to select jobs and to get the outcome of skipped commits.
+	rep := report.Report{}
+	rep.Begin()
+
+	results := make([]*report.CommitResult, 0, len(commits))
+	actions := make(map[string]*plan.Action, len(commits))
+
+	for _, c := range commits {
+		r := rep.AddCommit(c)
+		a := &plan.Action{SHA: r.SHA, Subject: r.Subject}
+
+		results = append(results, r)
+		actions[r.SHA] = a
+		p.Actions = append(p.Actions, a)
+	}
+
+	jobs, err := s.selectJobs(ctx, commits, results)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range results {
+		if r.Outcome != report.OutcomeNotProcessed {
+			actions[r.SHA].Type = plan.ActionSkip
+			actions[r.SHA].SkipReason = r.Outcome
+		}
+	}
+
+	err = s.runJobs(ctx, jobs, func(ctx context.Context, j *pickJob, r pickResult, _ pushFunc) (bool, error) {
+		return false, s.planAction(ctx, actions[j.commit.Hash.String()], j, r)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// planAction fills a with what publishing the job would do.
+func (s *Sync) planAction(ctx context.Context, a *plan.Action, j *pickJob, r pickResult) error {
+	upstreamPR := s.upstreamPR(ctx, j)
+
+	if r.cherryPickErr != nil {
+		j.logger.Info("Planning issue", "error", r.cherryPickErr)
+
+		content, err := s.IssueHelper.Render(r.cherryPickErr, s.UpstreamConfig.URL, j.commit, upstreamPR, r.hookResults)
+		if err != nil {
+			return fmt.Errorf("could not render the issue for commit %s: %v", j.commit.Hash, err)
+		}
+
+		a.Content = content
+		a.Error = r.cherryPickErr.Error()
+		a.Type = plan.ActionIssue
+
+		return nil
+	}
+
+	j.logger.Info("Planning PR", "branch", j.branchName)
+
+	content, err := s.PRHelper.Render(s.UpstreamConfig.URL, j.commit, upstreamPR, r.hookResults)
+	if err != nil {
+		return fmt.Errorf("could not render the PR for commit %s: %v", j.commit.Hash, err)
+	}
+
+	autoMerge, err := s.shouldAutoMerge(j.commit, j.logger)
+	if err != nil {
+		return err
+	}
+
+	a.AutoMerge = autoMerge
+	a.Branch = j.branchName
+	a.Content = content
+	a.Draft = s.DownstreamConfig.CreateDraftPRs
+	a.Tree = r.tree.String()
+	a.Type = plan.ActionPR
+
+	return nil
+}
+
+// Apply takes the actions in p. It refuses to start if the upstream ref or the downstream main branch moved since p
+// was made, or if a planned commit is not missing downstream anymore. It stops if a commit is not cherry-picked as
+// predicted.
+func (s *Sync) Apply(ctx context.Context, p *plan.Plan) (err error) {
+	var (
+		rs    runState
+		start = time.Now()
+	)
+
+	rep := s.beginReport()
+
+	defer func() {
+		rep.Finish(err)
+		s.Metrics.ObserveRun(time.Since(start), rs.prsCreated, rs.issuesCreated, err)
+	}()
+
+	if p.Upstream.URL != s.UpstreamConfig.URL || p.Upstream.Ref != s.UpstreamConfig.Ref {
+		return fmt.Errorf("the plan was made for upstream %s@%s, not %s@%s", p.Upstream.URL, p.Upstream.Ref, s.UpstreamConfig.URL, s.UpstreamConfig.Ref)
+	}
+
+	if p.Downstream.Repo != s.RepoName.String() || p.Downstream.MainBranch != s.DownstreamConfig.MainBranch {
+		return fmt.Errorf(
+			"the plan was made for downstream %s@%s, not %s@%s",
+			p.Downstream.Repo,
+			p.Downstream.MainBranch,
+			s.RepoName,
+			s.DownstreamConfig.MainBranch,
+		)
+	}
+
+	commits, err := s.missingCommits(ctx)
+	if err != nil {
+		return err
+	}
+
+	upstreamSHA, downstreamSHA, err := s.tips()
+	if err != nil {
+		return err
+	}
+
+	if upstreamSHA.String() != p.Upstream.SHA {
+		return fmt.Errorf("upstream moved since the plan was made: %s is at %s, not %s", p.Upstream.Ref, upstreamSHA, p.Upstream.SHA)
+	}
+
+	if downstreamSHA.String() != p.Downstream.SHA {
+		return fmt.Errorf("downstream moved since the plan was made: %s is at %s, not %s", p.Downstream.MainBranch, downstreamSHA, p.Downstream.SHA)
+	}
+
+	missing := make(map[string]*object.Commit, len(commits))
+
+	for _, c := range commits {
+		missing[c.Hash.String()] = c
+	}
+
+	jobs := make([]*pickJob, 0, len(p.Actions))
+
+	for _, a := range p.Actions {
+		c, ok := missing[a.SHA]
+
+		switch a.Type {
+		case plan.ActionSkip:
+			if ok {
+				rep.AddCommit(c).SetOutcome(a.SkipReason)
+			}
+		case plan.ActionIssue, plan.ActionPR:
+			if !ok {
+				return fmt.Errorf("commit %s is not missing downstream anymore", a.SHA)
+			}
+
+			j := newPickJob(c, rep.AddCommit(c), s.Logger)
+			j.planned = a
+			jobs = append(jobs, j)
+		default:
+			return fmt.Errorf("commit %s: unknown action type %q", a.SHA, a.Type)
+		}
+	}
+
+	return s.runJobs(ctx, jobs, func(ctx context.Context, j *pickJob, r pickResult, push pushFunc) (bool, error) {
+		if err := checkPlanned(j, r); err != nil {
+			return false, err
+		}
+
+		return s.publish(ctx, j, r, push, &rs)
+	})
+}
+
+// checkPlanned returns an error if the job was not cherry-picked as planned.
+func checkPlanned(j *pickJob, r pickResult) error {
+	a := j.planned
+
+	switch {
+	case a.Type == plan.ActionIssue && r.cherryPickErr == nil:
+		return fmt.Errorf("commit %s was planned to fail to cherry-pick, but was cherry-picked cleanly", a.SHA)
+	case a.Type == plan.ActionPR && r.cherryPickErr != nil:
+		return fmt.Errorf("commit %s was planned to be cherry-picked cleanly, but failed: %v", a.SHA, r.cherryPickErr)
+	case a.Type == plan.ActionPR && r.tree.String() != a.Tree:
+		return fmt.Errorf("cherry-picking commit %s resulted in tree %s, not %s as planned", a.SHA, r.tree, a.Tree)
+	}
+
+	return nil
+}
+
+// tips returns the commits that the upstream ref, as last fetched, and the downstream main branch point to.
+func (s *Sync) tips() (plumbing.Hash, plumbing.Hash, error) {
+	upstreamRef, err := s.Repo.Reference(plumbing.NewRemoteReferenceName(internal.UpstreamRemoteName, s.UpstreamConfig.Ref), true)
+	if err != nil {
+		return plumbing.ZeroHash, plumbing.ZeroHash, fmt.Errorf("could not get the upstream ref %s: %v", s.UpstreamConfig.Ref, err)
+	}
+
+	downstreamRef, err := s.Repo.Reference(plumbing.NewBranchReferenceName(s.DownstreamConfig.MainBranch), true)
+	if err != nil {
+		return plumbing.ZeroHash, plumbing.ZeroHash, fmt.Errorf("could not get the downstream branch %s: %v", s.DownstreamConfig.MainBranch, err)
+	}
+
+	return upstreamRef.Hash(), downstreamRef.Hash(), nil
+}
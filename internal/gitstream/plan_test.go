@@ -0,0 +1,227 @@
+package gitstream
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-logr/logr"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-github/v47/github"
+	"github.com/rh-ecosystem-edge/gitstream/internal"
+	"github.com/rh-ecosystem-edge/gitstream/internal/config"
+	gh "github.com/rh-ecosystem-edge/gitstream/internal/github"
+	"github.com/rh-ecosystem-edge/gitstream/internal/gitutils"
+	"github.com/rh-ecosystem-edge/gitstream/internal/plan"
+	"github.com/rh-ecosystem-edge/gitstream/internal/report"
+	"github.com/rh-ecosystem-edge/gitstream/internal/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSync_PlanApply(t *testing.T) {
+	const (
+		downstreamMainBranch = "main"
+		githubToken          = "github-token"
+		ignoredAuthor        = "ignored-author"
+		repoPath             = "/repo/path"
+		upstreamRef          = "us-main"
+		upstreamURL          = "some-upstream-url"
+	)
+
+	ctx := context.Background()
+
+	type mocks struct {
+		cp          *gitutils.MockCherryPicker
+		differ      *gitutils.MockDiffer
+		helper      *gitutils.MockHelper
+		issueHelper *gh.MockIssueHelper
+		prHelper    *gh.MockPRHelper
+	}
+
+	setup := func(t *testing.T) (*Sync, *mocks, *git.Repository, plumbing.Hash) {
+		t.Helper()
+
+		ctrl := gomock.NewController(t)
+
+		m := mocks{
+			cp:          gitutils.NewMockCherryPicker(ctrl),
+			differ:      gitutils.NewMockDiffer(ctrl),
+			helper:      gitutils.NewMockHelper(ctrl),
+			issueHelper: gh.NewMockIssueHelper(ctrl),
+			prHelper:    gh.NewMockPRHelper(ctrl),
+		}
+
+		repo := test.NewRepo(t)
+		sha, commit := test.AddEmptyCommit(t, repo, "test commit")
+
+		for _, name := range []plumbing.ReferenceName{
+			plumbing.NewBranchReferenceName(downstreamMainBranch),
+			plumbing.NewRemoteReferenceName(internal.UpstreamRemoteName, upstreamRef),
+		} {
+			require.NoError(t, repo.Storer.SetReference(plumbing.NewHashReference(name, sha)))
+		}
+
+		s := &Sync{
+			CherryPicker: m.cp,
+			Differ:       m.differ,
+			DownstreamConfig: config.Downstream{
+				IgnoreAuthors: []string{ignoredAuthor},
+				LocalRepoPath: repoPath,
+				MainBranch:    downstreamMainBranch,
+				MaxOpenItems:  -1,
+			},
+			GitHelper:      m.helper,
+			GitHubToken:    githubToken,
+			IssueHelper:    m.issueHelper,
+			Logger:         logr.Discard(),
+			PRHelper:       m.prHelper,
+			Repo:           repo,
+			RepoName:       &gh.RepoName{Owner: "owner", Repo: "repo"},
+			UpstreamConfig: config.Upstream{Ref: upstreamRef, URL: upstreamURL},
+		}
+
+		return s, &m, repo, commit.TreeHash
+	}
+
+	newCommit := func(sha string, month time.Month, author string) *object.Commit {
+		return &object.Commit{
+			Author:    object.Signature{Name: author},
+			Committer: object.Signature{When: time.Date(2022, month, 1, 0, 0, 0, 0, time.UTC)},
+			Hash:      plumbing.NewHash(sha),
+			Message:   "Subject " + sha[:4] + "\n\nBody",
+		}
+	}
+
+	commits := []*object.Commit{
+		newCommit("e3229f3c533ed51070beff092e5c7694a8ee81f0", 1, "author"),
+		newCommit("9c08d42326af62aa0f8cea021c4d37971606148f", 2, "author"),
+		newCommit("0d1a26e67d8f5eaf1f6ba5c57fc3c7d91ac0fd1c", 3, ignoredAuthor),
+	}
+
+	prContent := &gh.Content{Body: "pr body", Title: "pr title"}
+	issueContent := &gh.Content{Body: "issue body", Title: "issue title"}
+	randomError := errors.New("random error")
+
+	makePlan := func(t *testing.T, s *Sync, m *mocks) *plan.Plan {
+		t.Helper()
+
+		gomock.InOrder(
+			m.differ.EXPECT().GetMissingCommits(ctx, s.Repo, s.RepoName, s.DiffConfig, downstreamMainBranch, s.UpstreamConfig).Return(commits, nil, nil),
+			m.issueHelper.EXPECT().ListAllOpen(ctx, true),
+			m.cp.EXPECT().Run(ctx, s.Repo, repoPath, commits[0]),
+			m.prHelper.EXPECT().Render(upstreamURL, commits[0], nil, gomock.Nil()).Return(prContent, nil),
+			m.cp.EXPECT().Run(ctx, s.Repo, repoPath, commits[1]).Return(nil, randomError),
+			m.issueHelper.EXPECT().Render(&ErrMatcher{Err: randomError}, upstreamURL, commits[1], nil, gomock.Nil()).Return(issueContent, nil),
+		)
+
+		p, err := s.Plan(ctx)
+		require.NoError(t, err)
+
+		return p
+	}
+
+	t.Run("plan and apply", func(t *testing.T) {
+		s, m, repo, tree := setup(t)
+
+		p := makePlan(t, s, m)
+
+		head, err := repo.Reference(plumbing.NewBranchReferenceName(downstreamMainBranch), true)
+		require.NoError(t, err)
+
+		assert.Equal(t, plan.Downstream{MainBranch: downstreamMainBranch, Repo: "owner/repo", SHA: head.Hash().String()}, p.Downstream)
+		assert.Equal(t, plan.Upstream{Ref: upstreamRef, SHA: head.Hash().String(), URL: upstreamURL}, p.Upstream)
+		assert.Equal(
+			t,
+			[]*plan.Action{
+				{
+					Branch:  "gs-" + commits[0].Hash.String(),
+					Content: prContent,
+					SHA:     commits[0].Hash.String(),
+					Subject: "Subject e322",
+					Tree:    tree.String(),
+					Type:    plan.ActionPR,
+				},
+				{
+					Content: issueContent,
+					Error:   "could not cherry-pick: random error",
+					SHA:     commits[1].Hash.String(),
+					Subject: "Subject 9c08",
+					Type:    plan.ActionIssue,
+				},
+				{
+					SHA:        commits[2].Hash.String(),
+					SkipReason: report.OutcomeSkippedIgnoredAuthor,
+					Subject:    "Subject 0d1a",
+					Type:       plan.ActionSkip,
+				},
+			},
+			p.Actions,
+		)
+
+		s.Report = &report.Report{}
+
+		gomock.InOrder(
+			m.differ.EXPECT().GetMissingCommits(ctx, s.Repo, s.RepoName, s.DiffConfig, downstreamMainBranch, s.UpstreamConfig).Return(commits, nil, nil),
+			m.cp.EXPECT().Run(ctx, s.Repo, repoPath, commits[0]),
+			m.helper.EXPECT().PushContextWithAuth(ctx, githubToken),
+			m.prHelper.
+				EXPECT().
+				CreateFromContent(ctx, "gs-"+commits[0].Hash.String(), downstreamMainBranch, prContent, false).
+				Return(&github.PullRequest{HTMLURL: github.String("some-pr-url")}, nil),
+			m.cp.EXPECT().Run(ctx, s.Repo, repoPath, commits[1]).Return(nil, randomError),
+			m.issueHelper.
+				EXPECT().
+				CreateFromContent(ctx, issueContent).
+				Return(&github.Issue{HTMLURL: github.String("some-issue-url")}, nil),
+		)
+
+		require.NoError(t, s.Apply(ctx, p))
+
+		require.Len(t, s.Report.Commits, 3)
+		assert.Equal(t, "some-pr-url", s.Report.Commits[0].PRURL)
+		assert.Equal(t, "some-issue-url", s.Report.Commits[1].IssueURL)
+		assert.Equal(t, report.OutcomeSkippedIgnoredAuthor, s.Report.Commits[2].Outcome)
+	})
+
+	t.Run("apply refuses to run if downstream moved", func(t *testing.T) {
+		s, m, repo, _ := setup(t)
+
+		p := makePlan(t, s, m)
+
+		sha, _ := test.AddEmptyCommit(t, repo, "new downstream commit")
+		require.NoError(
+			t,
+			repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName(downstreamMainBranch), sha)),
+		)
+
+		m.differ.EXPECT().GetMissingCommits(ctx, s.Repo, s.RepoName, s.DiffConfig, downstreamMainBranch, s.UpstreamConfig).Return(commits, nil, nil)
+
+		assert.EqualError(
+			t,
+			s.Apply(ctx, p),
+			"downstream moved since the plan was made: main is at "+sha.String()+", not "+p.Downstream.SHA,
+		)
+	})
+
+	t.Run("apply stops if a commit is not cherry-picked as planned", func(t *testing.T) {
+		s, m, _, _ := setup(t)
+
+		p := makePlan(t, s, m)
+
+		gomock.InOrder(
+			m.differ.EXPECT().GetMissingCommits(ctx, s.Repo, s.RepoName, s.DiffConfig, downstreamMainBranch, s.UpstreamConfig).Return(commits, nil, nil),
+			m.cp.EXPECT().Run(ctx, s.Repo, repoPath, commits[0]).Return(nil, randomError),
+		)
+
+		assert.EqualError(
+			t,
+			s.Apply(ctx, p),
+			"commit "+commits[0].Hash.String()+" was planned to be cherry-picked cleanly, but failed: could not cherry-pick: random error",
+		)
+	})
+}
@@ -0,0 +1,237 @@
+package gitstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-logr/logr"
+	"github.com/google/go-github/v47/github"
+	"github.com/rh-ecosystem-edge/gitstream/internal"
+	"github.com/rh-ecosystem-edge/gitstream/internal/config"
+	gh "github.com/rh-ecosystem-edge/gitstream/internal/github"
+	"github.com/rh-ecosystem-edge/gitstream/internal/gitutils"
+	"github.com/rh-ecosystem-edge/gitstream/internal/markup"
+	"github.com/rh-ecosystem-edge/gitstream/internal/report"
+)
+
+// Rebase replays the downstream-only commits of the downstream main branch, as listed by Carries, on a
+// gs-rebase-<upstream sha> branch created from the upstream ref, and opens a single PR meant to replace the main
+// branch. The PR is a draft if a carry conflicted. CherryPicker should not add markup to the commits it creates, so that they remain downstream-only.
+type Rebase struct {
+	CherryPicker     gitutils.CherryPicker
+	DownstreamConfig config.Downstream
+	DryRun           bool
+	Finder           markup.Finder
+	GitHelper        gitutils.Helper
+	GitHubToken      string
+	Logger           logr.Logger
+	PRHelper         gh.PRHelper
+	Repo             *git.Repository
+	RepoName         *gh.RepoName
+	RepoPath         string
+	// Report records the outcome of each carry if it is not nil.
+	Report         *report.Report
+	UpstreamConfig config.Upstream
+}
+
+func (r *Rebase) Run(ctx context.Context) (err error) {
+	rep := r.Report
+	if rep == nil {
+		rep = &report.Report{}
+	}
+
+	rep.Begin()
+	rep.DryRun = r.DryRun
+	rep.Downstream = report.Downstream{MainBranch: r.DownstreamConfig.MainBranch, Repo: r.RepoName.String()}
+	rep.Upstream = report.Upstream{Ref: r.UpstreamConfig.Ref, URL: r.UpstreamConfig.URL}
+
+	defer func() {
+		rep.Finish(err)
+	}()
+
+	ca := Carries{
+		DownstreamMainBranch: r.DownstreamConfig.MainBranch,
+		Finder:               r.Finder,
+		GitHelper:            r.GitHelper,
+		Logger:               r.Logger,
+		Repo:                 r.Repo,
+		UpstreamConfig:       r.UpstreamConfig,
+	}
+
+	upstream, carries, err := ca.find(ctx)
+	if err != nil {
+		return err
+	}
+
+	branchName := internal.GitStreamPrefix + "rebase-" + upstream.Hash.String()
+
+	logger := r.Logger.WithValues("branch", branchName, "upstream sha", upstream.Hash)
+
+	wt, err := r.Repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("could not get the worktree: %v", err)
+	}
+
+	if err = createBranchAt(r.Repo, wt, branchName, upstream.Hash); err != nil {
+		return err
+	}
+
+	data := gh.RebaseData{
+		AppName:     internal.AppName,
+		BaseBranch:  r.DownstreamConfig.MainBranch,
+		Carries:     make([]gh.RebaseCarry, 0, len(carries)),
+		UpstreamRef: r.UpstreamConfig.Ref,
+		UpstreamSHA: upstream.Hash.String(),
+		UpstreamURL: r.UpstreamConfig.URL,
+	}
+
+	conflicted := false
+
+	// Carries are replayed oldest first.
+	for i := len(carries) - 1; i >= 0; i-- {
+		commit := carries[i].commit
+
+		res := rep.AddCommit(commit)
+
+		if err = r.replay(ctx, wt, commit, res); err != nil {
+			return err
+		}
+
+		conflicted = conflicted || res.Outcome == report.OutcomeConflicted
+
+		data.Carries = append(data.Carries, gh.RebaseCarry{
+			Outcome: string(res.Outcome),
+			SHA:     res.SHA,
+			Subject: strings.ReplaceAll(res.Subject, "|", `\|`),
+		})
+	}
+
+	if r.DryRun {
+		logger.Info("Dry run: not pushing the branch nor creating a PR")
+		return nil
+	}
+
+	logger.Info("Pushing branch")
+
+	if err = r.GitHelper.PushBranchContextWithAuth(ctx, r.GitHubToken, branchName); err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("could not push branch %s: %v", branchName, err)
+	}
+
+	return r.openPR(ctx, logger, branchName, data, conflicted)
+}
+
+// openPR creates the rebase PR for branchName, or updates it if it exists. The PR is a draft if a carry conflicted; an
+// existing draft PR is marked as ready once no carry conflicts anymore.
+func (r *Rebase) openPR(ctx context.Context, logger logr.Logger, branchName string, data gh.RebaseData, conflicted bool) error {
+	prs, err := r.PRHelper.ListAllOpen(ctx, func(pr *github.PullRequest) bool {
+		return pr.GetHead().GetRef() == branchName
+	})
+	if err != nil {
+		return fmt.Errorf("could not list open PRs: %v", err)
+	}
+
+	content, err := gh.RenderRebase(data)
+	if err != nil {
+		return err
+	}
+
+	if len(prs) > 0 {
+		existing := prs[0]
+
+		if err = r.PRHelper.UpdateContent(ctx, existing, content); err != nil {
+			return err
+		}
+
+		if !conflicted && existing.GetDraft() {
+			if err = r.PRHelper.MakeReady(ctx, existing); err != nil {
+				return fmt.Errorf("could not mark PR %d as ready: %v", existing.GetNumber(), err)
+			}
+		}
+
+		logger.Info("Updated PR", "url", existing.GetHTMLURL())
+
+		return nil
+	}
+
+	pr, err := r.PRHelper.CreateFromContent(ctx, branchName, r.DownstreamConfig.MainBranch, content, conflicted)
+	if err != nil {
+		return fmt.Errorf("could not create the rebase PR: %v", err)
+	}
+
+	logger.Info("Created PR", "url", pr.GetHTMLURL())
+
+	return nil
+}
+
+// replay cherry-picks commit on the current branch and records the outcome in res. Conflicting and empty carries
+// are reported and discarded; only errors that prevent the rebase from continuing are returned.
+func (r *Rebase) replay(ctx context.Context, wt *git.Worktree, commit *object.Commit, res *report.CommitResult) error {
+	logger := r.Logger.WithValues("sha", commit.Hash)
+
+	res.Begin()
+
+	_, err := r.CherryPicker.Run(ctx, r.Repo, r.RepoPath, commit)
+
+	switch {
+	case err == nil:
+		logger.Info("Carry applied")
+		res.SetOutcome(report.OutcomeApplied)
+
+		return nil
+	case errors.Is(err, git.ErrEmptyCommit):
+		logger.Info("Carry became empty")
+		res.SetOutcome(report.OutcomeEmpty)
+	default:
+		logger.Info("Carry conflicted", "error", err)
+		res.SetError(err)
+		res.SetOutcome(report.OutcomeConflicted)
+	}
+
+	if err = wt.Reset(&git.ResetOptions{Mode: git.HardReset}); err != nil {
+		return fmt.Errorf("could not reset the worktree after %s: %v", commit.Hash, err)
+	}
+
+	return nil
+}
+
+// createBranchAt checks out branchName at hash, discarding any previous branch with the same name and any change in
+// the worktree.
+func createBranchAt(repo *git.Repository, wt *git.Worktree, branchName string, hash plumbing.Hash) error {
+	branchRef := plumbing.NewBranchReferenceName(branchName)
+
+	head, err := repo.Head()
+	if err != nil {
+		return fmt.Errorf("could not get HEAD: %v", err)
+	}
+
+	if head.Name() == branchRef {
+		// The checked out branch cannot be removed.
+		if err = wt.Reset(&git.ResetOptions{Commit: hash, Mode: git.HardReset}); err != nil {
+			return fmt.Errorf("could not reset branch %s: %v", branchName, err)
+		}
+
+		return nil
+	}
+
+	if err = repo.Storer.RemoveReference(branchRef); err != nil {
+		return fmt.Errorf("could not remove reference %q for branch %s: %v", branchRef, branchName, err)
+	}
+
+	co := git.CheckoutOptions{
+		Branch: branchRef,
+		Create: true,
+		Force:  true,
+		Hash:   hash,
+	}
+
+	if err = wt.Checkout(&co); err != nil {
+		return fmt.Errorf("could not checkout branch %s: %v", branchName, err)
+	}
+
+	return nil
+}
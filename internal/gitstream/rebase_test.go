@@ -0,0 +1,159 @@
+package gitstream
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-logr/logr"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-github/v47/github"
+	"github.com/rh-ecosystem-edge/gitstream/internal"
+	"github.com/rh-ecosystem-edge/gitstream/internal/config"
+	gh "github.com/rh-ecosystem-edge/gitstream/internal/github"
+	"github.com/rh-ecosystem-edge/gitstream/internal/gitutils"
+	"github.com/rh-ecosystem-edge/gitstream/internal/markup"
+	"github.com/rh-ecosystem-edge/gitstream/internal/report"
+	"github.com/rh-ecosystem-edge/gitstream/internal/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRebase_Run(t *testing.T) {
+	const (
+		downstreamMainBranch = "main"
+		githubToken          = "github-token"
+		markupName           = "Upstream-Commit"
+		upstreamMainBranch   = "us-main"
+		upstreamURL          = "some-upstream-url"
+	)
+
+	ctx := context.Background()
+
+	finder, err := markup.NewFinder(markupName)
+	require.NoError(t, err)
+
+	repo, fs := test.NewRepoWithFS(t)
+
+	one, two, upper1, upper2, lower, added := "one\n", "two\n", "ONE\n", "TWO\n", "uno\n", "added\n"
+
+	base, _ := test.AddCommit(t, repo, fs, "base", map[string]*string{"one.txt": &one, "two.txt": &two})
+	upstreamSHA, _ := test.AddCommit(t, repo, fs, "upstream", map[string]*string{"one.txt": &upper1, "two.txt": &upper2})
+
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+
+	require.NoError(
+		t,
+		wt.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName(downstreamMainBranch), Create: true, Hash: base, Force: true}),
+	)
+
+	test.AddCommit(t, repo, fs, "picked\n\n"+markupName+": "+upstreamSHA.String(), map[string]*string{"picked.txt": &added})
+	_, applied := test.AddCommit(t, repo, fs, "applied | carry", map[string]*string{"added.txt": &added})
+	_, conflicted := test.AddCommit(t, repo, fs, "conflicted carry", map[string]*string{"one.txt": &lower})
+	dsMainSHA, empty := test.AddCommit(t, repo, fs, "empty carry", map[string]*string{"two.txt": &upper2})
+
+	branchName := "gs-rebase-" + upstreamSHA.String()
+
+	newRebase := func(t *testing.T) (*Rebase, *gh.MockPRHelper) {
+		t.Helper()
+
+		ctrl := gomock.NewController(t)
+
+		mockHelper := gitutils.NewMockHelper(ctrl)
+		mockPRHelper := gh.NewMockPRHelper(ctrl)
+
+		gomock.InOrder(
+			mockHelper.
+				EXPECT().
+				GetBranchRef(ctx, downstreamMainBranch).
+				Return(plumbing.NewHashReference(plumbing.NewBranchReferenceName(downstreamMainBranch), dsMainSHA), nil),
+			mockHelper.EXPECT().RecreateRemote(ctx, internal.UpstreamRemoteName, upstreamURL),
+			mockHelper.
+				EXPECT().
+				GetRemoteRef(ctx, internal.UpstreamRemoteName, upstreamMainBranch).
+				Return(plumbing.NewHashReference(plumbing.NewRemoteReferenceName(internal.UpstreamRemoteName, upstreamMainBranch), upstreamSHA), nil),
+			mockHelper.EXPECT().PushBranchContextWithAuth(ctx, githubToken, branchName),
+		)
+
+		r := &Rebase{
+			CherryPicker:     gitutils.NewNativeCherryPicker(gitutils.CommitOptions{}, nil, logr.Discard()),
+			DownstreamConfig: config.Downstream{MainBranch: downstreamMainBranch},
+			Finder:           finder,
+			GitHelper:        mockHelper,
+			GitHubToken:      githubToken,
+			Logger:           logr.Discard(),
+			PRHelper:         mockPRHelper,
+			Repo:             repo,
+			RepoName:         &gh.RepoName{Owner: "owner", Repo: "repo"},
+			Report:           &report.Report{},
+			UpstreamConfig:   config.Upstream{Ref: upstreamMainBranch, URL: upstreamURL},
+		}
+
+		return r, mockPRHelper
+	}
+
+	t.Run("rebase PR is created", func(t *testing.T) {
+		r, mockPRHelper := newRebase(t)
+
+		gomock.InOrder(
+			mockPRHelper.EXPECT().ListAllOpen(ctx, gomock.Any()),
+			mockPRHelper.
+				EXPECT().
+				CreateFromContent(ctx, branchName, downstreamMainBranch, gomock.Any(), true).
+				DoAndReturn(func(_ context.Context, _, _ string, content *gh.Content, _ bool) (*github.PullRequest, error) {
+					assert.Contains(t, content.Body, "| `"+applied.Hash.String()+"` | applied \\| carry | applied |")
+					return &github.PullRequest{HTMLURL: github.String("some-pr-url")}, nil
+				}),
+		)
+
+		require.NoError(t, r.Run(ctx))
+
+		outcomes := make(map[string]report.Outcome)
+
+		for _, c := range r.Report.Commits {
+			outcomes[c.SHA] = c.Outcome
+		}
+
+		assert.Equal(
+			t,
+			map[string]report.Outcome{
+				applied.Hash.String():    report.OutcomeApplied,
+				conflicted.Hash.String(): report.OutcomeConflicted,
+				empty.Hash.String():      report.OutcomeEmpty,
+			},
+			outcomes,
+		)
+
+		// The branch is upstream plus the applied carry, without markup.
+		head, err := repo.Head()
+		require.NoError(t, err)
+		assert.Equal(t, plumbing.NewBranchReferenceName(branchName), head.Name())
+
+		headCommit, err := repo.CommitObject(head.Hash())
+		require.NoError(t, err)
+		assert.Equal(t, "applied | carry", headCommit.Message)
+
+		assert.Equal(t, []plumbing.Hash{upstreamSHA}, headCommit.ParentHashes)
+	})
+
+	t.Run("existing rebase PR is updated", func(t *testing.T) {
+		r, mockPRHelper := newRebase(t)
+
+		pr := &github.PullRequest{Draft: github.Bool(true), Head: &github.PullRequestBranch{Ref: github.String(branchName)}}
+
+		// A carry still conflicts: the PR stays a draft.
+		gomock.InOrder(
+			mockPRHelper.EXPECT().ListAllOpen(ctx, gomock.Any()).Return([]*github.PullRequest{pr}, nil),
+			mockPRHelper.
+				EXPECT().
+				UpdateContent(ctx, pr, gomock.Any()).
+				Do(func(_ context.Context, _ *github.PullRequest, content *gh.Content) {
+					assert.Contains(t, content.Body, "| `"+conflicted.Hash.String()+"` | conflicted carry | conflicted |")
+				}),
+		)
+
+		require.NoError(t, r.Run(ctx))
+	})
+}
@@ -0,0 +1,182 @@
+package gitstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-logr/logr"
+	"github.com/google/go-github/v47/github"
+	"github.com/hashicorp/go-multierror"
+	"github.com/rh-ecosystem-edge/gitstream/internal"
+	"github.com/rh-ecosystem-edge/gitstream/internal/config"
+	gh "github.com/rh-ecosystem-edge/gitstream/internal/github"
+	"github.com/rh-ecosystem-edge/gitstream/internal/gitutils"
+	"github.com/rh-ecosystem-edge/gitstream/internal/markup"
+)
+
+const downstreamRemoteName = "origin"
+
+type Refresh struct {
+	CherryPicker     gitutils.CherryPicker
+	DownstreamConfig config.Downstream
+	DryRun           bool
+	Finder           markup.Finder
+	GitHelper        gitutils.Helper
+	GitHubToken      string
+	Logger           logr.Logger
+	PRHelper         gh.PRHelper
+	Repo             *git.Repository
+	UpstreamConfig   config.Upstream
+}
+
+func (r *Refresh) Run(ctx context.Context) error {
+	const remoteName = internal.UpstreamRemoteName
+
+	if _, err := r.GitHelper.RecreateRemote(ctx, remoteName, r.UpstreamConfig.URL); err != nil {
+		return fmt.Errorf("could not recreate remote: %v", err)
+	}
+
+	if err := r.GitHelper.FetchRemoteContext(ctx, remoteName, r.UpstreamConfig.Ref); err != nil {
+		return fmt.Errorf("could not fetch remote %s: %v", remoteName, err)
+	}
+
+	// PRs are refreshed onto the main branch as it is on the downstream repository, not as it was last checked out.
+	if err := r.GitHelper.ResetBranchToRemote(ctx, downstreamRemoteName, r.DownstreamConfig.MainBranch); err != nil {
+		return fmt.Errorf("could not update the downstream main branch: %v", err)
+	}
+
+	prs, err := r.PRHelper.ListAllOpen(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not list open PRs: %v", err)
+	}
+
+	wt, err := r.Repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("could not get the worktree: %v", err)
+	}
+
+	var multiErr error
+
+	upstream := newUpstreamSHAs(r.Repo, r.UpstreamConfig.Ref)
+
+	for _, pr := range prs {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		if err := r.refreshPR(ctx, wt, pr, upstream); err != nil {
+			r.Logger.Error(err, "Could not refresh PR; continuing with the next one", "url", pr.GetHTMLURL())
+			multiErr = multierror.Append(multiErr, fmt.Errorf("could not refresh PR %d: %v", pr.GetNumber(), err))
+		}
+	}
+
+	return multiErr
+}
+
+func (r *Refresh) refreshPR(ctx context.Context, wt *git.Worktree, pr *github.PullRequest, upstream *upstreamSHAs) error {
+	logger := r.Logger.WithValues("url", pr.GetHTMLURL())
+	logger.Info("Processing PR")
+
+	branchName := pr.GetHead().GetRef()
+
+	if !strings.HasPrefix(branchName, internal.GitStreamPrefix) {
+		logger.Info("PR branch was not created by GitStream; skipping", "branch", branchName)
+		return nil
+	}
+
+	shas, err := r.Finder.FindSHAs(pr.GetBody())
+	if err != nil {
+		return fmt.Errorf("error while looking for SHAs in %q: %v", pr.GetBody(), err)
+	}
+
+	if len(shas) != 1 {
+		logger.Info("Expected exactly one upstream SHA in the PR body; skipping", "count", len(shas))
+		return nil
+	}
+
+	hash, err := upstream.resolve(shas[0])
+	if err != nil {
+		return err
+	}
+
+	upstreamCommit, err := r.Repo.CommitObject(hash)
+	if err != nil {
+		return fmt.Errorf("could not find upstream commit %s: %v", hash, err)
+	}
+
+	remoteRef, err := r.GitHelper.GetRemoteRef(ctx, downstreamRemoteName, branchName)
+	if err != nil {
+		return fmt.Errorf("could not get the current head of branch %s: %v", branchName, err)
+	}
+
+	remoteCommit, err := r.Repo.CommitObject(remoteRef.Hash())
+	if err != nil {
+		return fmt.Errorf("could not get commit %s: %v", remoteRef.Hash(), err)
+	}
+
+	logger = logger.WithValues("sha", upstreamCommit.Hash.String())
+
+	if err := recreateBranchFromMain(r.Repo, wt, r.DownstreamConfig.MainBranch, branchName, logger); err != nil {
+		return err
+	}
+
+	logger.Info("Running cherry-pick")
+
+	if _, err := r.CherryPicker.Run(ctx, r.Repo, r.DownstreamConfig.LocalRepoPath, upstreamCommit); err != nil {
+		logger.Info("Could not cherry-pick onto the main branch", "error", err)
+
+		if r.DryRun {
+			logger.Info("Dry run: skipping comment and conversion to draft")
+			return nil
+		}
+
+		if err := r.PRHelper.CommentError(ctx, pr, err, r.UpstreamConfig.URL, upstreamCommit); err != nil {
+			return fmt.Errorf("could not comment on the PR: %v", err)
+		}
+
+		if pr.GetDraft() {
+			return nil
+		}
+
+		if err := r.PRHelper.ConvertToDraft(ctx, pr); err != nil {
+			return fmt.Errorf("could not convert the PR to draft: %v", err)
+		}
+
+		logger.Info("Converted PR to draft")
+
+		return nil
+	}
+
+	head, err := r.Repo.Head()
+	if err != nil {
+		return fmt.Errorf("could not get HEAD: %v", err)
+	}
+
+	newCommit, err := r.Repo.CommitObject(head.Hash())
+	if err != nil {
+		return fmt.Errorf("could not get commit %s: %v", head.Hash(), err)
+	}
+
+	if newCommit.TreeHash == remoteCommit.TreeHash {
+		logger.Info("Tree unchanged; not pushing")
+		return nil
+	}
+
+	if r.DryRun {
+		logger.Info("Dry run: skipping push")
+		return nil
+	}
+
+	logger.Info("Tree changed; force-pushing branch", "name", branchName)
+
+	if err := r.GitHelper.PushBranchContextWithAuth(ctx, r.GitHubToken, branchName); err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("error while pushing branch %s: %v", branchName, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+package gitstream
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-logr/logr"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-github/v47/github"
+	"github.com/rh-ecosystem-edge/gitstream/internal/config"
+	gh "github.com/rh-ecosystem-edge/gitstream/internal/github"
+	"github.com/rh-ecosystem-edge/gitstream/internal/gitutils"
+	"github.com/rh-ecosystem-edge/gitstream/internal/markup"
+	"github.com/rh-ecosystem-edge/gitstream/internal/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefresh_Run(t *testing.T) {
+	const (
+		downstreamMainBranch = "main"
+		githubToken          = "github-token"
+		repoPath             = "/repo/path"
+		upstreamMainBranch   = "us-main"
+		upstreamURL          = "some-upstream-url"
+	)
+
+	ctrl := gomock.NewController(t)
+
+	mockCP := gitutils.NewMockCherryPicker(ctrl)
+	mockFinder := markup.NewMockFinder(ctrl)
+	mockHelper := gitutils.NewMockHelper(ctrl)
+	mockPRHelper := gh.NewMockPRHelper(ctrl)
+
+	ctx := context.Background()
+
+	repo, fs := test.NewRepoWithFS(t)
+
+	mainSHA, _ := test.AddEmptyCommit(t, repo, "downstream commit")
+	upstreamSHA, upstreamCommit := test.AddEmptyCommit(t, repo, "upstream commit")
+
+	require.NoError(
+		t,
+		repo.Storer.SetReference(
+			plumbing.NewHashReference(plumbing.NewBranchReferenceName(downstreamMainBranch), mainSHA),
+		),
+	)
+
+	require.NoError(
+		t,
+		repo.Storer.SetReference(
+			plumbing.NewHashReference(plumbing.NewRemoteReferenceName("gs-upstream", upstreamMainBranch), upstreamSHA),
+		),
+	)
+
+	remoteRef := plumbing.NewHashReference(plumbing.NewRemoteReferenceName("origin", "gs-stale"), mainSHA)
+
+	newPR := func(number int, branch string, draft bool) *github.PullRequest {
+		return &github.PullRequest{
+			Body:    github.String("some body"),
+			Draft:   github.Bool(draft),
+			Head:    &github.PullRequestBranch{Ref: github.String(branch)},
+			HTMLURL: github.String("some-url"),
+			Number:  github.Int(number),
+		}
+	}
+
+	upToDatePR := newPR(1, "gs-up-to-date", false)
+	stalePR := newPR(2, "gs-stale", false)
+	conflictingPR := newPR(3, "gs-conflicting", false)
+	foreignPR := newPR(4, "some-branch", false)
+	failingPR := newPR(5, "gs-failing", false)
+
+	addFile := func(_ context.Context, _ *git.Repository, _ string, _ *object.Commit) {
+		wt, err := repo.Worktree()
+		require.NoError(t, err)
+
+		const testFileName = "test-file"
+
+		fd, err := fs.Create(testFileName)
+		require.NoError(t, err)
+
+		_, err = fd.Write([]byte("test contents"))
+		require.NoError(t, err)
+		require.NoError(t, fd.Close())
+
+		_, err = wt.Add(testFileName)
+		require.NoError(t, err)
+
+		co := git.CommitOptions{
+			Author: &object.Signature{Name: "Unit tests", When: time.Now()},
+		}
+
+		_, err = wt.Commit("cherry-pick", &co)
+		require.NoError(t, err)
+	}
+
+	randomError := errors.New("random error")
+
+	gomock.InOrder(
+		mockHelper.EXPECT().RecreateRemote(ctx, "gs-upstream", upstreamURL),
+		mockHelper.EXPECT().FetchRemoteContext(ctx, "gs-upstream", upstreamMainBranch),
+		mockHelper.EXPECT().ResetBranchToRemote(ctx, "origin", downstreamMainBranch),
+		mockPRHelper.
+			EXPECT().
+			ListAllOpen(ctx, nil).
+			Return([]*github.PullRequest{upToDatePR, failingPR, stalePR, conflictingPR, foreignPR}, nil),
+
+		mockFinder.EXPECT().FindSHAs("some body").Return([]string{upstreamSHA.String()}, nil),
+		mockHelper.EXPECT().GetRemoteRef(ctx, "origin", "gs-up-to-date").Return(remoteRef, nil),
+		mockCP.EXPECT().Run(ctx, repo, repoPath, upstreamCommit),
+
+		// A failure does not prevent the next PRs from being refreshed.
+		mockFinder.EXPECT().FindSHAs("some body").Return([]string{upstreamSHA.String()}, nil),
+		mockHelper.EXPECT().GetRemoteRef(ctx, "origin", "gs-failing").Return(nil, randomError),
+
+		// Abbreviated SHAs are resolved against the upstream history.
+		mockFinder.EXPECT().FindSHAs("some body").Return([]string{upstreamSHA.String()[:10]}, nil),
+		mockHelper.EXPECT().GetRemoteRef(ctx, "origin", "gs-stale").Return(remoteRef, nil),
+		mockCP.EXPECT().Run(ctx, repo, repoPath, upstreamCommit).Do(addFile),
+		mockHelper.EXPECT().PushBranchContextWithAuth(ctx, githubToken, "gs-stale"),
+
+		mockFinder.EXPECT().FindSHAs("some body").Return([]string{upstreamSHA.String()}, nil),
+		mockHelper.EXPECT().GetRemoteRef(ctx, "origin", "gs-conflicting").Return(remoteRef, nil),
+		mockCP.EXPECT().Run(ctx, repo, repoPath, upstreamCommit).Return(nil, randomError),
+		mockPRHelper.EXPECT().CommentError(ctx, conflictingPR, randomError, upstreamURL, upstreamCommit),
+		mockPRHelper.EXPECT().ConvertToDraft(ctx, conflictingPR),
+	)
+
+	r := Refresh{
+		CherryPicker: mockCP,
+		DownstreamConfig: config.Downstream{
+			LocalRepoPath: repoPath,
+			MainBranch:    downstreamMainBranch,
+		},
+		Finder:      mockFinder,
+		GitHelper:   mockHelper,
+		GitHubToken: githubToken,
+		Logger:      logr.Discard(),
+		PRHelper:    mockPRHelper,
+		Repo:        repo,
+		UpstreamConfig: config.Upstream{
+			Ref: upstreamMainBranch,
+			URL: upstreamURL,
+		},
+	}
+
+	err := r.Run(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "could not refresh PR 5")
+	assert.NotContains(t, err.Error(), "could not refresh PR 2")
+}
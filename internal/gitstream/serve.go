@@ -0,0 +1,93 @@
+package gitstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/rh-ecosystem-edge/gitstream/internal/config"
+	gh "github.com/rh-ecosystem-edge/gitstream/internal/github"
+	"github.com/rh-ecosystem-edge/gitstream/internal/metrics"
+	"github.com/rh-ecosystem-edge/gitstream/internal/webhook"
+)
+
+type Serve struct {
+	Logger           logr.Logger
+	Metrics          *metrics.Metrics
+	MetricsConfig    config.Metrics
+	ServeConfig      config.Serve
+	Sync             *Sync
+	UpstreamConfig   config.Upstream
+	UpstreamRepoName *gh.RepoName
+	WebhookSecret    []byte
+}
+
+func (s *Serve) Run(ctx context.Context) error {
+	d := webhook.NewDebouncer(s.ServeConfig.Debounce, s.runSync, s.Logger.WithName("debouncer"))
+
+	mux := http.NewServeMux()
+	mux.Handle(
+		s.ServeConfig.Path,
+		webhook.NewHandler(s.WebhookSecret, s.UpstreamRepoName, s.UpstreamConfig.Ref, d.Trigger, s.Logger.WithName("webhook")),
+	)
+
+	if s.Metrics != nil {
+		mux.Handle(s.MetricsConfig.Path, s.Metrics.Handler())
+	}
+
+	srv := http.Server{
+		Addr:              s.ServeConfig.Address,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	debouncerDone := make(chan struct{})
+
+	go func() {
+		defer close(debouncerDone)
+
+		if err := d.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			s.Logger.Error(err, "Debouncer exited")
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer shutdownCancel()
+
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			s.Logger.Error(err, "Could not shut down the HTTP server")
+		}
+	}()
+
+	s.Logger.Info("Listening for webhooks", "address", s.ServeConfig.Address, "path", s.ServeConfig.Path)
+
+	err := srv.ListenAndServe()
+
+	cancel()
+	<-debouncerDone
+
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("HTTP server error: %v", err)
+	}
+
+	return nil
+}
+
+// runSync brings the downstream main branch up to date with origin, as the server outlives many of its changes, and
+// runs Sync.
+func (s *Serve) runSync(ctx context.Context) error {
+	if err := s.Sync.GitHelper.ResetBranchToRemote(ctx, downstreamRemoteName, s.Sync.DownstreamConfig.MainBranch); err != nil {
+		return fmt.Errorf("could not update the downstream main branch: %v", err)
+	}
+
+	return s.Sync.Run(ctx)
+}
@@ -0,0 +1,95 @@
+package gitstream
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-logr/logr"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-github/v47/github"
+	"github.com/rh-ecosystem-edge/gitstream/internal/config"
+	gh "github.com/rh-ecosystem-edge/gitstream/internal/github"
+	"github.com/rh-ecosystem-edge/gitstream/internal/gitutils"
+	"github.com/rh-ecosystem-edge/gitstream/internal/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServe_runSync(t *testing.T) {
+	const downstreamMainBranch = "main"
+
+	ctx := context.Background()
+
+	origin, err := git.PlainInit(t.TempDir(), false)
+	require.NoError(t, err)
+
+	first, _ := test.AddEmptyCommit(t, origin, "first")
+
+	require.NoError(
+		t,
+		origin.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName(downstreamMainBranch), first)),
+	)
+
+	originWT, err := origin.Worktree()
+	require.NoError(t, err)
+	require.NoError(t, originWT.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName(downstreamMainBranch)}))
+
+	repo, err := git.PlainClone(t.TempDir(), false, &git.CloneOptions{URL: originWT.Filesystem.Root()})
+	require.NoError(t, err)
+
+	// A stale local commit on main, that the next run must discard.
+	stale, _ := test.AddEmptyCommit(t, repo, "stale")
+
+	ctrl := gomock.NewController(t)
+
+	mockDiffer := gitutils.NewMockDiffer(ctrl)
+	mockIssueHelper := gh.NewMockIssueHelper(ctrl)
+
+	repoName := &gh.RepoName{Owner: "owner", Repo: "repo"}
+
+	s := Serve{
+		Logger: logr.Discard(),
+		Sync: &Sync{
+			Differ:           mockDiffer,
+			DownstreamConfig: config.Downstream{MainBranch: downstreamMainBranch, MaxOpenItems: -1},
+			GitHelper:        gitutils.NewHelper(repo, logr.Discard()),
+			IssueHelper:      mockIssueHelper,
+			Logger:           logr.Discard(),
+			Repo:             repo,
+			RepoName:         repoName,
+		},
+	}
+
+	var seen []plumbing.Hash
+
+	mockDiffer.
+		EXPECT().
+		GetMissingCommits(ctx, repo, repoName, config.Diff{}, downstreamMainBranch, config.Upstream{}).
+		DoAndReturn(func(_ context.Context, repo *git.Repository, _ *gh.RepoName, _ config.Diff, branch string, _ config.Upstream) ([]*object.Commit, []gitutils.UnresolvedIntent, error) {
+			ref, err := repo.Reference(plumbing.NewBranchReferenceName(branch), true)
+			require.NoError(t, err)
+
+			seen = append(seen, ref.Hash())
+
+			return nil, nil, nil
+		}).
+		Times(2)
+
+	mockIssueHelper.EXPECT().ListAllOpen(ctx, true).Return([]*github.Issue{}, nil).Times(2)
+
+	require.NoError(t, s.runSync(ctx))
+
+	second, _ := test.AddEmptyCommit(t, origin, "second")
+
+	require.NoError(t, s.runSync(ctx))
+
+	assert.Equal(t, []plumbing.Hash{first, second}, seen)
+	assert.NotContains(t, seen, stale)
+
+	head, err := repo.Head()
+	require.NoError(t, err)
+	assert.Equal(t, second, head.Hash())
+}
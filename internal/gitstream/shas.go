@@ -0,0 +1,83 @@
+package gitstream
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/rh-ecosystem-edge/gitstream/internal"
+)
+
+// upstreamSHAs resolves the SHAs found in GitStream issues and PRs, which may be abbreviated, to upstream commits.
+// Like the differ does for downstream intents, an abbreviated SHA is resolved if it is the prefix of exactly one commit
+// reachable from the upstream ref, which must already be fetched. The upstream history is only walked once, and only
+// if an abbreviated SHA must be resolved.
+type upstreamSHAs struct {
+	commits []plumbing.Hash
+	ref     string
+	repo    *git.Repository
+	walked  bool
+}
+
+func newUpstreamSHAs(repo *git.Repository, ref string) *upstreamSHAs {
+	return &upstreamSHAs{ref: ref, repo: repo}
+}
+
+// resolve returns the full hash of sha.
+func (u *upstreamSHAs) resolve(sha string) (plumbing.Hash, error) {
+	if len(sha) == 2*len(plumbing.ZeroHash) {
+		return plumbing.NewHash(sha), nil
+	}
+
+	if !u.walked {
+		if err := u.walk(); err != nil {
+			return plumbing.ZeroHash, err
+		}
+	}
+
+	candidates := make([]plumbing.Hash, 0)
+
+	for _, h := range u.commits {
+		if strings.HasPrefix(h.String(), sha) {
+			candidates = append(candidates, h)
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return plumbing.ZeroHash, fmt.Errorf("abbreviated SHA %s matches no upstream commit", sha)
+	case 1:
+		return candidates[0], nil
+	default:
+		return plumbing.ZeroHash, fmt.Errorf("abbreviated SHA %s is ambiguous: it matches %v", sha, candidates)
+	}
+}
+
+func (u *upstreamSHAs) walk() error {
+	name := plumbing.NewRemoteReferenceName(internal.UpstreamRemoteName, u.ref)
+
+	ref, err := u.repo.Reference(name, true)
+	if err != nil {
+		return fmt.Errorf("could not get the reference for %s: %v", name, err)
+	}
+
+	iter, err := u.repo.Log(&git.LogOptions{From: ref.Hash()})
+	if err != nil {
+		return fmt.Errorf("could not get a commit iterator: %v", err)
+	}
+
+	err = iter.ForEach(func(c *object.Commit) error {
+		u.commits = append(u.commits, c.Hash)
+		return nil
+	})
+	if err != nil && !errors.Is(err, plumbing.ErrObjectNotFound) {
+		return fmt.Errorf("could not walk the history of %s: %v", name, err)
+	}
+
+	u.walked = true
+
+	return nil
+}
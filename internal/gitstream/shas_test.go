@@ -0,0 +1,50 @@
+package gitstream
+
+import (
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/rh-ecosystem-edge/gitstream/internal/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpstreamSHAs_resolve(t *testing.T) {
+	const upstreamMainBranch = "us-main"
+
+	repo := test.NewRepo(t)
+
+	first, _ := test.AddEmptyCommit(t, repo, "first")
+	second, _ := test.AddEmptyCommit(t, repo, "second")
+
+	t.Run("full SHAs are not looked up", func(t *testing.T) {
+		const sha = "a109a5cfd36f7abe14089da2da0638149c4dc6cc"
+
+		h, err := newUpstreamSHAs(repo, upstreamMainBranch).resolve(sha)
+		require.NoError(t, err)
+		assert.Equal(t, plumbing.NewHash(sha), h)
+	})
+
+	t.Run("upstream ref not fetched", func(t *testing.T) {
+		_, err := newUpstreamSHAs(repo, upstreamMainBranch).resolve(first.String()[:8])
+		assert.ErrorContains(t, err, "could not get the reference for refs/remotes/gs-upstream/us-main")
+	})
+
+	require.NoError(
+		t,
+		repo.Storer.SetReference(
+			plumbing.NewHashReference(plumbing.NewRemoteReferenceName("gs-upstream", upstreamMainBranch), second),
+		),
+	)
+
+	u := newUpstreamSHAs(repo, upstreamMainBranch)
+
+	for _, h := range []plumbing.Hash{first, second} {
+		res, err := u.resolve(h.String()[:8])
+		require.NoError(t, err)
+		assert.Equal(t, h, res)
+	}
+
+	_, err := u.resolve("0000")
+	assert.EqualError(t, err, "abbreviated SHA 0000 matches no upstream commit")
+}
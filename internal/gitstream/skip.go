@@ -0,0 +1,86 @@
+package gitstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/rh-ecosystem-edge/gitstream/internal/skiplist"
+)
+
+// Skip adds upstream commits to the skip list at Path. The file must then be committed to the downstream main
+// branch for the entries to be taken into account.
+type Skip struct {
+	// Expires is the time from which the entries are ignored. The entries never expire if it is nil.
+	Expires *time.Time
+	Logger  logr.Logger
+	Path    string
+	Reason  string
+	SHAs    []string
+}
+
+func (s *Skip) Run(_ context.Context) error {
+	if s.Reason == "" {
+		return errors.New("a reason is required")
+	}
+
+	l, err := skiplist.ReadFile(s.Path)
+	if err != nil {
+		return err
+	}
+
+	for _, sha := range s.SHAs {
+		normalized, err := skiplist.NormalizeSHA(sha)
+		if err != nil {
+			return err
+		}
+
+		s.Logger.Info("Skipping commit", "sha", normalized, "reason", s.Reason, "expires", s.Expires)
+		l.Add(skiplist.Entry{Expires: s.Expires, Reason: s.Reason, SHA: normalized})
+	}
+
+	if err = l.WriteFile(s.Path); err != nil {
+		return err
+	}
+
+	s.Logger.Info("Updated the skip list; commit it to the main branch for it to take effect", "path", s.Path)
+
+	return nil
+}
+
+// Unskip removes upstream commits from the skip list at Path.
+type Unskip struct {
+	Logger logr.Logger
+	Path   string
+	SHAs   []string
+}
+
+func (u *Unskip) Run(_ context.Context) error {
+	l, err := skiplist.ReadFile(u.Path)
+	if err != nil {
+		return err
+	}
+
+	for _, sha := range u.SHAs {
+		normalized, err := skiplist.NormalizeSHA(sha)
+		if err != nil {
+			return err
+		}
+
+		if !l.Remove(normalized) {
+			return fmt.Errorf("%s is not in the skip list", normalized)
+		}
+
+		u.Logger.Info("Removed commit from the skip list", "sha", normalized)
+	}
+
+	if err = l.WriteFile(u.Path); err != nil {
+		return err
+	}
+
+	u.Logger.Info("Updated the skip list; commit it to the main branch for it to take effect", "path", u.Path)
+
+	return nil
+}
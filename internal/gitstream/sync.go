@@ -5,16 +5,22 @@ import (
 	"errors"
 	"fmt"
 	"sort"
+	"time"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-logr/logr"
-	"github.com/rh-ecosystem-edge/gitstream/internal"
+	"github.com/google/go-github/v47/github"
 	"github.com/rh-ecosystem-edge/gitstream/internal/config"
 	gh "github.com/rh-ecosystem-edge/gitstream/internal/github"
 	"github.com/rh-ecosystem-edge/gitstream/internal/gitutils"
+	"github.com/rh-ecosystem-edge/gitstream/internal/hooks"
+	"github.com/rh-ecosystem-edge/gitstream/internal/metrics"
+	"github.com/rh-ecosystem-edge/gitstream/internal/plan"
 	"github.com/rh-ecosystem-edge/gitstream/internal/process"
+	"github.com/rh-ecosystem-edge/gitstream/internal/report"
+	"github.com/rh-ecosystem-edge/gitstream/internal/rules"
 )
 
 type Sync struct {
@@ -25,32 +31,101 @@ type Sync struct {
 	DryRun           bool
 	GitHelper        gitutils.Helper
 	GitHubToken      string
+	Hooks            *hooks.Runner
 	IssueHelper      gh.IssueHelper
 	Logger           logr.Logger
+	Metrics          *metrics.Metrics
 	PRHelper         gh.PRHelper
 	Repo             *git.Repository
 	RepoName         *gh.RepoName
+	Report           *report.Report
+	Rules            *rules.Engine
+	SyncConfig       config.Sync
 	UpstreamConfig   config.Upstream
+	UpstreamHelper   gh.UpstreamHelper
+	WorktreeManager  gitutils.WorktreeManager
 }
 
-func (s *Sync) Run(ctx context.Context) error {
-	commits, err := s.Differ.GetMissingCommits(
+func (s *Sync) Run(ctx context.Context) (err error) {
+	var (
+		rs    runState
+		start = time.Now()
+	)
+
+	rep := s.beginReport()
+
+	defer func() {
+		rep.Finish(err)
+		s.Metrics.ObserveRun(time.Since(start), rs.prsCreated, rs.issuesCreated, err)
+	}()
+
+	commits, err := s.missingCommits(ctx)
+	if err != nil {
+		return err
+	}
+
+	results := make([]*report.CommitResult, 0, len(commits))
+
+	for _, c := range commits {
+		results = append(results, rep.AddCommit(c))
+	}
+
+	jobs, err := s.selectJobs(ctx, commits, results)
+	if err != nil {
+		return err
+	}
+
+	return s.runJobs(ctx, jobs, func(ctx context.Context, j *pickJob, r pickResult, push pushFunc) (bool, error) {
+		return s.publish(ctx, j, r, push, &rs)
+	})
+}
+
+// beginReport starts s.Report, or a report that is discarded if s.Report is nil.
+func (s *Sync) beginReport() *report.Report {
+	rep := s.Report
+	if rep == nil {
+		rep = &report.Report{}
+	}
+
+	rep.Begin()
+	rep.DryRun = s.DryRun
+	rep.Downstream = report.Downstream{MainBranch: s.DownstreamConfig.MainBranch, Repo: s.RepoName.String()}
+	rep.Upstream = report.Upstream{Ref: s.UpstreamConfig.Ref, URL: s.UpstreamConfig.URL}
+
+	return rep
+}
+
+// missingCommits returns the upstream commits missing downstream, oldest first.
+func (s *Sync) missingCommits(ctx context.Context) ([]*object.Commit, error) {
+	commits, _, err := s.Differ.GetMissingCommits(
 		ctx,
 		s.Repo,
 		s.RepoName,
-		s.DiffConfig.CommitsSince,
+		s.DiffConfig,
 		s.DownstreamConfig.MainBranch,
 		s.UpstreamConfig,
 	)
 	if err != nil {
-		return fmt.Errorf("could not get commits not present in downstream: %v", err)
+		return nil, fmt.Errorf("could not get commits not present in downstream: %v", err)
 	}
 
+	s.Metrics.SetMissingCommits(len(commits), oldestCommitTime(commits))
+
+	sort.Slice(commits, func(i, j int) bool {
+		return commits[i].Committer.When.Before(commits[j].Committer.When)
+	})
+
+	return commits, nil
+}
+
+// selectJobs returns a job for each of commits that can be cherry-picked, and sets the outcome of the other commits
+// in results.
+func (s *Sync) selectJobs(ctx context.Context, commits []*object.Commit, results []*report.CommitResult) ([]*pickJob, error) {
 	s.Logger.V(1).Info("Listing GitStream issues (including PRs)")
 
 	issuesAndPRs, err := s.IssueHelper.ListAllOpen(ctx, true)
 	if err != nil {
-		return fmt.Errorf("could not list issues: %v", err)
+		return nil, fmt.Errorf("could not list issues: %v", err)
 	}
 
 	existingOpenIssues := len(issuesAndPRs)
@@ -59,6 +134,8 @@ func (s *Sync) Run(ctx context.Context) error {
 
 	maxItems := s.DownstreamConfig.MaxOpenItems
 
+	s.Metrics.SetOpenItems(existingOpenIssues, maxItems)
+
 	if maxItems != -1 && existingOpenIssues > maxItems {
 		s.Logger.Info(
 			"Maximum number of items on GitHub exceeded",
@@ -66,33 +143,18 @@ func (s *Sync) Run(ctx context.Context) error {
 			"max", maxItems,
 		)
 
-		return nil
-	}
-
-	sort.Slice(commits, func(i, j int) bool {
-		return commits[i].Committer.When.Before(commits[j].Committer.When)
-	})
-
-	wt, err := s.Repo.Worktree()
-	if err != nil {
-		return fmt.Errorf("could not get the worktree: %v", err)
-	}
+		setOutcomes(results, report.OutcomeSkippedMaxItems)
 
-	mainCheckoutOptions := git.CheckoutOptions{
-		Branch: plumbing.NewBranchReferenceName(s.DownstreamConfig.MainBranch),
-		Force:  true,
+		return nil, nil
 	}
 
 	canBeCreated := maxItems - existingOpenIssues
 	ignoreAuthors := makeStringSet(s.DownstreamConfig.IgnoreAuthors)
 
-	for _, c := range commits {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
+	// Every commit that is cherry-picked results in exactly one PR or issue, so the jobs are selected upfront.
+	jobs := make([]*pickJob, 0, len(commits))
 
+	for i, c := range commits {
 		if maxItems != -1 && canBeCreated <= 0 {
 			s.Logger.Info(
 				"Maximum number of open objects reached",
@@ -100,89 +162,401 @@ func (s *Sync) Run(ctx context.Context) error {
 				"max", maxItems,
 			)
 
-			return nil
+			setOutcomes(results[i:], report.OutcomeSkippedMaxItems)
+
+			break
 		}
 
 		if _, ok := ignoreAuthors[c.Author.Name]; ok {
 			s.Logger.Info("Skipping ignored author", "name", c.Author.Name)
+			results[i].SetOutcome(report.OutcomeSkippedIgnoredAuthor)
 			continue
 		}
 
-		canBeCreated--
+		d, err := s.Rules.Evaluate(ctx, c)
+		if err != nil {
+			return nil, fmt.Errorf("could not evaluate the rules for commit %s: %v", c.Hash, err)
+		}
 
-		sha := c.Hash.String()
+		if !d.Include {
+			s.Logger.Info("Skipping commit excluded by rule", "sha", c.Hash, "rule", d.Rule)
+			results[i].Rule = d.Rule
+			results[i].SetOutcome(report.OutcomeSkippedRule)
+			continue
+		}
 
-		logger := s.Logger.WithValues("sha", sha)
+		canBeCreated--
 
-		logger.Info("Cherry-picking commit")
+		jobs = append(jobs, newPickJob(c, results[i], s.Logger))
+	}
 
-		logger.Info("Checking out main branch", "name", s.DownstreamConfig.MainBranch)
+	return jobs, nil
+}
 
-		if err := wt.Checkout(&mainCheckoutOptions); err != nil {
-			return fmt.Errorf("could not checkout the main branch: %v", err)
-		}
+// pushFunc pushes a job's branch to the downstream repository.
+type pushFunc func(ctx context.Context, branchName string) error
 
-		if err := wt.Reset(&git.ResetOptions{Mode: git.HardReset}); err != nil {
-			return fmt.Errorf("could not reset: %v", err)
-		}
+// publishFunc handles the result of a job once it has been cherry-picked. It returns true if the run should stop.
+type publishFunc func(ctx context.Context, j *pickJob, r pickResult, push pushFunc) (bool, error)
 
-		branchName := internal.GitStreamPrefix + sha
+// runJobs cherry-picks jobs, in parallel if several workers are configured, and publishes them in order.
+func (s *Sync) runJobs(ctx context.Context, jobs []*pickJob, publish publishFunc) error {
+	if workers := s.SyncConfig.Workers; workers > 1 && len(jobs) > 1 {
+		return s.runParallel(ctx, jobs, workers, publish)
+	}
 
-		logger.Info("Switching to branch", "name", branchName)
+	return s.runSequential(ctx, jobs, publish)
+}
 
-		branchRef := plumbing.NewBranchReferenceName(branchName)
+// runState holds the counters of a single run.
+type runState struct {
+	issuesCreated int
+	prsCreated    int
+}
+
+// runSequential cherry-picks and publishes every job in turn, in the main worktree of the downstream repository.
+func (s *Sync) runSequential(ctx context.Context, jobs []*pickJob, publish publishFunc) error {
+	pushAll := func(ctx context.Context, _ string) error {
+		return s.GitHelper.PushContextWithAuth(ctx, s.GitHubToken)
+	}
 
-		if err := s.Repo.Storer.RemoveReference(branchRef); err != nil {
-			return fmt.Errorf("could not remove reference %q for branch %s: %v", branchRef, branchName, err)
+	for _, j := range jobs {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
 		}
 
-		co := git.CheckoutOptions{
-			Branch: branchRef,
-			Create: true,
-			Force:  true,
+		r := s.pick(ctx, s.Repo, s.DownstreamConfig.LocalRepoPath, j)
+		if r.err != nil {
+			return r.err
 		}
 
-		if err := wt.Checkout(&co); err != nil {
-			return fmt.Errorf("could not checkout branch %s: %v", branchName, err)
+		done, err := publish(ctx, j, r, pushAll)
+		if err != nil || done {
+			return err
 		}
+	}
 
-		logger.Info("Running cherry-pick")
+	return nil
+}
 
-		if err := s.cherryPick(ctx, c, branchName, logger); err != nil {
-			if s.DryRun {
-				logger.Info("Dry run: skipping issue creation")
-				continue
-			}
+// pick creates the job's branch from the main branch in repo and cherry-picks the job's commit onto it.
+// Errors that should stop the run are returned in the err field of the result.
+func (s *Sync) pick(ctx context.Context, repo *git.Repository, repoPath string, j *pickJob) pickResult {
+	j.result.Begin()
 
-			issue, err := s.IssueHelper.Create(ctx, err, s.UpstreamConfig.URL, c)
-			if err != nil {
-				return fmt.Errorf("could not create issue for commit %s: %v", sha, err)
-			}
+	j.logger.Info("Cherry-picking commit")
 
-			logger.Info("Created issue", "url", *issue.HTMLURL)
-			continue
-		}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return pickResult{err: fmt.Errorf("could not get the worktree: %v", err)}
+	}
+
+	if err := recreateBranchFromMain(repo, wt, s.DownstreamConfig.MainBranch, j.branchName, j.logger); err != nil {
+		return pickResult{err: err}
+	}
+
+	j.logger.Info("Running cherry-pick")
+
+	hookResults, err := s.cherryPick(ctx, repo, repoPath, j.commit, j.logger)
+	if err != nil {
+		return pickResult{cherryPickErr: err, hookResults: hookResults}
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return pickResult{err: fmt.Errorf("could not get HEAD: %v", err)}
+	}
+
+	headCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return pickResult{err: fmt.Errorf("could not get the cherry-picked commit: %v", err)}
+	}
+
+	return pickResult{hookResults: hookResults, tree: headCommit.TreeHash}
+}
+
+// publish creates an issue if the job's commit could not be cherry-picked, or pushes its branch, runs the after-push
+// hooks and creates a PR otherwise. It returns true if the run should stop.
+func (s *Sync) publish(
+	ctx context.Context,
+	j *pickJob,
+	r pickResult,
+	push pushFunc,
+	rs *runState,
+) (bool, error) {
+	c := j.commit
+	logger := j.logger
+	result := j.result
+
+	if r.cherryPickErr != nil {
+		return false, s.createIssue(ctx, j, r.cherryPickErr, r.hookResults, rs)
+	}
+
+	if s.DryRun {
+		logger.Info("Dry run: skipping push")
+		result.SetOutcome(report.OutcomeDryRun)
+		return true, nil
+	}
 
-		if s.DryRun {
-			logger.Info("Dry run: skipping push")
-			return nil
+	if err := push(ctx, j.branchName); err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return false, fmt.Errorf("error while pushing branch %s: %v", j.branchName, err)
+	}
+
+	if s.Hooks.Has(hooks.StageAfterPush) {
+		// In parallel runs, the job was picked in the worktree of a worker, which may already hold another job: after-push
+		// hooks run on the job's branch in the main worktree.
+		if err := checkoutBranch(s.Repo, j.branchName); err != nil {
+			return false, err
 		}
+	}
+
+	afterPushResults, err := s.Hooks.Run(ctx, hooks.StageAfterPush, s.DownstreamConfig.LocalRepoPath, c, "GITSTREAM_BRANCH="+j.branchName)
+	hookResults := append(r.hookResults, afterPushResults...)
+
+	if err != nil {
+		err = fmt.Errorf("could not run after-push hooks: %w", &gitutils.CherryPickError{Err: err, Step: hooks.StageAfterPush})
+		return false, s.createIssue(ctx, j, err, hookResults, rs)
+	}
+
+	pr, err := s.createPR(ctx, j, hookResults, len(afterPushResults) > 0)
+	if err != nil {
+		return false, fmt.Errorf("could not create PR: %v", err)
+	}
+
+	rs.prsCreated++
+	logger.Info("Created PR", "url", pr.HTMLURL)
+	result.PRURL = pr.GetHTMLURL()
+	result.SetOutcome(report.OutcomePicked)
+
+	if err := s.enableAutoMerge(ctx, pr, j); err != nil {
+		return false, err
+	}
 
-		if err := s.GitHelper.PushContextWithAuth(ctx, s.GitHubToken); err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
-			return fmt.Errorf("error while pushing branch %s: %v", branchName, err)
+	return false, nil
+}
+
+// createIssue records cherryPickErr as the job's failure and creates an issue for it.
+func (s *Sync) createIssue(ctx context.Context, j *pickJob, cherryPickErr error, hookResults []hooks.Result, rs *runState) error {
+	s.Metrics.IncCherryPickFailures(cherryPickFailureReason(cherryPickErr))
+	j.result.SetError(cherryPickErr)
+	j.result.SetOutcome(report.OutcomeFailed)
+
+	if s.DryRun {
+		j.logger.Info("Dry run: skipping issue creation")
+		return nil
+	}
+
+	var (
+		issue *github.Issue
+		err   error
+	)
+
+	// A job planned to be picked cleanly that fails at an after-push hook has no planned issue.
+	if a := j.planned; a != nil && a.Type == plan.ActionIssue {
+		issue, err = s.IssueHelper.CreateFromContent(ctx, a.Content)
+	} else {
+		issue, err = s.IssueHelper.Create(ctx, cherryPickErr, s.UpstreamConfig.URL, j.commit, s.upstreamPR(ctx, j), hookResults)
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not create issue for commit %s: %v", j.commit.Hash, err)
+	}
+
+	rs.issuesCreated++
+	j.logger.Info("Created issue", "url", *issue.HTMLURL)
+	j.result.IssueURL = issue.GetHTMLURL()
+
+	return nil
+}
+
+func setOutcomes(results []*report.CommitResult, o report.Outcome) {
+	for _, r := range results {
+		r.SetOutcome(o)
+	}
+}
+
+// recreateBranchFromMain checks out a clean copy of mainBranch as branchName, discarding any previous branch with the
+// same name. mainBranch itself is not checked out: go-git would rewrite its reference, which workers share.
+func recreateBranchFromMain(repo *git.Repository, wt *git.Worktree, mainBranch, branchName string, logger logr.Logger) error {
+	mainRef, err := repo.Reference(plumbing.NewBranchReferenceName(mainBranch), true)
+	if err != nil {
+		return fmt.Errorf("could not get the main branch: %v", err)
+	}
+
+	logger.Info("Switching to branch", "name", branchName, "main sha", mainRef.Hash())
+
+	branchRef := plumbing.NewBranchReferenceName(branchName)
+
+	if err = repo.Storer.RemoveReference(branchRef); err != nil {
+		return fmt.Errorf("could not remove reference %q for branch %s: %v", branchRef, branchName, err)
+	}
+
+	co := git.CheckoutOptions{
+		Branch: branchRef,
+		Create: true,
+		Force:  true,
+		Hash:   mainRef.Hash(),
+	}
+
+	if err = wt.Checkout(&co); err != nil {
+		return fmt.Errorf("could not checkout branch %s: %v", branchName, err)
+	}
+
+	return nil
+}
+
+// checkoutBranch checks out branchName in the worktree of repo, unless it is already checked out.
+func checkoutBranch(repo *git.Repository, branchName string) error {
+	branchRef := plumbing.NewBranchReferenceName(branchName)
+
+	head, err := repo.Head()
+	if err != nil {
+		return fmt.Errorf("could not get HEAD: %v", err)
+	}
+
+	if head.Name() == branchRef {
+		return nil
+	}
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("could not get the worktree: %v", err)
+	}
+
+	if err = wt.Checkout(&git.CheckoutOptions{Branch: branchRef, Force: true}); err != nil {
+		return fmt.Errorf("could not checkout branch %s: %v", branchName, err)
+	}
+
+	return nil
+}
+
+// createPR opens the job's PR. The planned content of a job is used as is, unless after-push hooks produced results
+// that the plan could not include.
+func (s *Sync) createPR(ctx context.Context, j *pickJob, hookResults []hooks.Result, afterPushResults bool) (*github.PullRequest, error) {
+	a := j.planned
+	if a == nil {
+		return s.PRHelper.Create(
+			ctx,
+			j.branchName,
+			s.DownstreamConfig.MainBranch,
+			s.UpstreamConfig.URL,
+			j.commit,
+			s.upstreamPR(ctx, j),
+			s.DownstreamConfig.CreateDraftPRs,
+			hookResults,
+		)
+	}
+
+	content := a.Content
+
+	if afterPushResults {
+		j.logger.Info("Rendering the PR again to include the results of after-push hooks")
+
+		var err error
+
+		if content, err = s.PRHelper.Render(s.UpstreamConfig.URL, j.commit, s.upstreamPR(ctx, j), hookResults); err != nil {
+			return nil, err
 		}
+	}
 
-		pr, err := s.PRHelper.Create(ctx, branchName, s.DownstreamConfig.MainBranch, s.UpstreamConfig.URL, c, s.DownstreamConfig.CreateDraftPRs)
-		if err != nil {
-			return fmt.Errorf("could not create PR: %v", err)
+	return s.PRHelper.CreateFromContent(ctx, j.branchName, s.DownstreamConfig.MainBranch, content, a.Draft)
+}
+
+// upstreamPR returns the upstream PR that introduced the job's commit, or nil if it is unknown. Errors are logged and
+// ignored, as the PR only adds context to what is created downstream.
+func (s *Sync) upstreamPR(ctx context.Context, j *pickJob) *gh.PullRequest {
+	if s.UpstreamHelper == nil {
+		return nil
+	}
+
+	pr, err := s.UpstreamHelper.GetCommitPR(ctx, j.commit.Hash.String())
+	if err != nil {
+		j.logger.Info("Could not get the upstream PR of the commit", "error", err)
+		return nil
+	}
+
+	return pr
+}
+
+// shouldAutoMerge returns true if auto-merge should be enabled on the PR for commit.
+func (s *Sync) shouldAutoMerge(commit *object.Commit, logger logr.Logger) (bool, error) {
+	cfg := s.DownstreamConfig.AutoMerge
+
+	if !cfg.Enabled {
+		return false, nil
+	}
+
+	if s.DownstreamConfig.CreateDraftPRs {
+		logger.Info("Not enabling auto-merge on a draft PR")
+		return false, nil
+	}
+
+	ok, reason, err := canAutoMerge(cfg, commit)
+	if err != nil {
+		return false, fmt.Errorf("could not check if auto-merge can be enabled: %v", err)
+	}
+
+	if !ok {
+		logger.Info("Not enabling auto-merge", "reason", reason)
+	}
+
+	return ok, nil
+}
+
+// enableAutoMerge enables auto-merge on the job's PR if the plan or, for jobs that were not planned, the configuration
+// allows it.
+func (s *Sync) enableAutoMerge(ctx context.Context, pr *github.PullRequest, j *pickJob) error {
+	ok := j.planned != nil && j.planned.AutoMerge
+
+	if j.planned == nil {
+		var err error
+
+		if ok, err = s.shouldAutoMerge(j.commit, j.logger); err != nil {
+			return err
 		}
+	}
 
-		logger.Info("Created PR", "url", pr.HTMLURL)
+	if !ok {
+		return nil
+	}
+
+	method := s.DownstreamConfig.AutoMerge.Method
+
+	j.logger.Info("Enabling auto-merge", "method", method)
+
+	if err := s.PRHelper.EnableAutoMerge(ctx, pr, method); err != nil {
+		return fmt.Errorf("could not enable auto-merge on PR %d: %v", pr.GetNumber(), err)
 	}
 
 	return nil
 }
 
+// cherryPickFailureReason returns the step at which a cherry-pick failed.
+func cherryPickFailureReason(err error) string {
+	cpe := &gitutils.CherryPickError{}
+
+	if errors.As(err, &cpe) {
+		return cpe.Step
+	}
+
+	return "unknown"
+}
+
+// oldestCommitTime returns the earliest committer time in commits, or the zero time if commits is empty.
+func oldestCommitTime(commits []*object.Commit) time.Time {
+	var oldest time.Time
+
+	for _, c := range commits {
+		if t := c.Committer.When; oldest.IsZero() || t.Before(oldest) {
+			oldest = t
+		}
+	}
+
+	return oldest
+}
+
 func makeStringSet(strs []string) map[string]struct{} {
 
 	stringSet := make(map[string]struct{}, len(strs))
@@ -194,16 +568,17 @@ func makeStringSet(strs []string) map[string]struct{} {
 	return stringSet
 }
 
-func (s *Sync) cherryPick(ctx context.Context, commit *object.Commit, branchName string, logger logr.Logger) error {
-	if err := s.CherryPicker.Run(ctx, s.Repo, s.DownstreamConfig.LocalRepoPath, commit); err != nil {
+func (s *Sync) cherryPick(ctx context.Context, repo *git.Repository, repoPath string, commit *object.Commit, logger logr.Logger) ([]hooks.Result, error) {
+	hookResults, err := s.CherryPicker.Run(ctx, repo, repoPath, commit)
+	if err != nil {
 		pe := &process.Error{}
 
 		if errors.As(err, &pe) {
 			logger.Info("Output", "combined", pe.CombinedString())
 		}
 
-		return fmt.Errorf("could not cherry-pick: %w", err)
+		return hookResults, fmt.Errorf("could not cherry-pick: %w", err)
 	}
 
-	return nil
+	return hookResults, nil
 }
@@ -4,9 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-logr/logr"
@@ -15,6 +19,9 @@ import (
 	"github.com/rh-ecosystem-edge/gitstream/internal/config"
 	gh "github.com/rh-ecosystem-edge/gitstream/internal/github"
 	"github.com/rh-ecosystem-edge/gitstream/internal/gitutils"
+	"github.com/rh-ecosystem-edge/gitstream/internal/hooks"
+	"github.com/rh-ecosystem-edge/gitstream/internal/report"
+	"github.com/rh-ecosystem-edge/gitstream/internal/rules"
 	"github.com/rh-ecosystem-edge/gitstream/internal/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -94,6 +101,7 @@ func TestSync_Run(t *testing.T) {
 			},
 			Logger:         logger,
 			PRHelper:       mockPRHelper,
+			Report:         &report.Report{},
 			UpstreamConfig: upstreamConfig,
 		}
 
@@ -122,29 +130,40 @@ func TestSync_Run(t *testing.T) {
 		gomock.InOrder(
 			mockDiffer.
 				EXPECT().
-				GetMissingCommits(ctx, repo, &ghRepoName, &since, downstreamMainBranch, upstreamConfig).
-				Return([]*object.Commit{commit1, commit2}, nil),
+				GetMissingCommits(ctx, repo, &ghRepoName, s.DiffConfig, downstreamMainBranch, upstreamConfig).
+				Return([]*object.Commit{commit1, commit2}, nil, nil),
 			mockIssueHelper.EXPECT().ListAllOpen(gomock.Any(), true),
 			mockCP.EXPECT().Run(ctx, repo, repoPath, commit2),
 			mockHelper.EXPECT().PushContextWithAuth(ctx, githubToken),
 			mockPRHelper.
 				EXPECT().
-				Create(ctx, branch2, downstreamMainBranch, upstreamURL, commit2, createDraftPRs).
+				Create(ctx, branch2, downstreamMainBranch, upstreamURL, commit2, nil, createDraftPRs, gomock.Nil()).
 				Return(&github.PullRequest{HTMLURL: github.String("some-string")}, nil),
 			mockCP.
 				EXPECT().
 				Run(ctx, repo, repoPath, commit1).
-				Return(randomError),
+				Return(nil, randomError),
 			mockIssueHelper.
 				EXPECT().
-				Create(ctx, &ErrMatcher{Err: randomError}, upstreamURL, commit1).
-				Return(&github.Issue{HTMLURL: github.String("some-string")}, nil),
+				Create(ctx, &ErrMatcher{Err: randomError}, upstreamURL, commit1, nil, gomock.Nil()).
+				Return(&github.Issue{HTMLURL: github.String("some-issue-url")}, nil),
 		)
 
 		assert.NoError(
 			t,
 			s.Run(ctx),
 		)
+
+		require.Len(t, s.Report.Commits, 2)
+
+		assert.Equal(t, sha2, s.Report.Commits[0].SHA)
+		assert.Equal(t, report.OutcomePicked, s.Report.Commits[0].Outcome)
+		assert.Equal(t, "some-string", s.Report.Commits[0].PRURL)
+
+		assert.Equal(t, sha1, s.Report.Commits[1].SHA)
+		assert.Equal(t, report.OutcomeFailed, s.Report.Commits[1].Outcome)
+		assert.Equal(t, "some-issue-url", s.Report.Commits[1].IssueURL)
+		assert.Equal(t, "could not cherry-pick: random error", s.Report.Commits[1].Error)
 	})
 
 	t.Run("it should skip commits from ignored authors", func(t *testing.T) {
@@ -236,15 +255,411 @@ func TestSync_Run(t *testing.T) {
 		gomock.InOrder(
 			mockDiffer.
 				EXPECT().
-				GetMissingCommits(ctx, repo, &ghRepoName, &since, downstreamMainBranch, upstreamConfig).
-				Return(commits, nil),
+				GetMissingCommits(ctx, repo, &ghRepoName, s.DiffConfig, downstreamMainBranch, upstreamConfig).
+				Return(commits, nil, nil),
+			mockIssueHelper.EXPECT().ListAllOpen(gomock.Any(), true),
+		)
+
+		assert.NoError(
+			t,
+			s.Run(ctx),
+		)
+	})
+
+	t.Run("commits excluded by a rule are skipped", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		const downstreamMainBranch = "main"
+
+		mockIssueHelper := gh.NewMockIssueHelper(ctrl)
+		mockDiffer := gitutils.NewMockDiffer(ctrl)
+
+		ctx := context.Background()
+
+		engine, err := rules.New([]config.Rule{{Action: config.RuleActionExclude, Name: "docs", Subject: "^docs:"}}, nil)
+		require.NoError(t, err)
+
+		ghRepoName := gh.RepoName{Owner: "owner", Repo: "repo"}
+		upstreamConfig := config.Upstream{URL: "some-upstream-url"}
+
+		s := Sync{
+			Differ:      mockDiffer,
+			IssueHelper: mockIssueHelper,
+			RepoName:    &ghRepoName,
+			DownstreamConfig: config.Downstream{
+				MainBranch:   downstreamMainBranch,
+				MaxOpenItems: -1,
+			},
+			Logger:         logr.Discard(),
+			Report:         &report.Report{},
+			Rules:          engine,
+			UpstreamConfig: upstreamConfig,
+		}
+
+		commits := []*object.Commit{
+			{Hash: plumbing.NewHash("e3229f3c533ed51070beff092e5c7694a8ee81f0"), Message: "docs: fix typo"},
+		}
+
+		gomock.InOrder(
+			mockDiffer.
+				EXPECT().
+				GetMissingCommits(ctx, nil, &ghRepoName, config.Diff{}, downstreamMainBranch, upstreamConfig).
+				Return(commits, nil, nil),
+			mockIssueHelper.EXPECT().ListAllOpen(gomock.Any(), true),
+		)
+
+		require.NoError(t, s.Run(ctx))
+
+		require.Len(t, s.Report.Commits, 1)
+		assert.Equal(t, report.OutcomeSkippedRule, s.Report.Commits[0].Outcome)
+		assert.Equal(t, "docs", s.Report.Commits[0].Rule)
+	})
+
+	t.Run("auto-merge is only enabled on PRs for eligible commits", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		const (
+			downstreamMainBranch = "main"
+			upstreamURL          = "some-upstream-url"
+		)
+
+		mockCP := gitutils.NewMockCherryPicker(ctrl)
+		mockIssueHelper := gh.NewMockIssueHelper(ctrl)
+		mockPRHelper := gh.NewMockPRHelper(ctrl)
+		mockDiffer := gitutils.NewMockDiffer(ctrl)
+		mockHelper := gitutils.NewMockHelper(ctrl)
+
+		ctx := context.Background()
+
+		repo, fs := test.NewRepoWithFS(t)
+
+		contents := "contents\n"
+
+		mainSHA, _ := test.AddEmptyCommit(t, repo, "test commit")
+		_, eligible := test.AddCommit(t, repo, fs, "change code", map[string]*string{"code.go": &contents})
+		_, ineligible := test.AddCommit(t, repo, fs, "change docs", map[string]*string{"docs/README.md": &contents})
+
+		require.NoError(
+			t,
+			repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName(downstreamMainBranch), mainSHA)),
+		)
+
+		ghRepoName := gh.RepoName{Owner: "owner", Repo: "repo"}
+		upstreamConfig := config.Upstream{URL: upstreamURL}
+
+		s := Sync{
+			CherryPicker: mockCP,
+			Differ:       mockDiffer,
+			GitHelper:    mockHelper,
+			IssueHelper:  mockIssueHelper,
+			Repo:         repo,
+			RepoName:     &ghRepoName,
+			DownstreamConfig: config.Downstream{
+				AutoMerge: config.AutoMerge{
+					Enabled:        true,
+					MaxDiffLines:   -1,
+					Method:         config.AutoMergeMethodSquash,
+					ProtectedPaths: []string{"docs/"},
+				},
+				MainBranch:   downstreamMainBranch,
+				MaxOpenItems: -1,
+			},
+			Logger:         logr.Discard(),
+			PRHelper:       mockPRHelper,
+			UpstreamConfig: upstreamConfig,
+		}
+
+		eligiblePR := &github.PullRequest{HTMLURL: github.String("eligible")}
+
+		gomock.InOrder(
+			mockDiffer.
+				EXPECT().
+				GetMissingCommits(ctx, repo, &ghRepoName, config.Diff{}, downstreamMainBranch, upstreamConfig).
+				Return([]*object.Commit{eligible, ineligible}, nil, nil),
+			mockIssueHelper.EXPECT().ListAllOpen(gomock.Any(), true),
+		)
+
+		mockCP.EXPECT().Run(ctx, repo, "", gomock.Any()).Times(2)
+		mockHelper.EXPECT().PushContextWithAuth(ctx, "").Times(2)
+
+		mockPRHelper.
+			EXPECT().
+			Create(ctx, "gs-"+eligible.Hash.String(), downstreamMainBranch, upstreamURL, eligible, nil, false, gomock.Nil()).
+			Return(eligiblePR, nil)
+		mockPRHelper.
+			EXPECT().
+			Create(ctx, "gs-"+ineligible.Hash.String(), downstreamMainBranch, upstreamURL, ineligible, nil, false, gomock.Nil()).
+			Return(&github.PullRequest{HTMLURL: github.String("ineligible")}, nil)
+
+		// EnableAutoMerge is not expected for the PR of the commit that touches a protected path.
+		mockPRHelper.EXPECT().EnableAutoMerge(ctx, eligiblePR, config.AutoMergeMethodSquash)
+
+		require.NoError(t, s.Run(ctx))
+	})
+
+	t.Run("parallel workers publish in commit order", func(t *testing.T) {
+
+		ctrl := gomock.NewController(t)
+
+		const (
+			downstreamMainBranch = "main"
+			githubToken          = "github-token"
+			upstreamURL          = "some-upstream-url"
+			workers              = 2
+		)
+
+		mockCP := gitutils.NewMockCherryPicker(ctrl)
+		mockIssueHelper := gh.NewMockIssueHelper(ctrl)
+		mockPRHelper := gh.NewMockPRHelper(ctrl)
+		mockDiffer := gitutils.NewMockDiffer(ctrl)
+		mockHelper := gitutils.NewMockHelper(ctrl)
+		mockWM := gitutils.NewMockWorktreeManager(ctrl)
+
+		ctx := context.Background()
+
+		newRepoWithMain := func() *git.Repository {
+			repo := test.NewRepo(t)
+			sha, _ := test.AddEmptyCommit(t, repo, "test commit")
+
+			require.NoError(
+				t,
+				repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName(downstreamMainBranch), sha)),
+			)
+
+			return repo
+		}
+
+		repo := newRepoWithMain()
+
+		ghRepoName := gh.RepoName{Owner: "owner", Repo: "repo"}
+		upstreamConfig := config.Upstream{URL: upstreamURL}
+
+		s := Sync{
+			CherryPicker: mockCP,
+			Differ:       mockDiffer,
+			GitHelper:    mockHelper,
+			GitHubToken:  githubToken,
+			IssueHelper:  mockIssueHelper,
+			Repo:         repo,
+			RepoName:     &ghRepoName,
+			DownstreamConfig: config.Downstream{
+				MainBranch:   downstreamMainBranch,
+				MaxOpenItems: 3,
+			},
+			Logger:          logr.Discard(),
+			PRHelper:        mockPRHelper,
+			Report:          &report.Report{},
+			SyncConfig:      config.Sync{Workers: workers},
+			UpstreamConfig:  upstreamConfig,
+			WorktreeManager: mockWM,
+		}
+
+		commits := make([]*object.Commit, 4)
+
+		for i := range commits {
+			commits[i] = &object.Commit{
+				Hash: plumbing.NewHash(fmt.Sprintf("%040d", i+1)),
+				Committer: object.Signature{
+					When: time.Date(2022, 5, i+1, 0, 0, 0, 0, time.UTC),
+				},
+			}
+		}
+
+		mockWM.
+			EXPECT().
+			Add(ctx, gomock.Any(), downstreamMainBranch).
+			DoAndReturn(func(_ context.Context, _, _ string) (*git.Repository, error) {
+				return newRepoWithMain(), nil
+			}).
+			Times(workers)
+
+		mockWM.EXPECT().Remove(gomock.Any(), gomock.Any()).Times(workers)
+
+		randomError := errors.New("random error")
+
+		// The first commit takes longer to cherry-pick than the second one, which must still be published after it.
+		mockCP.
+			EXPECT().
+			Run(gomock.Any(), gomock.Any(), gomock.Any(), commits[0]).
+			Do(func(_ context.Context, _ *git.Repository, _ string, _ *object.Commit) {
+				time.Sleep(50 * time.Millisecond)
+			})
+
+		mockCP.EXPECT().Run(gomock.Any(), gomock.Any(), gomock.Any(), commits[1]).Return(nil, randomError)
+		mockCP.EXPECT().Run(gomock.Any(), gomock.Any(), gomock.Any(), commits[2])
+
+		branchName := func(c *object.Commit) string {
+			return "gs-" + c.Hash.String()
+		}
+
+		gomock.InOrder(
+			mockDiffer.
+				EXPECT().
+				GetMissingCommits(ctx, repo, &ghRepoName, config.Diff{}, downstreamMainBranch, upstreamConfig).
+				Return([]*object.Commit{commits[3], commits[1], commits[0], commits[2]}, nil, nil),
 			mockIssueHelper.EXPECT().ListAllOpen(gomock.Any(), true),
+			mockHelper.EXPECT().PushBranchContextWithAuth(ctx, githubToken, branchName(commits[0])),
+			mockPRHelper.
+				EXPECT().
+				Create(ctx, branchName(commits[0]), downstreamMainBranch, upstreamURL, commits[0], nil, false, gomock.Nil()).
+				Return(&github.PullRequest{HTMLURL: github.String("pr-1")}, nil),
+			mockIssueHelper.
+				EXPECT().
+				Create(ctx, &ErrMatcher{Err: randomError}, upstreamURL, commits[1], nil, gomock.Nil()).
+				Return(&github.Issue{HTMLURL: github.String("issue-2")}, nil),
+			mockHelper.EXPECT().PushBranchContextWithAuth(ctx, githubToken, branchName(commits[2])),
+			mockPRHelper.
+				EXPECT().
+				Create(ctx, branchName(commits[2]), downstreamMainBranch, upstreamURL, commits[2], nil, false, gomock.Nil()).
+				Return(&github.PullRequest{HTMLURL: github.String("pr-3")}, nil),
 		)
 
 		assert.NoError(
 			t,
 			s.Run(ctx),
 		)
+
+		require.Len(t, s.Report.Commits, 4)
+
+		expected := []report.Outcome{
+			report.OutcomePicked,
+			report.OutcomeFailed,
+			report.OutcomePicked,
+			report.OutcomeSkippedMaxItems,
+		}
+
+		for i, o := range expected {
+			assert.Equal(t, commits[i].Hash.String(), s.Report.Commits[i].SHA)
+			assert.Equal(t, o, s.Report.Commits[i].Outcome)
+		}
+	})
+
+	t.Run("after-push hooks run on the job's branch in parallel runs", func(t *testing.T) {
+		if _, err := exec.LookPath("git"); err != nil {
+			t.Skip("git is not available")
+		}
+
+		ctrl := gomock.NewController(t)
+
+		const (
+			downstreamMainBranch = "main"
+			githubToken          = "github-token"
+			upstreamURL          = "some-upstream-url"
+			workers              = 2
+		)
+
+		mockCP := gitutils.NewMockCherryPicker(ctrl)
+		mockIssueHelper := gh.NewMockIssueHelper(ctrl)
+		mockPRHelper := gh.NewMockPRHelper(ctrl)
+		mockDiffer := gitutils.NewMockDiffer(ctrl)
+		mockHelper := gitutils.NewMockHelper(ctrl)
+
+		ctx := context.Background()
+
+		repoPath := t.TempDir()
+
+		repo, err := git.PlainInit(repoPath, false)
+		require.NoError(t, err)
+
+		wt, err := repo.Worktree()
+		require.NoError(t, err)
+
+		base := "base\n"
+
+		baseSHA, _ := test.AddCommit(t, repo, wt.Filesystem, "base", map[string]*string{"base.txt": &base})
+		_, u1 := test.AddCommit(t, repo, wt.Filesystem, "upstream 1", map[string]*string{"u1.txt": &base})
+		_, u2 := test.AddCommit(t, repo, wt.Filesystem, "upstream 2", map[string]*string{"u2.txt": &base})
+
+		require.NoError(
+			t,
+			wt.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName(downstreamMainBranch), Create: true, Hash: baseSHA, Force: true}),
+		)
+
+		// The hook prints a file that only exists on the job's branch.
+		hr, err := hooks.NewRunner(
+			config.Sync{Hooks: []config.Hook{{Command: []string{"cat", "picked.txt"}, Name: "show", Stage: hooks.StageAfterPush}}},
+			upstreamURL,
+			logr.Discard(),
+		)
+		require.NoError(t, err)
+
+		ghRepoName := gh.RepoName{Owner: "owner", Repo: "repo"}
+		upstreamConfig := config.Upstream{URL: upstreamURL}
+
+		s := Sync{
+			CherryPicker: mockCP,
+			Differ:       mockDiffer,
+			GitHelper:    mockHelper,
+			GitHubToken:  githubToken,
+			Hooks:        hr,
+			IssueHelper:  mockIssueHelper,
+			Repo:         repo,
+			RepoName:     &ghRepoName,
+			DownstreamConfig: config.Downstream{
+				LocalRepoPath: repoPath,
+				MainBranch:    downstreamMainBranch,
+				MaxOpenItems:  -1,
+			},
+			Logger:          logr.Discard(),
+			PRHelper:        mockPRHelper,
+			Report:          &report.Report{},
+			SyncConfig:      config.Sync{Workers: workers},
+			UpstreamConfig:  upstreamConfig,
+			WorktreeManager: gitutils.NewWorktreeManager(repoPath, logr.Discard()),
+		}
+
+		// Each cherry-pick writes the SHA of the picked commit to picked.txt in the worktree of the worker.
+		mockCP.
+			EXPECT().
+			Run(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, repo *git.Repository, repoPath string, commit *object.Commit) ([]hooks.Result, error) {
+				if err := os.WriteFile(filepath.Join(repoPath, "picked.txt"), []byte(commit.Hash.String()), 0644); err != nil {
+					return nil, err
+				}
+
+				wt, err := repo.Worktree()
+				if err != nil {
+					return nil, err
+				}
+
+				if _, err = wt.Add("picked.txt"); err != nil {
+					return nil, err
+				}
+
+				_, err = wt.Commit("picked", &git.CommitOptions{
+					Author: &object.Signature{Name: "Unit tests", Email: "unit.tests@example.com", When: time.Now()},
+				})
+
+				return nil, err
+			}).
+			Times(2)
+
+		mockDiffer.
+			EXPECT().
+			GetMissingCommits(ctx, repo, &ghRepoName, config.Diff{}, downstreamMainBranch, upstreamConfig).
+			Return([]*object.Commit{u1, u2}, nil, nil)
+		mockIssueHelper.EXPECT().ListAllOpen(gomock.Any(), true)
+
+		for _, c := range []*object.Commit{u1, u2} {
+			c := c
+
+			mockHelper.EXPECT().PushBranchContextWithAuth(ctx, githubToken, "gs-"+c.Hash.String())
+			mockPRHelper.
+				EXPECT().
+				Create(ctx, "gs-"+c.Hash.String(), downstreamMainBranch, upstreamURL, c, nil, false, gomock.Any()).
+				DoAndReturn(func(_ context.Context, _, _, _ string, _ *object.Commit, _ *gh.PullRequest, _ bool, results []hooks.Result) (*github.PullRequest, error) {
+					require.Len(t, results, 1)
+					assert.Equal(t, c.Hash.String(), results[0].Output)
+
+					return &github.PullRequest{}, nil
+				})
+		}
+
+		require.NoError(t, s.Run(ctx))
+
+		for _, c := range s.Report.Commits {
+			assert.Equal(t, report.OutcomePicked, c.Outcome, c.SHA)
+		}
 	})
 }
 
@@ -0,0 +1,352 @@
+package gitstream
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-logr/logr"
+	"github.com/rh-ecosystem-edge/gitstream/internal"
+	"github.com/rh-ecosystem-edge/gitstream/internal/config"
+	gh "github.com/rh-ecosystem-edge/gitstream/internal/github"
+	"github.com/rh-ecosystem-edge/gitstream/internal/gitutils"
+	"github.com/rh-ecosystem-edge/gitstream/internal/markup"
+)
+
+// Tags mirrors upstream tags downstream. For each upstream tag that matches TagsConfig.Patterns and does not exist
+// downstream yet, it checks that all the upstream commits reachable from the tag are present downstream. If so, it
+// tags the newest downstream main branch commit that brought one of them and pushes the tag. Otherwise, it reports
+// the commits that are missing.
+type Tags struct {
+	DiffConfig config.Diff
+	// Differ must only consider the commits of the downstream main branch and the skip list as present downstream,
+	// not the commits that only have a GitStream PR or issue; see intents.NewDownstreamOnlyGetter.
+	Differ               gitutils.Differ
+	DownstreamMainBranch string
+	DryRun               bool
+	Finder               markup.Finder
+	GitHelper            gitutils.Helper
+	GitHubToken          string
+	Logger               logr.Logger
+	Repo                 *git.Repository
+	RepoName             *gh.RepoName
+	TagsConfig           config.Tags
+	UpstreamConfig       config.Upstream
+}
+
+type tagNameData struct {
+	Name string
+}
+
+func (t *Tags) Run(ctx context.Context) error {
+	const remoteName = internal.UpstreamRemoteName
+
+	if len(t.TagsConfig.Patterns) == 0 {
+		return errors.New("no tag patterns configured")
+	}
+
+	tmpl, err := template.New("name").Option("missingkey=error").Parse(t.TagsConfig.NameTemplate)
+	if err != nil {
+		return fmt.Errorf("could not parse the tag name template: %v", err)
+	}
+
+	if _, err = t.GitHelper.RecreateRemote(ctx, remoteName, t.UpstreamConfig.URL); err != nil {
+		return fmt.Errorf("could not recreate remote: %v", err)
+	}
+
+	refs, err := t.GitHelper.FetchRemoteTagsContext(ctx, remoteName)
+	if err != nil {
+		return fmt.Errorf("could not fetch the upstream tags: %v", err)
+	}
+
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].Name() < refs[j].Name()
+	})
+
+	// Tags pushed from another clone are not in the local repository.
+	dsRefs, err := t.GitHelper.FetchRemoteTagsContext(ctx, downstreamRemoteName)
+	if err != nil {
+		return fmt.Errorf("could not fetch the downstream tags: %v", err)
+	}
+
+	dsTags := make(map[string]bool, len(dsRefs))
+
+	for _, ref := range dsRefs {
+		dsTags[strings.TrimPrefix(ref.Name().String(), gitutils.RemoteTagsPrefix(downstreamRemoteName))] = true
+	}
+
+	dsRef, err := t.GitHelper.GetBranchRef(ctx, t.DownstreamMainBranch)
+	if err != nil {
+		return fmt.Errorf("could not get the tip of branch %q: %v", t.DownstreamMainBranch, err)
+	}
+
+	var counterparts *downstreamCounterparts
+
+	for _, ref := range refs {
+		name := strings.TrimPrefix(ref.Name().String(), gitutils.RemoteTagsPrefix(remoteName))
+
+		if !t.matches(name) {
+			continue
+		}
+
+		logger := t.Logger.WithValues("upstream tag", name)
+
+		dsName, err := t.renderName(tmpl, name)
+		if err != nil {
+			return err
+		}
+
+		logger = logger.WithValues("downstream tag", dsName)
+
+		if dsTags[dsName] {
+			logger.V(1).Info("Downstream tag already exists")
+			continue
+		}
+
+		if _, err = t.Repo.Reference(plumbing.NewTagReferenceName(dsName), false); err == nil {
+			logger.V(1).Info("Downstream tag already exists locally")
+			continue
+		} else if !errors.Is(err, plumbing.ErrReferenceNotFound) {
+			return fmt.Errorf("could not look up tag %s: %v", dsName, err)
+		}
+
+		commit, err := t.peel(ref.Hash())
+		if err != nil {
+			return fmt.Errorf("could not get the commit of upstream tag %s: %v", name, err)
+		}
+
+		missing, _, err := t.Differ.GetMissingCommitsFrom(
+			ctx,
+			t.Repo,
+			t.RepoName,
+			t.DiffConfig,
+			t.DownstreamMainBranch,
+			t.UpstreamConfig,
+			commit.Hash,
+		)
+		if err != nil {
+			return fmt.Errorf("could not get the commits of upstream tag %s not present in downstream: %v", name, err)
+		}
+
+		if len(missing) > 0 {
+			shas := make([]string, 0, len(missing))
+
+			for _, c := range missing {
+				shas = append(shas, c.Hash.String())
+			}
+
+			logger.Info("Upstream tag blocked by commits not present in downstream", "missing", shas)
+
+			continue
+		}
+
+		if counterparts == nil {
+			if counterparts, err = t.downstreamCounterparts(dsRef.Hash()); err != nil {
+				return err
+			}
+		}
+
+		target, err := counterparts.newest(commit)
+		if err != nil {
+			return fmt.Errorf("could not find the downstream commit for upstream tag %s: %v", name, err)
+		}
+
+		if target == nil {
+			logger.Info("No downstream commit brought the commits of the upstream tag")
+			continue
+		}
+
+		logger = logger.WithValues("sha", target.Hash)
+
+		if t.DryRun {
+			logger.Info("Dry run: skipping tag creation")
+			continue
+		}
+
+		logger.Info("Creating downstream tag")
+
+		if _, err = t.Repo.CreateTag(dsName, target.Hash, nil); err != nil {
+			return fmt.Errorf("could not create tag %s: %v", dsName, err)
+		}
+
+		if err = t.GitHelper.PushTagContextWithAuth(ctx, t.GitHubToken, dsName); err != nil {
+			// The local tag is what marks the upstream tag as mirrored, so it must not outlive a failed push, or the
+			// push would never be retried.
+			if delErr := t.Repo.DeleteTag(dsName); delErr != nil {
+				logger.Error(delErr, "Could not delete the local tag after a failed push")
+			}
+
+			return fmt.Errorf("could not push tag %s: %v", dsName, err)
+		}
+	}
+
+	return nil
+}
+
+func (t *Tags) matches(name string) bool {
+	for _, p := range t.TagsConfig.Patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (t *Tags) renderName(tmpl *template.Template, name string) (string, error) {
+	var buf bytes.Buffer
+
+	if err := tmpl.Execute(&buf, tagNameData{Name: name}); err != nil {
+		return "", fmt.Errorf("could not render the downstream name of tag %s: %v", name, err)
+	}
+
+	dsName := strings.TrimSpace(buf.String())
+
+	if err := plumbing.NewTagReferenceName(dsName).Validate(); err != nil {
+		return "", fmt.Errorf("%q: invalid downstream name for tag %s: %v", dsName, name, err)
+	}
+
+	return dsName, nil
+}
+
+// peel returns the commit that hash, a commit or an annotated tag, points to.
+func (t *Tags) peel(hash plumbing.Hash) (*object.Commit, error) {
+	tag, err := t.Repo.TagObject(hash)
+	if err == nil {
+		return tag.Commit()
+	}
+
+	if !errors.Is(err, plumbing.ErrObjectNotFound) {
+		return nil, err
+	}
+
+	return t.Repo.CommitObject(hash)
+}
+
+// downstreamCounterparts maps upstream SHAs, as written downstream, to the downstream main branch commit that brought
+// them. Commits are identified by their position on the first-parent chain of the main branch, 0 being its tip.
+type downstreamCounterparts struct {
+	// abbreviated are the keys of positions shorter than a full SHA.
+	abbreviated []string
+	chain       []*object.Commit
+	positions   map[string]int
+}
+
+// downstreamCounterparts walks the first-parent chain from dsTip. Each commit of the chain brings itself, the commits
+// it merged, and the upstream commits referred to by the markup in their messages.
+func (t *Tags) downstreamCounterparts(dsTip plumbing.Hash) (*downstreamCounterparts, error) {
+	dc := &downstreamCounterparts{positions: make(map[string]int)}
+
+	c, err := t.Repo.CommitObject(dsTip)
+	if err != nil {
+		return nil, fmt.Errorf("could not get commit %s: %v", dsTip, err)
+	}
+
+	for {
+		pos := len(dc.chain)
+		dc.chain = append(dc.chain, c)
+
+		brought := []*object.Commit{c}
+
+		if c.NumParents() > 1 {
+			merged, err := gitutils.MergedCommits(c)
+			if err != nil {
+				return nil, err
+			}
+
+			brought = append(brought, merged...)
+		}
+
+		for _, b := range brought {
+			dc.add(b.Hash.String(), pos)
+
+			matches, err := t.Finder.Find(b.Message)
+			if err != nil {
+				return nil, fmt.Errorf("error while finding SHAs in commit %s: %v", b.Hash, err)
+			}
+
+			for _, m := range matches {
+				dc.add(m.SHA, pos)
+			}
+		}
+
+		if c.NumParents() == 0 {
+			break
+		}
+
+		if c, err = c.Parent(0); err != nil {
+			if errors.Is(err, plumbing.ErrObjectNotFound) {
+				// Shallow clones end with commits whose parents are missing.
+				break
+			}
+
+			return nil, fmt.Errorf("could not get the first parent of %s: %v", dc.chain[pos].Hash, err)
+		}
+	}
+
+	return dc, nil
+}
+
+// add records that sha was brought at pos, unless it was already brought by a newer commit.
+func (dc *downstreamCounterparts) add(sha string, pos int) {
+	if _, ok := dc.positions[sha]; ok {
+		return
+	}
+
+	dc.positions[sha] = pos
+
+	if len(sha) < 40 {
+		dc.abbreviated = append(dc.abbreviated, sha)
+	}
+}
+
+func (dc *downstreamCounterparts) position(sha string) (int, bool) {
+	if pos, ok := dc.positions[sha]; ok {
+		return pos, true
+	}
+
+	found := false
+	res := 0
+
+	for _, a := range dc.abbreviated {
+		if pos := dc.positions[a]; strings.HasPrefix(sha, a) && (!found || pos < res) {
+			found = true
+			res = pos
+		}
+	}
+
+	return res, found
+}
+
+// newest returns the newest downstream commit that brought an upstream commit reachable from upstream, or nil if
+// there is none.
+func (dc *downstreamCounterparts) newest(upstream *object.Commit) (*object.Commit, error) {
+	found := false
+	res := 0
+
+	err := object.NewCommitPreorderIter(upstream, nil, nil).ForEach(func(c *object.Commit) error {
+		if pos, ok := dc.position(c.Hash.String()); ok && (!found || pos < res) {
+			found = true
+			res = pos
+		}
+
+		return nil
+	})
+	if err != nil && !errors.Is(err, plumbing.ErrObjectNotFound) {
+		return nil, fmt.Errorf("could not walk the history of %s: %v", upstream.Hash, err)
+	}
+
+	if !found {
+		return nil, nil
+	}
+
+	return dc.chain[res], nil
+}
@@ -0,0 +1,225 @@
+package gitstream
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-logr/logr"
+	"github.com/golang/mock/gomock"
+	"github.com/rh-ecosystem-edge/gitstream/internal"
+	"github.com/rh-ecosystem-edge/gitstream/internal/config"
+	gh "github.com/rh-ecosystem-edge/gitstream/internal/github"
+	"github.com/rh-ecosystem-edge/gitstream/internal/gitutils"
+	"github.com/rh-ecosystem-edge/gitstream/internal/intents"
+	"github.com/rh-ecosystem-edge/gitstream/internal/markup"
+	"github.com/rh-ecosystem-edge/gitstream/internal/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTags_Run(t *testing.T) {
+	const (
+		downstreamMainBranch = "main"
+		githubToken          = "github-token"
+		markupName           = "Upstream-Commit"
+		upstreamURL          = "some-upstream-url"
+	)
+
+	ctx := context.Background()
+
+	finder, err := markup.NewFinder(markupName)
+	require.NoError(t, err)
+
+	repoName := &gh.RepoName{Owner: "owner", Repo: "repo"}
+
+	// Upstream history, on its own branch.
+	repo := test.NewRepo(t)
+
+	_, u1 := test.AddEmptyCommit(t, repo, "upstream 1")
+	_, u2 := test.AddEmptyCommit(t, repo, "upstream 2")
+	_, u3 := test.AddEmptyCommit(t, repo, "upstream 3")
+
+	// Downstream history: u1 and u2 were picked, u2 with an abbreviated SHA.
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+
+	require.NoError(
+		t,
+		wt.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("downstream"), Create: true, Hash: u1.Hash}),
+	)
+
+	_, d2 := test.AddEmptyCommit(t, repo, "upstream 2\n\n"+markupName+": "+u2.Hash.String()[:12])
+	dsMainSHA, _ := test.AddEmptyCommit(t, repo, "downstream only")
+
+	dsMainRef := plumbing.NewHashReference(plumbing.NewBranchReferenceName(downstreamMainBranch), dsMainSHA)
+
+	remoteTagRef := func(name string, hash plumbing.Hash) *plumbing.Reference {
+		return plumbing.NewHashReference(
+			plumbing.ReferenceName(gitutils.RemoteTagsPrefix(internal.UpstreamRemoteName)+name),
+			hash,
+		)
+	}
+
+	// v1.0.0 is an annotated tag.
+	annotated, err := repo.CreateTag("annotated", u2.Hash, &git.CreateTagOptions{
+		Message: "v1.0.0",
+		Tagger:  &object.Signature{Name: "Unit tests", Email: "unit.tests@example.com", When: time.Now()},
+	})
+	require.NoError(t, err)
+	require.NoError(t, repo.DeleteTag("annotated"))
+
+	_, err = repo.CreateTag("v0.9.0-ds", u1.Hash, nil)
+	require.NoError(t, err)
+
+	refs := []*plumbing.Reference{
+		remoteTagRef("v2.0.0", u3.Hash),
+		remoteTagRef("other", u3.Hash),
+		remoteTagRef("v1.0.0", annotated.Hash()),
+		remoteTagRef("v0.9.0", u1.Hash),
+	}
+
+	// newTagsWith returns Tags mirroring the upstream tags that match patterns, and expects the calls made before the
+	// upstream tags are checked. dsTags are the tags of the downstream remote.
+	newTagsWith := func(t *testing.T, differ gitutils.Differ, patterns []string, dsTags ...*plumbing.Reference) (*Tags, *gitutils.MockHelper) {
+		t.Helper()
+
+		mockHelper := gitutils.NewMockHelper(gomock.NewController(t))
+
+		tags := &Tags{
+			Differ:               differ,
+			DownstreamMainBranch: downstreamMainBranch,
+			Finder:               finder,
+			GitHelper:            mockHelper,
+			GitHubToken:          githubToken,
+			Logger:               logr.Discard(),
+			Repo:                 repo,
+			RepoName:             repoName,
+			TagsConfig:           config.Tags{NameTemplate: "{{ .Name }}-ds", Patterns: patterns},
+			UpstreamConfig:       config.Upstream{URL: upstreamURL},
+		}
+
+		gomock.InOrder(
+			mockHelper.EXPECT().RecreateRemote(ctx, internal.UpstreamRemoteName, upstreamURL),
+			mockHelper.EXPECT().FetchRemoteTagsContext(ctx, internal.UpstreamRemoteName).Return(refs, nil),
+			mockHelper.EXPECT().FetchRemoteTagsContext(ctx, "origin").Return(dsTags, nil),
+			mockHelper.EXPECT().GetBranchRef(ctx, downstreamMainBranch).Return(dsMainRef, nil),
+		)
+
+		return tags, mockHelper
+	}
+
+	newTags := func(t *testing.T, dryRun bool) (*Tags, *gitutils.MockHelper) {
+		t.Helper()
+
+		mockDiffer := gitutils.NewMockDiffer(gomock.NewController(t))
+
+		tags, mockHelper := newTagsWith(t, mockDiffer, []string{"v*"})
+		tags.DryRun = dryRun
+
+		gomock.InOrder(
+			mockDiffer.
+				EXPECT().
+				GetMissingCommitsFrom(ctx, repo, repoName, tags.DiffConfig, downstreamMainBranch, tags.UpstreamConfig, u2.Hash),
+			mockDiffer.
+				EXPECT().
+				GetMissingCommitsFrom(ctx, repo, repoName, tags.DiffConfig, downstreamMainBranch, tags.UpstreamConfig, u3.Hash).
+				Return([]*object.Commit{u3}, nil, nil),
+		)
+
+		return tags, mockHelper
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		tags, _ := newTags(t, true)
+
+		require.NoError(t, tags.Run(ctx))
+
+		_, err := repo.Tag("v1.0.0-ds")
+		assert.ErrorIs(t, err, git.ErrTagNotFound)
+	})
+
+	t.Run("tags whose push failed are not kept locally", func(t *testing.T) {
+		mockDiffer := gitutils.NewMockDiffer(gomock.NewController(t))
+
+		tags, mockHelper := newTagsWith(t, mockDiffer, []string{"v1.*"})
+
+		gomock.InOrder(
+			mockDiffer.
+				EXPECT().
+				GetMissingCommitsFrom(ctx, repo, repoName, tags.DiffConfig, downstreamMainBranch, tags.UpstreamConfig, u2.Hash),
+			mockHelper.EXPECT().PushTagContextWithAuth(ctx, githubToken, "v1.0.0-ds").Return(errors.New("random error")),
+		)
+
+		assert.EqualError(t, tags.Run(ctx), "could not push tag v1.0.0-ds: random error")
+
+		// The next run pushes the tag again.
+		_, err := repo.Tag("v1.0.0-ds")
+		assert.ErrorIs(t, err, git.ErrTagNotFound)
+	})
+
+	t.Run("tags that exist on the downstream remote only are not created again", func(t *testing.T) {
+		dsTag := plumbing.NewHashReference(plumbing.ReferenceName(gitutils.RemoteTagsPrefix("origin")+"v1.0.0-ds"), d2.Hash)
+
+		tags, _ := newTagsWith(t, gitutils.NewMockDiffer(gomock.NewController(t)), []string{"v1.*"}, dsTag)
+
+		require.NoError(t, tags.Run(ctx))
+
+		_, err := repo.Tag("v1.0.0-ds")
+		assert.ErrorIs(t, err, git.ErrTagNotFound)
+	})
+
+	t.Run("commits that only have a GitStream PR block the tag", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		diffHelper := gitutils.NewMockHelper(ctrl)
+		mockGetter := intents.NewMockGetter(ctrl)
+
+		differ := gitutils.NewDiffer(diffHelper, intents.NewDownstreamOnlyGetter(mockGetter), logr.Discard())
+
+		tags, _ := newTagsWith(t, differ, []string{"v2.*"})
+
+		diffHelper.EXPECT().GetBranchRef(ctx, downstreamMainBranch).Return(dsMainRef, nil)
+
+		mockGetter.
+			EXPECT().
+			FromLocalGitRepo(ctx, repo, dsMainSHA, nil).
+			Return(intents.CommitIntents{u1.Hash.String(): {}, u2.Hash.String()[:12]: {}}, nil)
+		mockGetter.EXPECT().FromSkipList(ctx, repo, dsMainSHA)
+		mockGetter.
+			EXPECT().
+			FromGitHubIssues(gomock.Any(), gomock.Any()).
+			Return(intents.CommitIntents{u3.Hash.String(): {Origin: "some-open-pr-url"}}, nil).
+			AnyTimes()
+
+		// No push is expected: u3 is not in the downstream main branch.
+		require.NoError(t, tags.Run(ctx))
+
+		_, err := repo.Tag("v2.0.0-ds")
+		assert.ErrorIs(t, err, git.ErrTagNotFound)
+	})
+
+	t.Run("tags whose commits are all present downstream are created and pushed", func(t *testing.T) {
+		tags, mockHelper := newTags(t, false)
+
+		mockHelper.EXPECT().PushTagContextWithAuth(ctx, githubToken, "v1.0.0-ds")
+
+		require.NoError(t, tags.Run(ctx))
+
+		ref, err := repo.Tag("v1.0.0-ds")
+		require.NoError(t, err)
+		assert.Equal(t, d2.Hash, ref.Hash())
+
+		_, err = repo.Tag("v2.0.0-ds")
+		assert.ErrorIs(t, err, git.ErrTagNotFound)
+	})
+
+	t.Run("no patterns", func(t *testing.T) {
+		tags := Tags{TagsConfig: config.Tags{NameTemplate: "{{ .Name }}"}}
+		assert.EqualError(t, tags.Run(ctx), "no tag patterns configured")
+	})
+}
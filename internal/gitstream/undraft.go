@@ -47,6 +47,8 @@ func (u *Undraft) Run(ctx context.Context) error {
 		return fmt.Errorf("could not list open PRs: %v", err)
 	}
 
+	upstream := newUpstreamSHAs(u.Repo, u.UpstreamConfig.Ref)
+
 	for _, pr := range prs {
 		logger := u.Logger.WithValues("url", *pr.HTMLURL)
 		logger.Info("Processing PR")
@@ -57,9 +59,14 @@ func (u *Undraft) Run(ctx context.Context) error {
 		}
 
 		for _, s := range shas {
-			upstreamCommit, err := u.Repo.CommitObject(s)
+			hash, err := upstream.resolve(s)
+			if err != nil {
+				return err
+			}
+
+			upstreamCommit, err := u.Repo.CommitObject(hash)
 			if err != nil {
-				return fmt.Errorf("could not find upstream commit %s: %v", s, err)
+				return fmt.Errorf("could not find upstream commit %s: %v", hash, err)
 			}
 
 			if t := upstreamCommit.Committer.When; oldestTime == nil || t.Before(*oldestTime) {
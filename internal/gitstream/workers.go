@@ -0,0 +1,154 @@
+package gitstream
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-logr/logr"
+	"github.com/rh-ecosystem-edge/gitstream/internal"
+	"github.com/rh-ecosystem-edge/gitstream/internal/hooks"
+	"github.com/rh-ecosystem-edge/gitstream/internal/plan"
+	"github.com/rh-ecosystem-edge/gitstream/internal/report"
+)
+
+type pickResult struct {
+	// cherryPickErr is set when the commit could not be cherry-picked; an issue should be created for it.
+	cherryPickErr error
+	// err is set when the run should stop.
+	err         error
+	hookResults []hooks.Result
+	// tree is the tree of the cherry-picked commit, if the commit could be cherry-picked.
+	tree plumbing.Hash
+}
+
+type pickJob struct {
+	branchName string
+	commit     *object.Commit
+	done       chan pickResult
+	logger     logr.Logger
+	// planned is the action that Apply expects for the job, or nil if the job was not planned.
+	planned *plan.Action
+	result  *report.CommitResult
+}
+
+func newPickJob(c *object.Commit, result *report.CommitResult, logger logr.Logger) *pickJob {
+	sha := c.Hash.String()
+
+	return &pickJob{
+		branchName: internal.GitStreamPrefix + sha,
+		commit:     c,
+		done:       make(chan pickResult, 1),
+		logger:     logger.WithValues("sha", sha),
+		result:     result,
+	}
+}
+
+// runParallel cherry-picks jobs concurrently, each worker using its own linked worktree of the downstream repository.
+// Jobs are published one at a time, in order.
+func (s *Sync) runParallel(ctx context.Context, jobs []*pickJob, workers int, publish publishFunc) error {
+	if workers > len(jobs) {
+		workers = len(jobs)
+	}
+
+	dir, err := os.MkdirTemp("", "gitstream-worktrees-")
+	if err != nil {
+		return fmt.Errorf("could not create a directory for worktrees: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	repos := make([]*git.Repository, 0, workers)
+	paths := make([]string, 0, workers)
+
+	for i := 0; i < workers; i++ {
+		path := filepath.Join(dir, strconv.Itoa(i))
+
+		s.Logger.V(1).Info("Adding worktree", "worker", i, "path", path)
+
+		repo, err := s.WorktreeManager.Add(ctx, path, s.DownstreamConfig.MainBranch)
+		if err != nil {
+			return fmt.Errorf("could not create the worktree for worker %d: %v", i, err)
+		}
+
+		defer func() {
+			if err := s.WorktreeManager.Remove(context.Background(), path); err != nil {
+				s.Logger.Error(err, "Could not remove worktree", "path", path)
+			}
+		}()
+
+		repos = append(repos, repo)
+		paths = append(paths, path)
+	}
+
+	workerCtx, cancel := context.WithCancel(ctx)
+
+	var wg sync.WaitGroup
+
+	// Workers must be done before their worktrees are removed.
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	queue := make(chan *pickJob)
+
+	for i := range repos {
+		wg.Add(1)
+
+		go func(repo *git.Repository, path string) {
+			defer wg.Done()
+
+			for j := range queue {
+				if workerCtx.Err() != nil {
+					return
+				}
+
+				j.done <- s.pick(workerCtx, repo, path, j)
+			}
+		}(repos[i], paths[i])
+	}
+
+	go func() {
+		defer close(queue)
+
+		for _, j := range jobs {
+			select {
+			case queue <- j:
+			case <-workerCtx.Done():
+				return
+			}
+		}
+	}()
+
+	// Other workers may still be working on their branch, so only the branch being published is pushed.
+	pushBranch := func(ctx context.Context, branchName string) error {
+		return s.GitHelper.PushBranchContextWithAuth(ctx, s.GitHubToken, branchName)
+	}
+
+	for _, j := range jobs {
+		var r pickResult
+
+		select {
+		case r = <-j.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		if r.err != nil {
+			return r.err
+		}
+
+		done, err := publish(ctx, j, r, pushBranch)
+		if err != nil || done {
+			return err
+		}
+	}
+
+	return nil
+}
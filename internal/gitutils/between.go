@@ -0,0 +1,143 @@
+package gitutils
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// walkSlop is the number of commits that are still walked once only excluded commits remain in the queue, to cope
+// with commits whose committer date is older than one of their parents'.
+const walkSlop = 5
+
+// CommitsBetween returns the commits reachable from include but not from any of exclude, like
+// git log ^exclude... include, in the order of a preorder walk from include.
+// Like git, it walks both sides by committer date and stops once only excluded commits remain, so the history that
+// exclude and include share is not walked. Missing parents, as found in shallow clones, end the walk.
+func CommitsBetween(exclude []*object.Commit, include *object.Commit) ([]*object.Commit, error) {
+	excluded, err := excludedBoundary(exclude, include)
+	if err != nil {
+		return nil, err
+	}
+
+	commits := make([]*object.Commit, 0)
+
+	err = object.NewCommitPreorderIter(include, excluded, nil).ForEach(func(c *object.Commit) error {
+		commits = append(commits, c)
+		return nil
+	})
+	if err != nil && !errors.Is(err, plumbing.ErrObjectNotFound) {
+		return nil, fmt.Errorf("could not walk the history of %s: %v", include.Hash, err)
+	}
+
+	return commits, nil
+}
+
+// excludedBoundary returns a set of commits reachable from exclude that contains at least all of those that are also
+// reachable from include without going through another excluded commit.
+func excludedBoundary(exclude []*object.Commit, include *object.Commit) (map[plumbing.Hash]bool, error) {
+	excluded := make(map[plumbing.Hash]bool)
+	q := &commitQueue{}
+
+	push := func(c *object.Commit, ex bool) {
+		// A commit is walked again only if it turns out to be excluded after being walked as included.
+		if prev, ok := excluded[c.Hash]; ok && (prev || !ex) {
+			return
+		}
+
+		excluded[c.Hash] = ex
+		heap.Push(q, c)
+	}
+
+	for _, c := range exclude {
+		push(c, true)
+	}
+
+	push(include, false)
+
+	slop := walkSlop
+
+	for q.Len() > 0 {
+		c := heap.Pop(q).(*object.Commit)
+		ex := excluded[c.Hash]
+
+		for i := 0; i < c.NumParents(); i++ {
+			p, err := c.Parent(i)
+			if errors.Is(err, plumbing.ErrObjectNotFound) {
+				continue
+			} else if err != nil {
+				return nil, fmt.Errorf("could not get parent %d of %s: %v", i, c.Hash, err)
+			}
+
+			push(p, ex)
+		}
+
+		if !q.onlyExcluded(excluded) {
+			slop = walkSlop
+		} else if slop--; slop == 0 {
+			break
+		}
+	}
+
+	for h, ex := range excluded {
+		if !ex {
+			delete(excluded, h)
+		}
+	}
+
+	return excluded, nil
+}
+
+// commitQueue is a heap of commits, newest committer date first. Commits with the same date are popped in the order
+// they were pushed, so that children come before their parents.
+type commitQueue struct {
+	commits []*object.Commit
+	order   []int
+	pushed  int
+}
+
+func (q *commitQueue) Len() int { return len(q.commits) }
+
+func (q *commitQueue) Less(i, j int) bool {
+	ti, tj := q.commits[i].Committer.When, q.commits[j].Committer.When
+
+	if !ti.Equal(tj) {
+		return ti.After(tj)
+	}
+
+	return q.order[i] < q.order[j]
+}
+
+func (q *commitQueue) Swap(i, j int) {
+	q.commits[i], q.commits[j] = q.commits[j], q.commits[i]
+	q.order[i], q.order[j] = q.order[j], q.order[i]
+}
+
+func (q *commitQueue) Push(x interface{}) {
+	q.commits = append(q.commits, x.(*object.Commit))
+	q.order = append(q.order, q.pushed)
+	q.pushed++
+}
+
+func (q *commitQueue) Pop() interface{} {
+	n := len(q.commits) - 1
+	c := q.commits[n]
+
+	q.commits = q.commits[:n]
+	q.order = q.order[:n]
+
+	return c
+}
+
+func (q *commitQueue) onlyExcluded(excluded map[plumbing.Hash]bool) bool {
+	for _, c := range q.commits {
+		if !excluded[c.Hash] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -5,70 +5,232 @@ import (
 	"errors"
 	"fmt"
 	"os/exec"
+	"time"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-logr/logr"
+	"github.com/rh-ecosystem-edge/gitstream/internal/config"
+	"github.com/rh-ecosystem-edge/gitstream/internal/hooks"
 	"github.com/rh-ecosystem-edge/gitstream/internal/process"
 )
 
 //go:generate mockgen -source=cherrypick.go -package=gitutils -destination=mock_cherrypick.go
 
 type CherryPicker interface {
-	Run(ctx context.Context, repo *git.Repository, repoPath string, commit *object.Commit) error
+	Run(ctx context.Context, repo *git.Repository, repoPath string, commit *object.Commit) ([]hooks.Result, error)
 }
 
+const (
+	BackendGit   = config.SyncBackendGit
+	BackendGoGit = config.SyncBackendGoGit
+)
+
+const (
+	StepAfterCherryPick = hooks.StageAfterCherryPick
+	StepAfterCommit     = hooks.StageAfterCommit
+	StepBeforeCommit    = hooks.StageBeforeCommit
+	StepCherryPick      = "cherry-pick"
+	StepCommit          = "commit"
+)
+
+// CherryPickError is returned by CherryPicker implementations and records the step that failed.
+type CherryPickError struct {
+	Err  error
+	Step string
+}
+
+func (e *CherryPickError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CherryPickError) Unwrap() error {
+	return e.Err
+}
+
+// CommitOptions configures the commits created by a CherryPicker.
+type CommitOptions struct {
+	// Committer is used as the committer of commits if its name and email are set. Otherwise, go-git reads them from
+	// the git configuration.
+	Committer config.Committer
+	// Markup is the key of the trailer that records the SHA of the picked commit. No trailer is added if it is empty.
+	Markup string
+	// MergeCommits is the upstream merge commits mode. In pr mode, merge commits are picked as the commits they
+	// merged, each committed with its own markup.
+	MergeCommits string
+	// SignOff adds a Signed-off-by trailer for Committer.
+	SignOff bool
+	// Signer signs commits if it is not nil.
+	Signer git.Signer
+}
+
+type applyFunc func(ctx context.Context, logger logr.Logger, repo *git.Repository, repoPath string, commit *object.Commit) error
+
 type CherryPickerImpl struct {
-	beforeCommitCmds [][]string
-	executor         Executor
-	logger           logr.Logger
-	markup           string
+	apply    applyFunc
+	executor Executor
+	hooks    *hooks.Runner
+	logger   logr.Logger
+	opts     CommitOptions
 }
 
-func NewCherryPicker(markup string, logger logr.Logger, beforeCommitCmds ...[]string) *CherryPickerImpl {
-	return &CherryPickerImpl{
-		beforeCommitCmds: beforeCommitCmds,
-		executor:         defaultExecutor,
-		logger:           logger,
-		markup:           markup,
+// NewCherryPicker returns a CherryPicker that applies commits with the git binary.
+// hooks may be nil.
+func NewCherryPicker(opts CommitOptions, hooks *hooks.Runner, logger logr.Logger) *CherryPickerImpl {
+	c := &CherryPickerImpl{
+		executor: defaultExecutor,
+		hooks:    hooks,
+		logger:   logger,
+		opts:     opts,
 	}
+
+	c.apply = c.gitCherryPick
+
+	return c
 }
 
-func (c *CherryPickerImpl) Run(ctx context.Context, repo *git.Repository, repoPath string, commit *object.Commit) error {
-	sha := commit.Hash.String()
+// NewNativeCherryPicker returns a CherryPicker that applies commits in-process with go-git.
+func NewNativeCherryPicker(opts CommitOptions, hooks *hooks.Runner, logger logr.Logger) *CherryPickerImpl {
+	c := NewCherryPicker(opts, hooks, logger)
+	c.apply = nativeCherryPick
 
-	logger := c.logger.WithValues("sha", sha)
+	return c
+}
 
-	if err := c.executor.RunCommand(ctx, logger, "git", repoPath, "cherry-pick", "-n", sha, "-m1"); err != nil {
+func (c *CherryPickerImpl) gitCherryPick(ctx context.Context, logger logr.Logger, _ *git.Repository, repoPath string, commit *object.Commit) error {
+	if err := c.executor.RunCommand(ctx, logger, "git", repoPath, "cherry-pick", "-n", commit.Hash.String(), "-m1"); err != nil {
 		return fmt.Errorf("error running git: %w", err)
 	}
 
-	for i, command := range c.beforeCommitCmds {
-		if err := c.executor.RunCommand(ctx, logger, command[0], repoPath, command[1:]...); err != nil {
-			return fmt.Errorf("could not run a command %d before committing: %v", i, err)
+	return nil
+}
+
+// Run cherry-picks commit and commits the result, running hooks at each stage. If any step fails, the on-failure hooks
+// are run. It returns the results of all hooks that ran.
+func (c *CherryPickerImpl) Run(ctx context.Context, repo *git.Repository, repoPath string, commit *object.Commit) ([]hooks.Result, error) {
+	logger := c.logger.WithValues("sha", commit.Hash.String())
+
+	var (
+		results []hooks.Result
+		err     error
+	)
+
+	if c.opts.MergeCommits == config.MergeCommitsPR && commit.NumParents() > 1 {
+		results, err = c.runMerged(ctx, logger, repo, repoPath, commit)
+	} else {
+		results, err = c.run(ctx, logger, repo, repoPath, commit)
+	}
+
+	if err != nil {
+		failureResults, hookErr := c.hooks.Run(ctx, hooks.StageOnFailure, repoPath, commit)
+		if hookErr != nil {
+			logger.Error(hookErr, "on-failure hook failed")
+		}
+
+		results = append(results, failureResults...)
+	}
+
+	return results, err
+}
+
+// runMerged cherry-picks the commits merged by merge, in order.
+func (c *CherryPickerImpl) runMerged(ctx context.Context, logger logr.Logger, repo *git.Repository, repoPath string, merge *object.Commit) ([]hooks.Result, error) {
+	merged, err := MergedCommits(merge)
+	if err != nil {
+		return nil, &CherryPickError{Err: err, Step: StepCherryPick}
+	}
+
+	if len(merged) == 0 {
+		return nil, &CherryPickError{Err: fmt.Errorf("merge commit %s merged no commits", merge.Hash), Step: StepCherryPick}
+	}
+
+	logger.Info("Cherry-picking the commits merged by the merge commit", "count", len(merged))
+
+	var results []hooks.Result
+
+	for _, m := range merged {
+		res, err := c.run(ctx, logger.WithValues("merged sha", m.Hash.String()), repo, repoPath, m)
+
+		results = append(results, res...)
+
+		if err != nil {
+			return results, fmt.Errorf("could not cherry-pick merged commit %s: %w", m.Hash, err)
+		}
+	}
+
+	return results, nil
+}
+
+func (c *CherryPickerImpl) run(ctx context.Context, logger logr.Logger, repo *git.Repository, repoPath string, commit *object.Commit) ([]hooks.Result, error) {
+	var results []hooks.Result
+
+	runHooks := func(stage string) error {
+		res, err := c.hooks.Run(ctx, stage, repoPath, commit)
+
+		results = append(results, res...)
+
+		if err != nil {
+			return &CherryPickError{Err: err, Step: stage}
+		}
+
+		return nil
+	}
+
+	if err := c.apply(ctx, logger, repo, repoPath, commit); err != nil {
+		return results, &CherryPickError{
+			Err:  err,
+			Step: StepCherryPick,
 		}
 	}
 
+	if err := runHooks(hooks.StageAfterCherryPick); err != nil {
+		return results, err
+	}
+
+	if err := runHooks(hooks.StageBeforeCommit); err != nil {
+		return results, err
+	}
+
 	wt, err := repo.Worktree()
 	if err != nil {
-		return fmt.Errorf("could not get worktree: %v", err)
+		return results, fmt.Errorf("could not get worktree: %v", err)
 	}
 
 	opts := git.CommitOptions{
 		All:    true,
 		Author: &commit.Author,
+		Signer: c.opts.Signer,
+	}
+
+	trailers := make([]string, 0, 2)
+
+	if committer := c.opts.Committer; committer.Name != "" && committer.Email != "" {
+		opts.Committer = &object.Signature{
+			Name:  committer.Name,
+			Email: committer.Email,
+			When:  time.Now(),
+		}
+
+		if c.opts.SignOff {
+			trailers = append(trailers, fmt.Sprintf("Signed-off-by: %s <%s>", committer.Name, committer.Email))
+		}
 	}
 
-	msg := fmt.Sprintf("%s\n\n%s: %v", commit.Message, c.markup, sha)
+	if c.opts.Markup != "" {
+		trailers = append(trailers, fmt.Sprintf("%s: %v", c.opts.Markup, commit.Hash))
+	}
 
-	newCommit, err := wt.Commit(msg, &opts)
+	newCommit, err := wt.Commit(cherryPickMessage(commit.Message, trailers...), &opts)
 	if err != nil {
-		return fmt.Errorf("could not commit: %v", err)
+		return results, &CherryPickError{
+			Err:  fmt.Errorf("could not commit: %w", err),
+			Step: StepCommit,
+		}
 	}
 
 	logger.Info("Successfully committed", "new sha", newCommit)
 
-	return nil
+	return results, runHooks(hooks.StageAfterCommit)
 }
 
 type Executor interface {
@@ -2,17 +2,22 @@ package gitutils
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"testing"
 
-	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-logr/logr"
 	"github.com/golang/mock/gomock"
+	"github.com/rh-ecosystem-edge/gitstream/internal/config"
+	"github.com/rh-ecosystem-edge/gitstream/internal/hooks"
 	"github.com/rh-ecosystem-edge/gitstream/internal/process"
 	"github.com/rh-ecosystem-edge/gitstream/internal/test"
 	"github.com/stretchr/testify/assert"
@@ -20,39 +25,52 @@ import (
 )
 
 func TestCherryPickerImpl_Run(t *testing.T) {
-	const (
-		markup   = "Some-Markup"
-		repoPath = "/path/to/repo"
-		sha      = "e3229f3c533ed51070beff092e5c7694a8ee81f0"
-	)
-
-	commands := [][]string{
-		{"first", "command"},
-		{"second", "command"},
+	const markup = "Some-Markup"
+
+	// appendStage returns a hook command that appends the stage and the commit SHA to a log file.
+	appendStage := []string{"sh", "-c", `echo "$GITSTREAM_HOOK_STAGE $GITSTREAM_COMMIT_SHA" >> hooks.log`}
+
+	syncCfg := config.Sync{
+		BeforeCommit: [][]string{appendStage},
+		Hooks: []config.Hook{
+			{Command: appendStage, Name: "after-cherry-pick", Stage: hooks.StageAfterCherryPick},
+			{Command: appendStage, Name: "after-commit", Stage: hooks.StageAfterCommit},
+			{Command: appendStage, Name: "other-paths", Paths: []string{"other/"}, Stage: hooks.StageAfterCommit},
+			{Command: appendStage, Name: "on-failure", Stage: hooks.StageOnFailure},
+		},
 	}
 
-	ctrl := gomock.NewController(t)
-
-	executor := NewMockExecutor(ctrl)
-
 	logger := logr.Discard()
 
-	cp := NewCherryPicker(markup, logger, commands...)
-	cp.executor = executor
+	runner, err := hooks.NewRunner(syncCfg, "some-upstream-url", logger)
+	require.NoError(t, err)
 
 	ctx := context.Background()
 
-	repo, fs := test.NewRepoWithFS(t)
+	setup := func(t *testing.T) (*git.Repository, billy.Filesystem, *object.Commit, string) {
+		t.Helper()
+
+		repo, fs := test.NewRepoWithFS(t)
 
-	commit := &object.Commit{
-		Hash:    plumbing.NewHash(sha),
-		Message: "Some message",
+		_, commit := test.AddCommit(t, repo, fs, "Some message", map[string]*string{"file": strPtr("contents")})
+
+		return repo, fs, commit, t.TempDir()
 	}
 
-	gomock.InOrder(
+	t.Run("working as expected", func(t *testing.T) {
+		repo, fs, commit, repoPath := setup(t)
+		sha := commit.Hash.String()
+
+		ctrl := gomock.NewController(t)
+
+		executor := NewMockExecutor(ctrl)
+
+		cp := NewCherryPicker(CommitOptions{Markup: markup}, runner, logger)
+		cp.executor = executor
+
 		executor.
 			EXPECT().
-			RunCommand(ctx, logger, "git", repoPath, "cherry-pick", "-n", sha, "-m1").
+			RunCommand(ctx, gomock.Any(), "git", repoPath, "cherry-pick", "-n", sha, "-m1").
 			Do(func(_ context.Context, _ logr.Logger, _, _ string, _ ...string) {
 				wt, err := repo.Worktree()
 				require.NoError(t, err)
@@ -68,23 +86,113 @@ func TestCherryPickerImpl_Run(t *testing.T) {
 
 				_, err = wt.Add(testFileName)
 				require.NoError(t, err)
-			}),
-		executor.EXPECT().RunCommand(ctx, logger, "first", repoPath, "command"),
-		executor.EXPECT().RunCommand(ctx, logger, "second", repoPath, "command"),
-	)
+			})
 
-	err := cp.Run(ctx, repo, repoPath, commit)
-	assert.NoError(t, err)
+		results, err := cp.Run(ctx, repo, repoPath, commit)
+		assert.NoError(t, err)
 
-	head, err := repo.Head()
-	require.NoError(t, err)
+		names := make([]string, 0, len(results))
 
-	headCommit, err := repo.CommitObject(head.Hash())
-	require.NoError(t, err)
-	assert.True(
-		t,
-		strings.HasSuffix(headCommit.Message, "Some-Markup: "+sha),
-	)
+		for _, r := range results {
+			names = append(names, r.Name)
+		}
+
+		assert.Equal(t, []string{"after-cherry-pick", "before_commit[0]", "after-commit"}, names)
+
+		log, err := os.ReadFile(filepath.Join(repoPath, "hooks.log"))
+		require.NoError(t, err)
+
+		assert.Equal(
+			t,
+			fmt.Sprintf("after-cherry-pick %[1]s\nbefore-commit %[1]s\nafter-commit %[1]s\n", sha),
+			string(log),
+		)
+
+		head, err := repo.Head()
+		require.NoError(t, err)
+
+		headCommit, err := repo.CommitObject(head.Hash())
+		require.NoError(t, err)
+		assert.True(
+			t,
+			strings.HasSuffix(headCommit.Message, "Some-Markup: "+sha),
+		)
+	})
+
+	t.Run("cherry-pick failure", func(t *testing.T) {
+		repo, _, commit, repoPath := setup(t)
+		sha := commit.Hash.String()
+
+		ctrl := gomock.NewController(t)
+
+		executor := NewMockExecutor(ctrl)
+
+		cp := NewCherryPicker(CommitOptions{Markup: markup}, runner, logger)
+		cp.executor = executor
+
+		randomError := errors.New("random error")
+
+		executor.
+			EXPECT().
+			RunCommand(ctx, gomock.Any(), "git", repoPath, "cherry-pick", "-n", sha, "-m1").
+			Return(randomError)
+
+		results, err := cp.Run(ctx, repo, repoPath, commit)
+
+		cpe := &CherryPickError{}
+		require.ErrorAs(t, err, &cpe)
+		assert.Equal(t, StepCherryPick, cpe.Step)
+		assert.ErrorIs(t, err, randomError)
+
+		require.Len(t, results, 1)
+		assert.Equal(t, "on-failure", results[0].Name)
+
+		log, err := os.ReadFile(filepath.Join(repoPath, "hooks.log"))
+		require.NoError(t, err)
+		assert.Equal(t, "on-failure "+sha+"\n", string(log))
+	})
+
+	t.Run("hook failure", func(t *testing.T) {
+		repo, _, commit, repoPath := setup(t)
+
+		failing, err := hooks.NewRunner(
+			config.Sync{
+				Hooks: []config.Hook{
+					{Command: []string{"sh", "-c", "echo allowed; exit 1"}, AllowFailure: true, Name: "allowed", Stage: hooks.StageBeforeCommit},
+					{Command: []string{"sh", "-c", "echo broken; exit 2"}, Name: "broken", Stage: hooks.StageBeforeCommit},
+					{Command: []string{"true"}, Name: "never", Stage: hooks.StageBeforeCommit},
+				},
+			},
+			"some-upstream-url",
+			logger,
+		)
+		require.NoError(t, err)
+
+		ctrl := gomock.NewController(t)
+
+		executor := NewMockExecutor(ctrl)
+		executor.EXPECT().RunCommand(ctx, gomock.Any(), "git", repoPath, gomock.Any())
+
+		cp := NewCherryPicker(CommitOptions{Markup: markup}, failing, logger)
+		cp.executor = executor
+
+		results, err := cp.Run(ctx, repo, repoPath, commit)
+
+		cpe := &CherryPickError{}
+		require.ErrorAs(t, err, &cpe)
+		assert.Equal(t, StepBeforeCommit, cpe.Step)
+
+		pe := &process.Error{}
+		require.ErrorAs(t, err, &pe)
+		assert.Equal(t, 2, pe.ExitCode())
+		assert.Equal(t, "broken\n", pe.CombinedString())
+
+		require.Len(t, results, 2)
+		assert.Equal(t, "allowed", results[0].Name)
+		assert.Error(t, results[0].Err)
+		assert.Equal(t, "allowed\n", results[0].Output)
+		assert.Equal(t, "broken", results[1].Name)
+	})
 }
 
 type executorFunc = func(ctx context.Context, bin string, args ...string) *exec.Cmd
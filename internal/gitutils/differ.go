@@ -3,9 +3,12 @@ package gitutils
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-logr/logr"
 	"github.com/rh-ecosystem-edge/gitstream/internal"
@@ -16,8 +19,17 @@ import (
 
 //go:generate mockgen -source=differ.go -package=gitutils -destination=mock_differ.go
 
+// UnresolvedIntent is a downstream intent with an abbreviated SHA that matches no upstream commit, or more than one.
+type UnresolvedIntent struct {
+	intents.Intent
+	// Candidates are the upstream commits matching SHA. It is empty if SHA is unknown.
+	Candidates []plumbing.Hash
+	SHA        string
+}
+
 type Differ interface {
-	GetMissingCommits(ctx context.Context, repo *git.Repository, repoName *gh.RepoName, since *time.Time, dsMainBranch string, upstreamConfig config.Upstream) ([]*object.Commit, error)
+	GetMissingCommits(ctx context.Context, repo *git.Repository, repoName *gh.RepoName, diffConfig config.Diff, dsMainBranch string, upstreamConfig config.Upstream) ([]*object.Commit, []UnresolvedIntent, error)
+	GetMissingCommitsFrom(ctx context.Context, repo *git.Repository, repoName *gh.RepoName, diffConfig config.Diff, dsMainBranch string, upstreamConfig config.Upstream, from plumbing.Hash) ([]*object.Commit, []UnresolvedIntent, error)
 }
 
 type DifferImpl struct {
@@ -34,20 +46,69 @@ func NewDiffer(helper Helper, ig intents.Getter, logger logr.Logger) *DifferImpl
 	}
 }
 
+// GetMissingCommits returns the upstream commits that are not referred to by any downstream commit, GitStream issue or
+// skip list entry.
+// Commits reachable from diffCfg.SinceRef, and commits committed before diffCfg.CommitsSince, are not considered.
+// Abbreviated SHAs are resolved against the upstream commits that are considered; those that match no commit or
+// several commits are returned as unresolved intents.
 func (d *DifferImpl) GetMissingCommits(
 	ctx context.Context,
 	repo *git.Repository,
 	repoName *gh.RepoName,
-	since *time.Time,
+	diffCfg config.Diff,
 	dsMainBranch string,
 	usCfg config.Upstream,
-) ([]*object.Commit, error) {
+) ([]*object.Commit, []UnresolvedIntent, error) {
+	downstreamIntents, err := d.downstreamIntents(ctx, repo, repoName, diffCfg, dsMainBranch)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if _, err = d.helper.RecreateRemote(ctx, internal.UpstreamRemoteName, usCfg.URL); err != nil {
+		return nil, nil, fmt.Errorf("could not recreate remote: %v", err)
+	}
+
+	from, err := d.helper.GetRemoteRef(ctx, internal.UpstreamRemoteName, usCfg.Ref)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not get the ref for %s/%s: %v", internal.UpstreamRemoteName, usCfg.Ref, err)
+	}
+
+	return d.missingCommits(ctx, repo, downstreamIntents, diffCfg, usCfg, from.Hash())
+}
+
+// GetMissingCommitsFrom is like GetMissingCommits, but considers the upstream commits reachable from from, which must
+// already be in repo, instead of those of the upstream ref.
+func (d *DifferImpl) GetMissingCommitsFrom(
+	ctx context.Context,
+	repo *git.Repository,
+	repoName *gh.RepoName,
+	diffCfg config.Diff,
+	dsMainBranch string,
+	usCfg config.Upstream,
+	from plumbing.Hash,
+) ([]*object.Commit, []UnresolvedIntent, error) {
+	downstreamIntents, err := d.downstreamIntents(ctx, repo, repoName, diffCfg, dsMainBranch)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return d.missingCommits(ctx, repo, downstreamIntents, diffCfg, usCfg, from)
+}
+
+// downstreamIntents returns the intents found in the downstream main branch, in GitStream issues and in the skip list.
+func (d *DifferImpl) downstreamIntents(
+	ctx context.Context,
+	repo *git.Repository,
+	repoName *gh.RepoName,
+	diffCfg config.Diff,
+	dsMainBranch string,
+) (intents.CommitIntents, error) {
 	dsFrom, err := d.helper.GetBranchRef(ctx, dsMainBranch)
 	if err != nil {
 		return nil, fmt.Errorf("could not get the tip of branch %q: %v", dsMainBranch, err)
 	}
 
-	logIntents, err := d.intentsGetter.FromLocalGitRepo(ctx, repo, dsFrom.Hash(), since)
+	logIntents, err := d.intentsGetter.FromLocalGitRepo(ctx, repo, dsFrom.Hash(), diffCfg.CommitsSince)
 	if err != nil {
 		return nil, fmt.Errorf("could not get hashes from commits: %v", err)
 	}
@@ -57,21 +118,116 @@ func (d *DifferImpl) GetMissingCommits(
 		return nil, fmt.Errorf("could not get hashes from issues: %v", err)
 	}
 
-	downstreamIntents := intents.MergeCommitIntents(logIntents, issueIntents)
+	skipIntents, err := d.intentsGetter.FromSkipList(ctx, repo, dsFrom.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("could not get hashes from the skip list: %v", err)
+	}
 
-	if _, err = d.helper.RecreateRemote(ctx, internal.UpstreamRemoteName, usCfg.URL); err != nil {
-		return nil, fmt.Errorf("could not recreate remote: %v", err)
+	return intents.MergeCommitIntents(logIntents, issueIntents, skipIntents), nil
+}
+
+// missingCommits returns the upstream commits reachable from from that are not referred to by downstreamIntents.
+func (d *DifferImpl) missingCommits(
+	ctx context.Context,
+	repo *git.Repository,
+	downstreamIntents intents.CommitIntents,
+	diffCfg config.Diff,
+	usCfg config.Upstream,
+	from plumbing.Hash,
+) ([]*object.Commit, []UnresolvedIntent, error) {
+	var (
+		err      error
+		sinceRef *object.Commit
+	)
+
+	if diffCfg.SinceRef != "" {
+		if sinceRef, err = d.resolveSinceRef(repo, diffCfg.SinceRef); err != nil {
+			return nil, nil, err
+		}
 	}
 
-	from, err := d.helper.GetRemoteRef(ctx, internal.UpstreamRemoteName, usCfg.Ref)
+	upstreamCommits, err := d.upstreamCommits(ctx, repo, from, diffCfg.CommitsSince, usCfg.MergeCommits, sinceRef)
 	if err != nil {
-		return nil, fmt.Errorf("could not get the ref for %s/%s: %v", internal.UpstreamRemoteName, usCfg.Ref, err)
+		return nil, nil, err
 	}
 
+	// In pr mode, merge commits are picked as the commits they merged, which downstream intents refer to.
+	merged := make(map[plumbing.Hash][]*object.Commit)
+	candidates := upstreamCommits
+
+	if usCfg.MergeCommits == config.MergeCommitsPR {
+		candidates = append(make([]*object.Commit, 0, len(upstreamCommits)), upstreamCommits...)
+
+		for _, commit := range upstreamCommits {
+			if commit.NumParents() < 2 {
+				continue
+			}
+
+			mc, err := MergedCommits(commit)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			merged[commit.Hash] = mc
+			candidates = append(candidates, mc...)
+		}
+	}
+
+	unresolved := d.resolveAbbreviatedSHAs(downstreamIntents, candidates)
+
 	commits := make([]*object.Commit, 0)
 
+	for _, commit := range upstreamCommits {
+		hash := commit.Hash
+
+		if usCfg.MergeCommits == config.MergeCommitsSkip && commit.NumParents() > 1 {
+			d.logger.V(1).Info("Ignoring upstream merge commit", "SHA", hash)
+			continue
+		}
+
+		intent, ok := downstreamIntents[hash.String()]
+		if ok {
+			d.logger.Info("Upstream commit found in downstream", "SHA", hash, "origin", intent.Origin, "markup", intent.Markup)
+			continue
+		}
+
+		if mc, isMerge := merged[hash]; isMerge && allInDownstream(mc, downstreamIntents) {
+			d.logger.Info("Upstream merge commit found in downstream through the commits it merged", "SHA", hash)
+			continue
+		}
+
+		d.logger.Info("Upstream commit not in downstream", "SHA", hash)
+		commits = append(commits, commit)
+	}
+
+	return commits, unresolved, nil
+}
+
+// upstreamCommits returns the upstream commits to consider, newest first: all commits reachable from from, or only its
+// first-parent chain, depending on the merge commits mode. If sinceRef is not nil, the walk stops at the commits
+// reachable from it.
+func (d *DifferImpl) upstreamCommits(
+	ctx context.Context,
+	repo *git.Repository,
+	from plumbing.Hash,
+	since *time.Time,
+	mode string,
+	sinceRef *object.Commit,
+) ([]*object.Commit, error) {
+	firstParent := mode == config.MergeCommitsFirstParent || mode == config.MergeCommitsPR
+
+	if sinceRef != nil {
+		return d.upstreamCommitsSinceRef(ctx, repo, from, since, firstParent, sinceRef)
+	}
+
+	if firstParent {
+		return firstParentCommits(ctx, repo, from, since, nil)
+	}
+
+	upstreamCommits := make([]*object.Commit, 0)
+
 	lo := git.LogOptions{
-		From: from.Hash(),
+		From: from,
 		//Order: git.LogOrderCommitterTime,
 		Since: since,
 	}
@@ -88,18 +244,132 @@ func (d *DifferImpl) GetMissingCommits(
 		default:
 		}
 
-		hash := commit.Hash
-
-		origin, ok := downstreamIntents[hash]
-		if ok {
-			d.logger.Info("Upstream commit found in downstream", "SHA", hash, "origin", origin)
-		} else {
-			d.logger.Info("Upstream commit not in downstream", "SHA", hash)
-			commits = append(commits, commit)
-		}
+		upstreamCommits = append(upstreamCommits, commit)
 
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	return upstreamCommits, nil
+}
+
+// upstreamCommitsSinceRef is like upstreamCommits, but only walks the commits reachable from from and not from
+// sinceRef, like git log sinceRef..from.
+func (d *DifferImpl) upstreamCommitsSinceRef(
+	ctx context.Context,
+	repo *git.Repository,
+	from plumbing.Hash,
+	since *time.Time,
+	firstParent bool,
+	sinceRef *object.Commit,
+) ([]*object.Commit, error) {
+	fromCommit, err := repo.CommitObject(from)
+	if err != nil {
+		return nil, fmt.Errorf("could not get commit %s: %v", from, err)
+	}
+
+	unhandled, err := CommitsBetween([]*object.Commit{sinceRef}, fromCommit)
+	if err != nil {
+		return nil, err
+	}
+
+	d.logger.Info("Ignoring upstream commits reachable from since_ref", "SHA", sinceRef.Hash)
+
+	if firstParent {
+		within := make(map[plumbing.Hash]bool, len(unhandled))
+
+		for _, c := range unhandled {
+			within[c.Hash] = true
+		}
+
+		return firstParentCommits(ctx, repo, from, since, within)
+	}
+
+	upstreamCommits := make([]*object.Commit, 0, len(unhandled))
+
+	for _, c := range unhandled {
+		if since == nil || !c.Committer.When.Before(*since) {
+			upstreamCommits = append(upstreamCommits, c)
+		}
+	}
+
+	return upstreamCommits, nil
+}
+
+// resolveSinceRef returns the commit that ref, an upstream tag or SHA, points to.
+func (d *DifferImpl) resolveSinceRef(repo *git.Repository, ref string) (*object.Commit, error) {
+	hash, err := repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve since_ref %q: %v", ref, err)
+	}
+
+	c, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("could not get the since_ref commit %s: %v", hash, err)
+	}
+
+	return c, nil
+}
+
+func allInDownstream(commits []*object.Commit, cis intents.CommitIntents) bool {
+	for _, c := range commits {
+		if _, ok := cis[c.Hash.String()]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// resolveAbbreviatedSHAs replaces the abbreviated SHAs in cis that match exactly one of commits with the full SHA.
+// It returns the abbreviated SHAs that match no commit or several commits.
+func (d *DifferImpl) resolveAbbreviatedSHAs(cis intents.CommitIntents, commits []*object.Commit) []UnresolvedIntent {
+	var unresolved []UnresolvedIntent
+
+	abbreviated := make([]string, 0)
+
+	for sha := range cis {
+		if len(sha) < 2*len(plumbing.ZeroHash) {
+			abbreviated = append(abbreviated, sha)
+		}
+	}
+
+	sort.Strings(abbreviated)
+
+	for _, sha := range abbreviated {
+		intent := cis[sha]
+
+		candidates := make([]plumbing.Hash, 0)
+
+		for _, c := range commits {
+			if strings.HasPrefix(c.Hash.String(), sha) {
+				candidates = append(candidates, c.Hash)
+			}
+		}
+
+		logger := d.logger.WithValues("SHA", sha, "origin", intent.Origin)
+
+		switch len(candidates) {
+		case 0:
+			logger.Info("Warning: abbreviated SHA matches no upstream commit")
+		case 1:
+			full := candidates[0].String()
+
+			logger.V(1).Info("Resolved abbreviated SHA", "full", full)
+
+			if _, ok := cis[full]; !ok {
+				cis[full] = intent
+			}
+
+			continue
+		default:
+			logger.Info("Warning: abbreviated SHA is ambiguous", "candidates", candidates)
+		}
+
+		unresolved = append(unresolved, UnresolvedIntent{Intent: intent, Candidates: candidates, SHA: sha})
+	}
 
-	return commits, err
+	return unresolved
 }
@@ -2,10 +2,12 @@ package gitutils
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-logr/logr"
 	"github.com/golang/mock/gomock"
 	"github.com/rh-ecosystem-edge/gitstream/internal/config"
@@ -54,6 +56,21 @@ func TestDifferImpl_GetMissingCommits(t *testing.T) {
 
 	_, missingCommit := test.AddEmptyCommit(t, repo, "commit 3")
 
+	// unknown is a prefix of none of the upstream commits
+	unknown := ""
+
+	for _, c := range "0123456789abcdef" {
+		prefix := string(c) + "000"
+
+		if !strings.HasPrefix(hash0.String(), string(c)) &&
+			!strings.HasPrefix(hash1.String(), string(c)) &&
+			!strings.HasPrefix(hash2.String(), string(c)) &&
+			!strings.HasPrefix(missingCommit.Hash.String(), string(c)) {
+			unknown = prefix
+			break
+		}
+	}
+
 	// downstream has 3, brnch main points to hash2
 	dsMainRef := plumbing.NewHashReference(plumbing.NewBranchReferenceName(dsMainBranch), hash2)
 
@@ -67,23 +84,195 @@ func TestDifferImpl_GetMissingCommits(t *testing.T) {
 		ig.
 			EXPECT().
 			FromLocalGitRepo(ctx, repo, hash2, &since).
-			Return(intents.CommitIntents{hash0: "commit from log"}, nil),
+			Return(intents.CommitIntents{hash0.String(): {Origin: "commit from log"}}, nil),
 		ig.
 			EXPECT().
 			FromGitHubIssues(ctx, &repoName).
 			Return(
 				intents.CommitIntents{
-					hash1: "commit from issue",
-					hash2: "commit from PR",
+					hash1.String()[:7]: {Origin: "commit from issue"},
+					hash2.String():     {Origin: "commit from PR"},
+					unknown:            {Origin: "unknown"},
 				},
 				nil),
+		ig.EXPECT().FromSkipList(ctx, repo, hash2),
 		helper.EXPECT().RecreateRemote(ctx, remoteName, remoteURL),
 		helper.EXPECT().GetRemoteRef(ctx, remoteName, branchName).Return(head, nil),
 	)
 
-	commits, err := di.GetMissingCommits(context.Background(), repo, &repoName, &since, dsMainBranch, usCfg)
+	commits, unresolved, err := di.GetMissingCommits(context.Background(), repo, &repoName, config.Diff{CommitsSince: &since}, dsMainBranch, usCfg)
 	assert.NoError(t, err)
 
 	assert.Len(t, commits, 1)
 	assert.Contains(t, commits, missingCommit)
+
+	require.Len(t, unresolved, 1)
+	assert.Equal(t, unknown, unresolved[0].SHA)
+	assert.Empty(t, unresolved[0].Candidates)
+}
+
+func TestDifferImpl_resolveAbbreviatedSHAs(t *testing.T) {
+	commits := []*object.Commit{
+		{Hash: plumbing.NewHash("abcd000000000000000000000000000000000000")},
+		{Hash: plumbing.NewHash("abcd100000000000000000000000000000000000")},
+		{Hash: plumbing.NewHash("1234000000000000000000000000000000000000")},
+	}
+
+	cis := intents.CommitIntents{
+		"abcd":  {Origin: "ambiguous"},
+		"abcd1": {Origin: "resolved"},
+		"ffff":  {Origin: "unknown"},
+		"5678000000000000000000000000000000000000": {Origin: "full"},
+	}
+
+	unresolved := NewDiffer(nil, nil, logr.Discard()).resolveAbbreviatedSHAs(cis, commits)
+
+	assert.Equal(
+		t,
+		[]UnresolvedIntent{
+			{
+				Intent:     intents.Intent{Origin: "ambiguous"},
+				Candidates: []plumbing.Hash{commits[0].Hash, commits[1].Hash},
+				SHA:        "abcd",
+			},
+			{
+				Intent:     intents.Intent{Origin: "unknown"},
+				Candidates: []plumbing.Hash{},
+				SHA:        "ffff",
+			},
+		},
+		unresolved,
+	)
+
+	assert.Equal(t, intents.Intent{Origin: "resolved"}, cis[commits[1].Hash.String()])
+}
+
+func TestDifferImpl_GetMissingCommits_MergeCommits(t *testing.T) {
+	repo, commits := newMergeRepo(t)
+
+	ctx := context.Background()
+
+	repoName := gh.RepoName{Owner: "owner", Repo: "repo"}
+
+	cases := []struct {
+		mode       string
+		downstream []string
+		expected   []string
+	}{
+		{mode: config.MergeCommitsAll, expected: []string{"merge", "m1", "s1", "s2"}},
+		{mode: config.MergeCommitsFirstParent, expected: []string{"merge", "m1"}},
+		{mode: config.MergeCommitsPR, expected: []string{"merge", "m1"}},
+		{mode: config.MergeCommitsPR, downstream: []string{"s1"}, expected: []string{"merge", "m1"}},
+		{mode: config.MergeCommitsPR, downstream: []string{"s1", "s2"}, expected: []string{"m1"}},
+		{mode: config.MergeCommitsSkip, expected: []string{"m1", "s1", "s2"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.mode, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			helper := NewMockHelper(ctrl)
+			ig := intents.NewMockGetter(ctrl)
+
+			dsMainRef := plumbing.NewHashReference(plumbing.NewBranchReferenceName("ds-main"), commits["c0"].Hash)
+			usRef := plumbing.NewHashReference(plumbing.NewRemoteReferenceName("gs-upstream", "main"), commits["merge"].Hash)
+
+			cis := intents.CommitIntents{commits["c0"].Hash.String(): {Origin: "commit from log"}}
+
+			for _, name := range c.downstream {
+				cis[commits[name].Hash.String()] = intents.Intent{Origin: "commit from log"}
+			}
+
+			gomock.InOrder(
+				helper.EXPECT().GetBranchRef(ctx, "ds-main").Return(dsMainRef, nil),
+				ig.EXPECT().FromLocalGitRepo(ctx, repo, commits["c0"].Hash, nil).Return(cis, nil),
+				ig.EXPECT().FromGitHubIssues(ctx, &repoName),
+				ig.EXPECT().FromSkipList(ctx, repo, commits["c0"].Hash),
+				helper.EXPECT().RecreateRemote(ctx, "gs-upstream", "remote-url"),
+				helper.EXPECT().GetRemoteRef(ctx, "gs-upstream", "main").Return(usRef, nil),
+			)
+
+			usCfg := config.Upstream{MergeCommits: c.mode, Ref: "main", URL: "remote-url"}
+
+			missing, _, err := NewDiffer(helper, ig, logr.Discard()).GetMissingCommits(ctx, repo, &repoName, config.Diff{}, "ds-main", usCfg)
+			require.NoError(t, err)
+
+			expected := make([]plumbing.Hash, 0, len(c.expected))
+
+			for _, name := range c.expected {
+				expected = append(expected, commits[name].Hash)
+			}
+
+			assert.ElementsMatch(t, expected, hashes(missing))
+		})
+	}
+}
+
+func TestDifferImpl_GetMissingCommits_SinceRef(t *testing.T) {
+	repo, commits := newMergeRepo(t)
+
+	_, err := repo.CreateTag("v1.0.0", commits["s1"].Hash, nil)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	repoName := gh.RepoName{Owner: "owner", Repo: "repo"}
+
+	cases := map[string]struct {
+		expected []string
+		mode     string
+		sinceRef string
+	}{
+		"sha":              {sinceRef: commits["m1"].Hash.String(), expected: []string{"merge", "s1", "s2"}},
+		"tag":              {sinceRef: "v1.0.0", expected: []string{"merge", "m1", "s2"}},
+		"first-parent sha": {mode: config.MergeCommitsFirstParent, sinceRef: commits["m1"].Hash.String(), expected: []string{"merge"}},
+		"first-parent tag": {mode: config.MergeCommitsFirstParent, sinceRef: "v1.0.0", expected: []string{"merge", "m1"}},
+		"side branch tip":  {sinceRef: commits["s2"].Hash.String(), expected: []string{"merge", "m1"}},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			helper := NewMockHelper(ctrl)
+			ig := intents.NewMockGetter(ctrl)
+
+			dsMainRef := plumbing.NewHashReference(plumbing.NewBranchReferenceName("ds-main"), commits["c0"].Hash)
+			usRef := plumbing.NewHashReference(plumbing.NewRemoteReferenceName("gs-upstream", "main"), commits["merge"].Hash)
+
+			gomock.InOrder(
+				helper.EXPECT().GetBranchRef(ctx, "ds-main").Return(dsMainRef, nil),
+				ig.EXPECT().FromLocalGitRepo(ctx, repo, commits["c0"].Hash, nil),
+				ig.EXPECT().FromGitHubIssues(ctx, &repoName),
+				ig.EXPECT().FromSkipList(ctx, repo, commits["c0"].Hash),
+				helper.EXPECT().RecreateRemote(ctx, "gs-upstream", "remote-url"),
+				helper.EXPECT().GetRemoteRef(ctx, "gs-upstream", "main").Return(usRef, nil),
+			)
+
+			mode := c.mode
+
+			if mode == "" {
+				mode = config.MergeCommitsAll
+			}
+
+			usCfg := config.Upstream{MergeCommits: mode, Ref: "main", URL: "remote-url"}
+
+			missing, _, err := NewDiffer(helper, ig, logr.Discard()).
+				GetMissingCommits(ctx, repo, &repoName, config.Diff{SinceRef: c.sinceRef}, "ds-main", usCfg)
+			require.NoError(t, err)
+
+			expected := make([]plumbing.Hash, 0, len(c.expected))
+
+			for _, name := range c.expected {
+				expected = append(expected, commits[name].Hash)
+			}
+
+			assert.ElementsMatch(t, expected, hashes(missing))
+		})
+	}
+
+	t.Run("unknown ref", func(t *testing.T) {
+		_, err := NewDiffer(nil, nil, logr.Discard()).resolveSinceRef(repo, "v2.0.0")
+		assert.ErrorContains(t, err, `could not resolve since_ref "v2.0.0"`)
+	})
 }
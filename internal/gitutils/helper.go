@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
@@ -16,11 +17,16 @@ import (
 //go:generate mockgen -source=helper.go -package=gitutils -destination=mock_helper.go
 
 type Helper interface {
+	FetchPullRequestHeadContext(ctx context.Context, remoteName string, number int) (*plumbing.Reference, error)
 	FetchRemoteContext(ctx context.Context, remoteName, branchName string) error
+	FetchRemoteTagsContext(ctx context.Context, remoteName string) ([]*plumbing.Reference, error)
 	GetBranchRef(ctx context.Context, branchName string) (*plumbing.Reference, error)
 	GetRemoteRef(ctx context.Context, remoteName, branchName string) (*plumbing.Reference, error)
+	PushBranchContextWithAuth(ctx context.Context, token, branchName string) error
 	PushContextWithAuth(ctx context.Context, token string) error
+	PushTagContextWithAuth(ctx context.Context, token, tagName string) error
 	RecreateRemote(ctx context.Context, remoteNAme, remoteURL string) (*git.Remote, error)
+	ResetBranchToRemote(ctx context.Context, remoteName, branchName string) error
 }
 
 type HelperImpl struct {
@@ -32,6 +38,35 @@ func NewHelper(repo *git.Repository, logger logr.Logger) Helper {
 	return &HelperImpl{repo: repo, logger: logger}
 }
 
+// FetchPullRequestHeadContext fetches the head of GitHub pull request number from remoteName to
+// refs/remotes/<remoteName>/pull/<number>.
+func (h *HelperImpl) FetchPullRequestHeadContext(ctx context.Context, remoteName string, number int) (*plumbing.Reference, error) {
+	remote, err := h.repo.Remote(remoteName)
+	if err != nil {
+		return nil, fmt.Errorf("could not find remote %s: %v", remoteName, err)
+	}
+
+	localRef := plumbing.NewRemoteReferenceName(remoteName, fmt.Sprintf("pull/%d", number))
+
+	fo := git.FetchOptions{
+		RefSpecs: []config.RefSpec{
+			config.RefSpec(fmt.Sprintf("+refs/pull/%d/head:%s", number, localRef)),
+		},
+		RemoteName: remoteName,
+	}
+
+	if err := remote.FetchContext(ctx, &fo); err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return nil, fmt.Errorf("could not fetch PR %d from remote %s: %v", number, remoteName, err)
+	}
+
+	ref, err := h.repo.Reference(localRef, true)
+	if err != nil {
+		return nil, fmt.Errorf("could not get the reference for PR %d: %v", number, err)
+	}
+
+	return ref, nil
+}
+
 func (h *HelperImpl) FetchRemoteContext(ctx context.Context, remoteName, branchName string) error {
 	remote, err := h.repo.Remote(remoteName)
 	if err != nil {
@@ -54,6 +89,49 @@ func (h *HelperImpl) FetchRemoteContext(ctx context.Context, remoteName, branchN
 	return nil
 }
 
+// FetchRemoteTagsContext fetches the tags of remoteName to refs/remotes/<remoteName>/tags, so that they do not collide
+// with local tags, and returns them.
+func (h *HelperImpl) FetchRemoteTagsContext(ctx context.Context, remoteName string) ([]*plumbing.Reference, error) {
+	remote, err := h.repo.Remote(remoteName)
+	if err != nil {
+		return nil, fmt.Errorf("could not find remote %s: %v", remoteName, err)
+	}
+
+	prefix := RemoteTagsPrefix(remoteName)
+
+	fo := git.FetchOptions{
+		RefSpecs: []config.RefSpec{
+			config.RefSpec("+refs/tags/*:" + prefix + "*"),
+		},
+		RemoteName: remoteName,
+		Tags:       git.NoTags,
+	}
+
+	if err := remote.FetchContext(ctx, &fo); err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return nil, fmt.Errorf("could not fetch the tags of remote %s: %v", remoteName, err)
+	}
+
+	iter, err := h.repo.References()
+	if err != nil {
+		return nil, fmt.Errorf("could not list references: %v", err)
+	}
+
+	refs := make([]*plumbing.Reference, 0)
+
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		if strings.HasPrefix(ref.Name().String(), prefix) {
+			refs = append(refs, ref)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not list references: %v", err)
+	}
+
+	return refs, nil
+}
+
 func (h *HelperImpl) GetBranchRef(ctx context.Context, branchName string) (*plumbing.Reference, error) {
 	return h.repo.Reference(plumbing.NewBranchReferenceName(branchName), true)
 }
@@ -73,6 +151,19 @@ func (h *HelperImpl) GetRemoteRef(ctx context.Context, remoteName, branchName st
 	return ref, nil
 }
 
+func (h *HelperImpl) PushBranchContextWithAuth(ctx context.Context, token, branchName string) error {
+	ref := plumbing.NewBranchReferenceName(branchName)
+
+	po := git.PushOptions{
+		Auth: AuthFromToken(token),
+		RefSpecs: []config.RefSpec{
+			config.RefSpec(fmt.Sprintf("+%[1]s:%[1]s", ref)),
+		},
+	}
+
+	return h.repo.PushContext(ctx, &po)
+}
+
 func (h *HelperImpl) PushContextWithAuth(ctx context.Context, token string) error {
 	po := git.PushOptions{
 		Auth:  AuthFromToken(token),
@@ -82,6 +173,19 @@ func (h *HelperImpl) PushContextWithAuth(ctx context.Context, token string) erro
 	return h.repo.PushContext(ctx, &po)
 }
 
+func (h *HelperImpl) PushTagContextWithAuth(ctx context.Context, token, tagName string) error {
+	ref := plumbing.NewTagReferenceName(tagName)
+
+	po := git.PushOptions{
+		Auth: AuthFromToken(token),
+		RefSpecs: []config.RefSpec{
+			config.RefSpec(fmt.Sprintf("%[1]s:%[1]s", ref)),
+		},
+	}
+
+	return h.repo.PushContext(ctx, &po)
+}
+
 func (h *HelperImpl) RecreateRemote(ctx context.Context, remoteName, remoteURL string) (*git.Remote, error) {
 	cfg, err := h.repo.Config()
 	if err != nil {
@@ -107,6 +211,49 @@ func (h *HelperImpl) RecreateRemote(ctx context.Context, remoteName, remoteURL s
 	return h.repo.CreateRemote(&rc)
 }
 
+// ResetBranchToRemote fetches branchName from remoteName, checks it out and hard-resets it to the fetched commit,
+// discarding any local commit or change.
+func (h *HelperImpl) ResetBranchToRemote(ctx context.Context, remoteName, branchName string) error {
+	remoteRef, err := h.GetRemoteRef(ctx, remoteName, branchName)
+	if err != nil {
+		return err
+	}
+
+	wt, err := h.repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("could not get the worktree: %v", err)
+	}
+
+	branchRef := plumbing.NewBranchReferenceName(branchName)
+
+	co := git.CheckoutOptions{
+		Branch: branchRef,
+		Force:  true,
+	}
+
+	if _, err = h.repo.Reference(branchRef, false); errors.Is(err, plumbing.ErrReferenceNotFound) {
+		co.Create = true
+		co.Hash = remoteRef.Hash()
+	}
+
+	if err = wt.Checkout(&co); err != nil {
+		return fmt.Errorf("could not checkout branch %s: %v", branchName, err)
+	}
+
+	if err = wt.Reset(&git.ResetOptions{Commit: remoteRef.Hash(), Mode: git.HardReset}); err != nil {
+		return fmt.Errorf("could not reset branch %s to %s: %v", branchName, remoteRef.Hash(), err)
+	}
+
+	h.logger.Info("Reset branch to its remote counterpart", "branch", branchName, "remote", remoteName, "sha", remoteRef.Hash())
+
+	return nil
+}
+
+// RemoteTagsPrefix is the prefix of the references to which FetchRemoteTagsContext fetches the tags of remoteName.
+func RemoteTagsPrefix(remoteName string) string {
+	return "refs/remotes/" + remoteName + "/tags/"
+}
+
 func AuthFromToken(token string) transport.AuthMethod {
 	return &http.BasicAuth{Username: token, Password: token}
 }
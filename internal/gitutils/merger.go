@@ -0,0 +1,131 @@
+package gitutils
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-logr/logr"
+	"github.com/rh-ecosystem-edge/gitstream/internal/config"
+	"github.com/rh-ecosystem-edge/gitstream/internal/merge"
+)
+
+//go:generate mockgen -source=merger.go -package=gitutils -destination=mock_merger.go
+
+// Merger applies the changes made by commit since its merge base with HEAD to the worktree and the index, like
+// git merge --no-commit. The merge is not recorded: committing it is up to the caller.
+// If the changes conflict with HEAD, nothing is written and a *merge.ConflictError is returned.
+type Merger interface {
+	Merge(ctx context.Context, logger logr.Logger, repo *git.Repository, repoPath string, commit *object.Commit) error
+}
+
+type MergerImpl struct {
+	committer config.Committer
+	executor  Executor
+}
+
+// NewMerger returns a Merger that merges commits with the git binary. git requires an identity even if the merge is not
+// committed: committer is used if its name and email are set. Otherwise, git reads them from its configuration.
+func NewMerger(committer config.Committer) *MergerImpl {
+	return &MergerImpl{committer: committer, executor: defaultExecutor}
+}
+
+func (m *MergerImpl) git(ctx context.Context, logger logr.Logger, repoPath string, args ...string) error {
+	if m.committer.Name != "" && m.committer.Email != "" {
+		args = append([]string{"-c", "user.name=" + m.committer.Name, "-c", "user.email=" + m.committer.Email}, args...)
+	}
+
+	return m.executor.RunCommand(ctx, logger, "git", repoPath, args...)
+}
+
+func (m *MergerImpl) Merge(ctx context.Context, logger logr.Logger, repo *git.Repository, repoPath string, commit *object.Commit) error {
+	mergeErr := m.git(ctx, logger, repoPath, "merge", "--no-commit", "--no-ff", commit.Hash.String())
+	if mergeErr == nil {
+		// The caller commits the merge with its own options; leave the index and the worktree as they are.
+		if err := m.git(ctx, logger, repoPath, "merge", "--quit"); err != nil {
+			return fmt.Errorf("error running git: %w", err)
+		}
+
+		return nil
+	}
+
+	conflicts, err := unmergedPaths(repo)
+	if err != nil {
+		return err
+	}
+
+	if len(conflicts) == 0 {
+		return fmt.Errorf("error running git: %w", mergeErr)
+	}
+
+	if err = m.git(ctx, logger, repoPath, "merge", "--abort"); err != nil {
+		return fmt.Errorf("could not abort the merge: %w", err)
+	}
+
+	return &merge.ConflictError{Conflicts: conflicts}
+}
+
+// unmergedPaths returns a conflict for each path that has unmerged entries in the index.
+func unmergedPaths(repo *git.Repository) ([]merge.Conflict, error) {
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, fmt.Errorf("could not read the index: %v", err)
+	}
+
+	stages := make(map[string]map[index.Stage]bool)
+
+	for _, e := range idx.Entries {
+		// Merged entries have stage 0; go-git's index.Merged is wrongly equal to index.AncestorMode.
+		if e.Stage == 0 {
+			continue
+		}
+
+		if stages[e.Name] == nil {
+			stages[e.Name] = make(map[index.Stage]bool)
+		}
+
+		stages[e.Name][e.Stage] = true
+	}
+
+	conflicts := make([]merge.Conflict, 0, len(stages))
+
+	for path, s := range stages {
+		var reason string
+
+		switch {
+		case s[index.AncestorMode] && s[index.OurMode] && s[index.TheirMode]:
+			reason = "content modified upstream and downstream"
+		case s[index.OurMode] && s[index.TheirMode]:
+			reason = "added upstream and downstream with different contents"
+		case s[index.AncestorMode] && s[index.OurMode]:
+			reason = "deleted upstream and modified downstream"
+		case s[index.AncestorMode] && s[index.TheirMode]:
+			reason = "modified upstream and deleted downstream"
+		default:
+			reason = "unmerged"
+		}
+
+		conflicts = append(conflicts, merge.Conflict{Path: path, Reason: reason})
+	}
+
+	sort.Slice(conflicts, func(i, j int) bool {
+		return conflicts[i].Path < conflicts[j].Path
+	})
+
+	return conflicts, nil
+}
+
+type nativeMerger struct{}
+
+// NewNativeMerger returns a Merger that merges commits in-process with go-git. Only one merge base is used, and
+// submodule changes are reported as conflicts.
+func NewNativeMerger() Merger {
+	return nativeMerger{}
+}
+
+func (nativeMerger) Merge(ctx context.Context, logger logr.Logger, repo *git.Repository, _ string, commit *object.Commit) error {
+	return NativeMerge(ctx, logger, repo, commit)
+}
@@ -0,0 +1,195 @@
+package gitutils
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-logr/logr"
+	"github.com/rh-ecosystem-edge/gitstream/internal/config"
+	"github.com/rh-ecosystem-edge/gitstream/internal/merge"
+	"github.com/rh-ecosystem-edge/gitstream/internal/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergerImpl(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+
+	ctx := context.Background()
+
+	committer := config.Committer{Email: "bot@example.com", Name: "Some Bot"}
+
+	newRepo := func(t *testing.T) (*git.Repository, *git.Worktree, string) {
+		t.Helper()
+
+		repoPath := t.TempDir()
+
+		repo, err := git.PlainInit(repoPath, false)
+		require.NoError(t, err)
+
+		wt, err := repo.Worktree()
+		require.NoError(t, err)
+
+		return repo, wt, repoPath
+	}
+
+	commitWithParents := func(t *testing.T, wt *git.Worktree, msg string, parents ...plumbing.Hash) plumbing.Hash {
+		t.Helper()
+
+		sha, err := wt.Commit(msg, &git.CommitOptions{
+			AllowEmptyCommits: true,
+			Author:            &object.Signature{Name: "Unit tests", Email: "unit.tests@example.com", When: time.Now()},
+			Parents:           parents,
+		})
+		require.NoError(t, err)
+
+		return sha
+	}
+
+	checkout := func(t *testing.T, wt *git.Worktree, branch string, hash plumbing.Hash) {
+		t.Helper()
+
+		require.NoError(
+			t,
+			wt.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName(branch), Create: true, Hash: hash, Force: true}),
+		)
+	}
+
+	assertFiles := func(t *testing.T, wt *git.Worktree, files map[string]string) {
+		t.Helper()
+
+		for path, expected := range files {
+			b, err := util.ReadFile(wt.Filesystem, path)
+			require.NoError(t, err)
+			assert.Equal(t, expected, string(b))
+		}
+	}
+
+	// crissCross returns a downstream and an upstream commit with two merge bases. Upstream changes f.txt and h.txt
+	// after merging both bases, while downstream only merges them: merging from either base alone conflicts.
+	crissCross := func(t *testing.T, repo *git.Repository, wt *git.Worktree) (plumbing.Hash, *object.Commit) {
+		t.Helper()
+
+		a, x, y, z, w := "a\n", "x\n", "y\n", "z\n", "w\n"
+
+		base, _ := test.AddCommit(t, repo, wt.Filesystem, "base", map[string]*string{"f.txt": &a, "h.txt": &a})
+		x1, _ := test.AddCommit(t, repo, wt.Filesystem, "x1", map[string]*string{"f.txt": &x})
+
+		checkout(t, wt, "y", base)
+		y1, _ := test.AddCommit(t, repo, wt.Filesystem, "y1", map[string]*string{"h.txt": &y})
+
+		require.NoError(t, util.WriteFile(wt.Filesystem, "f.txt", []byte(x), 0644))
+		_, err := wt.Add("f.txt")
+		require.NoError(t, err)
+
+		commitWithParents(t, wt, "upstream merge", y1, x1)
+		_, upstream := test.AddCommit(t, repo, wt.Filesystem, "upstream", map[string]*string{"f.txt": &z, "h.txt": &w})
+
+		checkout(t, wt, "downstream", y1)
+
+		require.NoError(t, util.WriteFile(wt.Filesystem, "f.txt", []byte(x), 0644))
+		_, err = wt.Add("f.txt")
+		require.NoError(t, err)
+
+		downstream := commitWithParents(t, wt, "downstream merge", x1, y1)
+
+		return downstream, upstream
+	}
+
+	t.Run("criss-cross merges use all merge bases", func(t *testing.T) {
+		repo, wt, repoPath := newRepo(t)
+
+		_, upstream := crissCross(t, repo, wt)
+
+		head, err := repo.Head()
+		require.NoError(t, err)
+
+		headCommit, err := repo.CommitObject(head.Hash())
+		require.NoError(t, err)
+
+		mbs, err := headCommit.MergeBase(upstream)
+		require.NoError(t, err)
+		require.Len(t, mbs, 2)
+
+		require.NoError(t, NewMerger(committer).Merge(ctx, logr.Discard(), repo, repoPath, upstream))
+
+		assertFiles(t, wt, map[string]string{"f.txt": "z\n", "h.txt": "w\n"})
+
+		// The merge is left to the caller to commit.
+		assert.NoFileExists(t, filepath.Join(repoPath, ".git", "MERGE_HEAD"))
+
+		status, err := wt.Status()
+		require.NoError(t, err)
+		assert.Equal(t, git.Modified, status.File("f.txt").Staging)
+		assert.Equal(t, git.Modified, status.File("h.txt").Staging)
+	})
+
+	t.Run("submodule changes are merged", func(t *testing.T) {
+		repo, wt, repoPath := newRepo(t)
+
+		one := "one\n"
+
+		base, _ := test.AddCommit(t, repo, wt.Filesystem, "base", map[string]*string{"one.txt": &one})
+
+		// Upstream adds a submodule, which is not checked out.
+		idx, err := repo.Storer.Index()
+		require.NoError(t, err)
+
+		subSHA := plumbing.NewHash("0123456789abcdef0123456789abcdef01234567")
+
+		idx.Entries = append(idx.Entries, &index.Entry{Hash: subSHA, Mode: filemode.Submodule, Name: "sub"})
+		require.NoError(t, repo.Storer.SetIndex(idx))
+
+		gitmodules := "[submodule \"sub\"]\n\tpath = sub\n\turl = https://example.com/sub.git\n"
+
+		_, upstream := test.AddCommit(t, repo, wt.Filesystem, "add submodule", map[string]*string{".gitmodules": &gitmodules})
+
+		checkout(t, wt, "downstream", base)
+		test.AddCommit(t, repo, wt.Filesystem, "downstream", map[string]*string{"two.txt": &one})
+
+		require.NoError(t, NewMerger(committer).Merge(ctx, logr.Discard(), repo, repoPath, upstream))
+
+		idx, err = repo.Storer.Index()
+		require.NoError(t, err)
+
+		e, err := idx.Entry("sub")
+		require.NoError(t, err)
+		assert.Equal(t, filemode.Submodule, e.Mode)
+		assert.Equal(t, subSHA, e.Hash)
+	})
+
+	t.Run("conflicts are reported", func(t *testing.T) {
+		repo, wt, repoPath := newRepo(t)
+
+		one, upper, lower := "one\n", "ONE\n", "uno\n"
+
+		base, _ := test.AddCommit(t, repo, wt.Filesystem, "base", map[string]*string{"one.txt": &one})
+		_, upstream := test.AddCommit(t, repo, wt.Filesystem, "upstream", map[string]*string{"one.txt": &upper, "new.txt": &one})
+
+		checkout(t, wt, "downstream", base)
+		test.AddCommit(t, repo, wt.Filesystem, "downstream", map[string]*string{"one.txt": &lower})
+
+		err := NewMerger(committer).Merge(ctx, logr.Discard(), repo, repoPath, upstream)
+
+		ce := &merge.ConflictError{}
+		require.ErrorAs(t, err, &ce)
+		assert.Equal(t, []merge.Conflict{{Path: "one.txt", Reason: "content modified upstream and downstream"}}, ce.Conflicts)
+
+		// The merge is aborted.
+		status, err := wt.Status()
+		require.NoError(t, err)
+		assert.True(t, status.IsClean())
+		assert.NoFileExists(t, filepath.Join(repoPath, ".git", "MERGE_HEAD"))
+	})
+}
@@ -0,0 +1,105 @@
+package gitutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// MergedCommits returns the commits that merge brought into its first parent: those reachable from its other parents,
+// but not from its first parent. For a merged PR, those are the commits of the PR. They are returned oldest first.
+func MergedCommits(merge *object.Commit) ([]*object.Commit, error) {
+	if merge.NumParents() < 2 {
+		return nil, fmt.Errorf("%s is not a merge commit", merge.Hash)
+	}
+
+	first, err := merge.Parent(0)
+	if err != nil {
+		return nil, fmt.Errorf("could not get the first parent of %s: %v", merge.Hash, err)
+	}
+
+	merged := make([]*object.Commit, 0)
+	seen := make(map[plumbing.Hash]bool)
+
+	for i := 1; i < merge.NumParents(); i++ {
+		p, err := merge.Parent(i)
+		if err != nil {
+			return nil, fmt.Errorf("could not get parent %d of %s: %v", i, merge.Hash, err)
+		}
+
+		// The side branch may have merged the first parent's history after forking from it, so the commits to
+		// exclude are all those reachable from the first parent, not only the merge bases.
+		commits, err := CommitsBetween([]*object.Commit{first}, p)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range commits {
+			if !seen[c.Hash] {
+				seen[c.Hash] = true
+				merged = append(merged, c)
+			}
+		}
+	}
+
+	for i, j := 0, len(merged)-1; i < j; i, j = i+1, j-1 {
+		merged[i], merged[j] = merged[j], merged[i]
+	}
+
+	return merged, nil
+}
+
+// firstParentCommits returns the first-parent chain of from, newest first. If since is not nil, the walk stops at the
+// first commit committed before since. If within is not nil, the walk stops at the first commit not in within.
+func firstParentCommits(
+	ctx context.Context,
+	repo *git.Repository,
+	from plumbing.Hash,
+	since *time.Time,
+	within map[plumbing.Hash]bool,
+) ([]*object.Commit, error) {
+	c, err := repo.CommitObject(from)
+	if err != nil {
+		return nil, fmt.Errorf("could not get commit %s: %v", from, err)
+	}
+
+	commits := make([]*object.Commit, 0)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		if since != nil && c.Committer.When.Before(*since) {
+			break
+		}
+
+		if within != nil && !within[c.Hash] {
+			break
+		}
+
+		commits = append(commits, c)
+
+		if c.NumParents() == 0 {
+			break
+		}
+
+		if c, err = c.Parent(0); err != nil {
+			if errors.Is(err, plumbing.ErrObjectNotFound) {
+				// Shallow clones end with commits whose parents are missing.
+				break
+			}
+
+			return nil, fmt.Errorf("could not get the first parent of %s: %v", commits[len(commits)-1].Hash, err)
+		}
+	}
+
+	return commits, nil
+}
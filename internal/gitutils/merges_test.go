@@ -0,0 +1,215 @@
+package gitutils
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-logr/logr"
+	"github.com/rh-ecosystem-edge/gitstream/internal/config"
+	"github.com/rh-ecosystem-edge/gitstream/internal/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMergeRepo creates the following history, where M merges the side branch into main:
+//
+//	c0 -- m1 ------ M
+//	  \            /
+//	   s1 -- s2 --
+func newMergeRepo(t *testing.T) (*git.Repository, map[string]*object.Commit) {
+	t.Helper()
+
+	repo := test.NewRepo(t)
+
+	_, c0 := test.AddEmptyCommit(t, repo, "c0")
+	_, s1 := test.AddEmptyCommit(t, repo, "s1")
+	_, s2 := test.AddEmptyCommit(t, repo, "s2")
+
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+
+	head, err := repo.Head()
+	require.NoError(t, err)
+
+	require.NoError(t, wt.Reset(&git.ResetOptions{Commit: c0.Hash, Mode: git.HardReset}))
+	require.NoError(t, repo.Storer.SetReference(plumbing.NewHashReference(head.Name(), c0.Hash)))
+
+	_, m1 := test.AddEmptyCommit(t, repo, "m1")
+
+	sha, err := wt.Commit("Merge side", &git.CommitOptions{
+		AllowEmptyCommits: true,
+		Author:            &object.Signature{Name: "Unit tests", Email: "unit.tests@example.com", When: time.Now()},
+		Parents:           []plumbing.Hash{m1.Hash, s2.Hash},
+	})
+	require.NoError(t, err)
+
+	merge, err := repo.CommitObject(sha)
+	require.NoError(t, err)
+
+	return repo, map[string]*object.Commit{"c0": c0, "m1": m1, "s1": s1, "s2": s2, "merge": merge}
+}
+
+// commitWithParents creates an empty commit with the given parents and no file.
+func commitWithParents(t *testing.T, repo *git.Repository, msg string, parents ...*object.Commit) *object.Commit {
+	t.Helper()
+
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+
+	parentHashes := make([]plumbing.Hash, 0, len(parents))
+
+	for _, p := range parents {
+		parentHashes = append(parentHashes, p.Hash)
+	}
+
+	sha, err := wt.Commit(msg, &git.CommitOptions{
+		AllowEmptyCommits: true,
+		Author:            &object.Signature{Name: "Unit tests", Email: "unit.tests@example.com", When: time.Now()},
+		Parents:           parentHashes,
+	})
+	require.NoError(t, err)
+
+	c, err := repo.CommitObject(sha)
+	require.NoError(t, err)
+
+	return c
+}
+
+func hashes(commits []*object.Commit) []plumbing.Hash {
+	res := make([]plumbing.Hash, 0, len(commits))
+
+	for _, c := range commits {
+		res = append(res, c.Hash)
+	}
+
+	return res
+}
+
+func TestMergedCommits(t *testing.T) {
+	_, commits := newMergeRepo(t)
+
+	merged, err := MergedCommits(commits["merge"])
+	require.NoError(t, err)
+	assert.Equal(t, []plumbing.Hash{commits["s1"].Hash, commits["s2"].Hash}, hashes(merged))
+
+	_, err = MergedCommits(commits["m1"])
+	assert.EqualError(t, err, commits["m1"].Hash.String()+" is not a merge commit")
+}
+
+func TestMergedCommits_SideBranchMergedMain(t *testing.T) {
+	// a0 -- c0 -- m1 ----------- M
+	//         \     \           /
+	//          s1 -- u -- s2 --
+	repo := test.NewRepo(t)
+
+	a0 := commitWithParents(t, repo, "a0")
+	c0 := commitWithParents(t, repo, "c0", a0)
+	m1 := commitWithParents(t, repo, "m1", c0)
+	s1 := commitWithParents(t, repo, "s1", c0)
+	u := commitWithParents(t, repo, "Merge main into side", s1, m1)
+	s2 := commitWithParents(t, repo, "s2", u)
+	merge := commitWithParents(t, repo, "Merge side", m1, s2)
+
+	merged, err := MergedCommits(merge)
+	require.NoError(t, err)
+	assert.Equal(t, []plumbing.Hash{s1.Hash, u.Hash, s2.Hash}, hashes(merged))
+}
+
+func TestCommitsBetween(t *testing.T) {
+	repo, commits := newMergeRepo(t)
+
+	res, err := CommitsBetween([]*object.Commit{commits["s1"]}, commits["merge"])
+	require.NoError(t, err)
+	assert.Equal(t, []plumbing.Hash{commits["merge"].Hash, commits["m1"].Hash, commits["s2"].Hash}, hashes(res))
+
+	res, err = CommitsBetween([]*object.Commit{commits["merge"]}, commits["s2"])
+	require.NoError(t, err)
+	assert.Empty(t, res)
+
+	head, err := repo.Head()
+	require.NoError(t, err)
+
+	headCommit, err := repo.CommitObject(head.Hash())
+	require.NoError(t, err)
+
+	res, err = CommitsBetween(nil, headCommit)
+	require.NoError(t, err)
+	assert.Len(t, res, 5)
+}
+
+func TestFirstParentCommits(t *testing.T) {
+	repo, commits := newMergeRepo(t)
+
+	ctx := context.Background()
+
+	res, err := firstParentCommits(ctx, repo, commits["merge"].Hash, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		[]plumbing.Hash{commits["merge"].Hash, commits["m1"].Hash, commits["c0"].Hash},
+		hashes(res),
+	)
+
+	future := time.Now().Add(time.Hour)
+
+	res, err = firstParentCommits(ctx, repo, commits["merge"].Hash, &future, nil)
+	require.NoError(t, err)
+	assert.Empty(t, res)
+}
+
+func TestCherryPickerImpl_Run_MergedCommits(t *testing.T) {
+	const markup = "Some-Markup"
+
+	repo, commits := newMergeRepo(t)
+
+	ctx := context.Background()
+
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+
+	head, err := repo.Head()
+	require.NoError(t, err)
+
+	// Downstream is at m1.
+	require.NoError(t, repo.Storer.SetReference(plumbing.NewHashReference(head.Name(), commits["m1"].Hash)))
+	require.NoError(t, wt.Reset(&git.ResetOptions{Commit: commits["m1"].Hash, Mode: git.HardReset}))
+
+	applied := make([]plumbing.Hash, 0)
+
+	cp := NewCherryPicker(CommitOptions{Markup: markup, MergeCommits: config.MergeCommitsPR}, nil, logr.Discard())
+	cp.apply = func(_ context.Context, _ logr.Logger, _ *git.Repository, _ string, commit *object.Commit) error {
+		applied = append(applied, commit.Hash)
+
+		name := strings.TrimSpace(commit.Message)
+
+		if err := util.WriteFile(wt.Filesystem, name, []byte(name), 0644); err != nil {
+			return err
+		}
+
+		_, err := wt.Add(name)
+
+		return err
+	}
+
+	_, err = cp.Run(ctx, repo, "", commits["merge"])
+	require.NoError(t, err)
+
+	assert.Equal(t, []plumbing.Hash{commits["s1"].Hash, commits["s2"].Hash}, applied)
+
+	head, err = repo.Head()
+	require.NoError(t, err)
+
+	res, err := firstParentCommits(ctx, repo, head.Hash(), nil, nil)
+	require.NoError(t, err)
+	require.Len(t, res, 4)
+
+	assert.Equal(t, "s2\n\n"+markup+": "+commits["s2"].Hash.String(), res[0].Message)
+	assert.Equal(t, "s1\n\n"+markup+": "+commits["s1"].Hash.String(), res[1].Message)
+	assert.Equal(t, commits["m1"].Hash, res[2].Hash)
+}
@@ -0,0 +1,67 @@
+package gitutils
+
+import (
+	"regexp"
+	"strings"
+)
+
+var trailerRegexp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*:\s`)
+
+// splitTrailers splits msg into its body and the lines of its trailer block. The trailer block is the last paragraph
+// of msg if it is not the subject and if all its lines are trailers or continuation lines, as git interpret-trailers
+// would parse it.
+func splitTrailers(msg string) (string, []string) {
+	msg = strings.TrimRight(msg, "\n")
+
+	i := strings.LastIndex(msg, "\n\n")
+	if i == -1 {
+		return msg, nil
+	}
+
+	lines := strings.Split(msg[i+2:], "\n")
+
+	for j, l := range lines {
+		if trailerRegexp.MatchString(l) {
+			continue
+		}
+
+		if j > 0 && (strings.HasPrefix(l, " ") || strings.HasPrefix(l, "\t")) {
+			continue
+		}
+
+		return msg, nil
+	}
+
+	return strings.TrimRight(msg[:i], "\n"), lines
+}
+
+// cherryPickMessage returns the message of the commit cherry-picked from an upstream commit with message msg.
+// trailers are appended to the trailer block of msg, unless a trailer with the same value already exists, so that
+// upstream trailers such as Co-authored-by keep being parsed as trailers.
+func cherryPickMessage(msg string, trailers ...string) string {
+	body, existing := splitTrailers(msg)
+
+	block := existing
+
+	for _, t := range trailers {
+		if !containsString(existing, t) {
+			block = append(block, t)
+		}
+	}
+
+	if len(block) == 0 {
+		return body
+	}
+
+	return body + "\n\n" + strings.Join(block, "\n")
+}
+
+func containsString(strs []string, s string) bool {
+	for _, str := range strs {
+		if str == s {
+			return true
+		}
+	}
+
+	return false
+}
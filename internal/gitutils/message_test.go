@@ -0,0 +1,84 @@
+package gitutils
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCherryPickMessage(t *testing.T) {
+	const (
+		markup  = "Upstream-Commit: abc"
+		signOff = "Signed-off-by: Bot <bot@example.com>"
+	)
+
+	cases := []struct {
+		name             string
+		msg              string
+		trailers         []string
+		expected         string
+		expectedTrailers string
+	}{
+		{
+			name:             "subject only",
+			msg:              "Some-Subject: with a colon\n",
+			trailers:         []string{markup},
+			expected:         "Some-Subject: with a colon\n\nUpstream-Commit: abc",
+			expectedTrailers: "Upstream-Commit: abc\n",
+		},
+		{
+			name:             "body without trailers",
+			msg:              "Subject\n\nSome body.\n",
+			trailers:         []string{signOff, markup},
+			expected:         "Subject\n\nSome body.\n\nSigned-off-by: Bot <bot@example.com>\nUpstream-Commit: abc",
+			expectedTrailers: "Signed-off-by: Bot <bot@example.com>\nUpstream-Commit: abc\n",
+		},
+		{
+			name:     "upstream trailers are kept in the trailer block",
+			msg:      "Subject\n\nSome body.\n\nCo-authored-by: Someone <someone@example.com>\nReviewed-by: Reviewer\n  on two lines\n",
+			trailers: []string{signOff, markup},
+			expected: "Subject\n\nSome body.\n\n" +
+				"Co-authored-by: Someone <someone@example.com>\nReviewed-by: Reviewer\n  on two lines\n" +
+				"Signed-off-by: Bot <bot@example.com>\nUpstream-Commit: abc",
+			expectedTrailers: "Co-authored-by: Someone <someone@example.com>\nReviewed-by: Reviewer on two lines\n" +
+				"Signed-off-by: Bot <bot@example.com>\nUpstream-Commit: abc\n",
+		},
+		{
+			name:             "existing identical trailers are not repeated",
+			msg:              "Subject\n\nSigned-off-by: Bot <bot@example.com>",
+			trailers:         []string{signOff, markup},
+			expected:         "Subject\n\nSigned-off-by: Bot <bot@example.com>\nUpstream-Commit: abc",
+			expectedTrailers: "Signed-off-by: Bot <bot@example.com>\nUpstream-Commit: abc\n",
+		},
+		{
+			name:             "no trailers",
+			msg:              "Subject\n\nSome body.\n",
+			expected:         "Subject\n\nSome body.",
+			expectedTrailers: "",
+		},
+		{
+			name:             "last paragraph is not a trailer block",
+			msg:              "Subject\n\nCo-authored-by: Someone <someone@example.com>\nbut this is prose.\n",
+			trailers:         []string{markup},
+			expected:         "Subject\n\nCo-authored-by: Someone <someone@example.com>\nbut this is prose.\n\nUpstream-Commit: abc",
+			expectedTrailers: "Upstream-Commit: abc\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			msg := cherryPickMessage(c.msg, c.trailers...)
+			assert.Equal(t, c.expected, msg)
+
+			cmd := exec.Command("git", "interpret-trailers", "--parse")
+			cmd.Stdin = strings.NewReader(msg)
+
+			out, err := cmd.Output()
+			require.NoError(t, err)
+			assert.Equal(t, c.expectedTrailers, string(out))
+		})
+	}
+}
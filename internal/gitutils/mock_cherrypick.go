@@ -12,6 +12,7 @@ import (
 	object "github.com/go-git/go-git/v5/plumbing/object"
 	logr "github.com/go-logr/logr"
 	gomock "github.com/golang/mock/gomock"
+	hooks "github.com/rh-ecosystem-edge/gitstream/internal/hooks"
 )
 
 // MockCherryPicker is a mock of CherryPicker interface.
@@ -38,11 +39,12 @@ func (m *MockCherryPicker) EXPECT() *MockCherryPickerMockRecorder {
 }
 
 // Run mocks base method.
-func (m *MockCherryPicker) Run(ctx context.Context, repo *git.Repository, repoPath string, commit *object.Commit) error {
+func (m *MockCherryPicker) Run(ctx context.Context, repo *git.Repository, repoPath string, commit *object.Commit) ([]hooks.Result, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Run", ctx, repo, repoPath, commit)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].([]hooks.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Run indicates an expected call of Run.
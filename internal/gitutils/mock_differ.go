@@ -7,9 +7,9 @@ package gitutils
 import (
 	context "context"
 	reflect "reflect"
-	time "time"
 
 	git "github.com/go-git/go-git/v5"
+	plumbing "github.com/go-git/go-git/v5/plumbing"
 	object "github.com/go-git/go-git/v5/plumbing/object"
 	gomock "github.com/golang/mock/gomock"
 	config "github.com/rh-ecosystem-edge/gitstream/internal/config"
@@ -40,16 +40,33 @@ func (m *MockDiffer) EXPECT() *MockDifferMockRecorder {
 }
 
 // GetMissingCommits mocks base method.
-func (m *MockDiffer) GetMissingCommits(ctx context.Context, repo *git.Repository, repoName *github.RepoName, since *time.Time, dsMainBranch string, upstreamConfig config.Upstream) ([]*object.Commit, error) {
+func (m *MockDiffer) GetMissingCommits(ctx context.Context, repo *git.Repository, repoName *github.RepoName, diffConfig config.Diff, dsMainBranch string, upstreamConfig config.Upstream) ([]*object.Commit, []UnresolvedIntent, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetMissingCommits", ctx, repo, repoName, since, dsMainBranch, upstreamConfig)
+	ret := m.ctrl.Call(m, "GetMissingCommits", ctx, repo, repoName, diffConfig, dsMainBranch, upstreamConfig)
 	ret0, _ := ret[0].([]*object.Commit)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret1, _ := ret[1].([]UnresolvedIntent)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
 }
 
 // GetMissingCommits indicates an expected call of GetMissingCommits.
-func (mr *MockDifferMockRecorder) GetMissingCommits(ctx, repo, repoName, since, dsMainBranch, upstreamConfig interface{}) *gomock.Call {
+func (mr *MockDifferMockRecorder) GetMissingCommits(ctx, repo, repoName, diffConfig, dsMainBranch, upstreamConfig interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMissingCommits", reflect.TypeOf((*MockDiffer)(nil).GetMissingCommits), ctx, repo, repoName, since, dsMainBranch, upstreamConfig)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMissingCommits", reflect.TypeOf((*MockDiffer)(nil).GetMissingCommits), ctx, repo, repoName, diffConfig, dsMainBranch, upstreamConfig)
+}
+
+// GetMissingCommitsFrom mocks base method.
+func (m *MockDiffer) GetMissingCommitsFrom(ctx context.Context, repo *git.Repository, repoName *github.RepoName, diffConfig config.Diff, dsMainBranch string, upstreamConfig config.Upstream, from plumbing.Hash) ([]*object.Commit, []UnresolvedIntent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMissingCommitsFrom", ctx, repo, repoName, diffConfig, dsMainBranch, upstreamConfig, from)
+	ret0, _ := ret[0].([]*object.Commit)
+	ret1, _ := ret[1].([]UnresolvedIntent)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetMissingCommitsFrom indicates an expected call of GetMissingCommitsFrom.
+func (mr *MockDifferMockRecorder) GetMissingCommitsFrom(ctx, repo, repoName, diffConfig, dsMainBranch, upstreamConfig, from interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMissingCommitsFrom", reflect.TypeOf((*MockDiffer)(nil).GetMissingCommitsFrom), ctx, repo, repoName, diffConfig, dsMainBranch, upstreamConfig, from)
 }
@@ -36,6 +36,21 @@ func (m *MockHelper) EXPECT() *MockHelperMockRecorder {
 	return m.recorder
 }
 
+// FetchPullRequestHeadContext mocks base method.
+func (m *MockHelper) FetchPullRequestHeadContext(ctx context.Context, remoteName string, number int) (*plumbing.Reference, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchPullRequestHeadContext", ctx, remoteName, number)
+	ret0, _ := ret[0].(*plumbing.Reference)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchPullRequestHeadContext indicates an expected call of FetchPullRequestHeadContext.
+func (mr *MockHelperMockRecorder) FetchPullRequestHeadContext(ctx, remoteName, number interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchPullRequestHeadContext", reflect.TypeOf((*MockHelper)(nil).FetchPullRequestHeadContext), ctx, remoteName, number)
+}
+
 // FetchRemoteContext mocks base method.
 func (m *MockHelper) FetchRemoteContext(ctx context.Context, remoteName, branchName string) error {
 	m.ctrl.T.Helper()
@@ -50,6 +65,21 @@ func (mr *MockHelperMockRecorder) FetchRemoteContext(ctx, remoteName, branchName
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchRemoteContext", reflect.TypeOf((*MockHelper)(nil).FetchRemoteContext), ctx, remoteName, branchName)
 }
 
+// FetchRemoteTagsContext mocks base method.
+func (m *MockHelper) FetchRemoteTagsContext(ctx context.Context, remoteName string) ([]*plumbing.Reference, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchRemoteTagsContext", ctx, remoteName)
+	ret0, _ := ret[0].([]*plumbing.Reference)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchRemoteTagsContext indicates an expected call of FetchRemoteTagsContext.
+func (mr *MockHelperMockRecorder) FetchRemoteTagsContext(ctx, remoteName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchRemoteTagsContext", reflect.TypeOf((*MockHelper)(nil).FetchRemoteTagsContext), ctx, remoteName)
+}
+
 // GetBranchRef mocks base method.
 func (m *MockHelper) GetBranchRef(ctx context.Context, branchName string) (*plumbing.Reference, error) {
 	m.ctrl.T.Helper()
@@ -80,6 +110,20 @@ func (mr *MockHelperMockRecorder) GetRemoteRef(ctx, remoteName, branchName inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRemoteRef", reflect.TypeOf((*MockHelper)(nil).GetRemoteRef), ctx, remoteName, branchName)
 }
 
+// PushBranchContextWithAuth mocks base method.
+func (m *MockHelper) PushBranchContextWithAuth(ctx context.Context, token, branchName string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PushBranchContextWithAuth", ctx, token, branchName)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PushBranchContextWithAuth indicates an expected call of PushBranchContextWithAuth.
+func (mr *MockHelperMockRecorder) PushBranchContextWithAuth(ctx, token, branchName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PushBranchContextWithAuth", reflect.TypeOf((*MockHelper)(nil).PushBranchContextWithAuth), ctx, token, branchName)
+}
+
 // PushContextWithAuth mocks base method.
 func (m *MockHelper) PushContextWithAuth(ctx context.Context, token string) error {
 	m.ctrl.T.Helper()
@@ -94,6 +138,20 @@ func (mr *MockHelperMockRecorder) PushContextWithAuth(ctx, token interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PushContextWithAuth", reflect.TypeOf((*MockHelper)(nil).PushContextWithAuth), ctx, token)
 }
 
+// PushTagContextWithAuth mocks base method.
+func (m *MockHelper) PushTagContextWithAuth(ctx context.Context, token, tagName string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PushTagContextWithAuth", ctx, token, tagName)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PushTagContextWithAuth indicates an expected call of PushTagContextWithAuth.
+func (mr *MockHelperMockRecorder) PushTagContextWithAuth(ctx, token, tagName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PushTagContextWithAuth", reflect.TypeOf((*MockHelper)(nil).PushTagContextWithAuth), ctx, token, tagName)
+}
+
 // RecreateRemote mocks base method.
 func (m *MockHelper) RecreateRemote(ctx context.Context, remoteNAme, remoteURL string) (*git.Remote, error) {
 	m.ctrl.T.Helper()
@@ -108,3 +166,17 @@ func (mr *MockHelperMockRecorder) RecreateRemote(ctx, remoteNAme, remoteURL inte
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecreateRemote", reflect.TypeOf((*MockHelper)(nil).RecreateRemote), ctx, remoteNAme, remoteURL)
 }
+
+// ResetBranchToRemote mocks base method.
+func (m *MockHelper) ResetBranchToRemote(ctx context.Context, remoteName, branchName string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetBranchToRemote", ctx, remoteName, branchName)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetBranchToRemote indicates an expected call of ResetBranchToRemote.
+func (mr *MockHelperMockRecorder) ResetBranchToRemote(ctx, remoteName, branchName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetBranchToRemote", reflect.TypeOf((*MockHelper)(nil).ResetBranchToRemote), ctx, remoteName, branchName)
+}
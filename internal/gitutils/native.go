@@ -0,0 +1,156 @@
+package gitutils
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-logr/logr"
+	"github.com/rh-ecosystem-edge/gitstream/internal/merge"
+)
+
+// nativeCherryPick applies the changes introduced by commit to the worktree and the index, like git cherry-pick -n.
+// For merge commits, changes are computed relative to the first parent, like git cherry-pick -m1.
+// If the changes conflict with HEAD, nothing is written and a *merge.ConflictError is returned.
+func nativeCherryPick(ctx context.Context, logger logr.Logger, repo *git.Repository, _ string, commit *object.Commit) error {
+	base := &object.Tree{}
+
+	if commit.NumParents() > 0 {
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return fmt.Errorf("could not get the first parent of %s: %v", commit.Hash, err)
+		}
+
+		if base, err = parent.Tree(); err != nil {
+			return fmt.Errorf("could not get the tree of %s: %v", parent.Hash, err)
+		}
+	}
+
+	theirs, err := commit.Tree()
+	if err != nil {
+		return fmt.Errorf("could not get the tree of %s: %v", commit.Hash, err)
+	}
+
+	return mergeIntoWorktree(ctx, logger, repo, base, theirs)
+}
+
+// NativeMerge applies the changes made by commit since its merge base with HEAD to the worktree and the index, like
+// git merge --no-commit. If there is no merge base, the changes are computed relative to an empty tree.
+// If the changes conflict with HEAD, nothing is written and a *merge.ConflictError is returned.
+func NativeMerge(ctx context.Context, logger logr.Logger, repo *git.Repository, commit *object.Commit) error {
+	head, err := repo.Head()
+	if err != nil {
+		return fmt.Errorf("could not get HEAD: %v", err)
+	}
+
+	headCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return fmt.Errorf("could not get the HEAD commit: %v", err)
+	}
+
+	mbs, err := headCommit.MergeBase(commit)
+	if err != nil {
+		return fmt.Errorf("could not get the merge base of HEAD and %s: %v", commit.Hash, err)
+	}
+
+	base := &object.Tree{}
+
+	// With several merge bases, using any of them may report more conflicts than git's recursive strategy.
+	if len(mbs) > 0 {
+		if base, err = mbs[0].Tree(); err != nil {
+			return fmt.Errorf("could not get the tree of %s: %v", mbs[0].Hash, err)
+		}
+	}
+
+	theirs, err := commit.Tree()
+	if err != nil {
+		return fmt.Errorf("could not get the tree of %s: %v", commit.Hash, err)
+	}
+
+	return mergeIntoWorktree(ctx, logger, repo, base, theirs)
+}
+
+// mergeIntoWorktree applies the changes from base to theirs to the worktree and the index, which must match HEAD.
+func mergeIntoWorktree(ctx context.Context, logger logr.Logger, repo *git.Repository, base, theirs *object.Tree) error {
+	head, err := repo.Head()
+	if err != nil {
+		return fmt.Errorf("could not get HEAD: %v", err)
+	}
+
+	headCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return fmt.Errorf("could not get the HEAD commit: %v", err)
+	}
+
+	ours, err := headCommit.Tree()
+	if err != nil {
+		return fmt.Errorf("could not get the tree of HEAD: %v", err)
+	}
+
+	res, err := merge.Trees(ctx, base, ours, theirs)
+	if err != nil {
+		return fmt.Errorf("could not merge trees: %v", err)
+	}
+
+	if len(res.Conflicts) > 0 {
+		return &merge.ConflictError{Conflicts: res.Conflicts}
+	}
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("could not get worktree: %v", err)
+	}
+
+	for _, c := range res.Changes {
+		logger.V(1).Info("Applying change", "path", c.Path, "delete", c.Delete)
+
+		if c.Delete {
+			if _, err = wt.Remove(c.Path); err != nil {
+				return fmt.Errorf("could not remove %s: %v", c.Path, err)
+			}
+
+			continue
+		}
+
+		if err = writeFile(wt.Filesystem, c); err != nil {
+			return err
+		}
+
+		if _, err = wt.Add(c.Path); err != nil {
+			return fmt.Errorf("could not add %s: %v", c.Path, err)
+		}
+	}
+
+	return nil
+}
+
+func writeFile(fs billy.Filesystem, c merge.Change) error {
+	if err := util.RemoveAll(fs, c.Path); err != nil {
+		return fmt.Errorf("could not remove %s: %v", c.Path, err)
+	}
+
+	if c.Mode == filemode.Symlink {
+		if err := fs.Symlink(string(c.Contents), c.Path); err != nil {
+			return fmt.Errorf("could not create symlink %s: %v", c.Path, err)
+		}
+
+		return nil
+	}
+
+	var perm os.FileMode = 0644
+
+	if c.Mode == filemode.Executable {
+		perm = 0755
+	}
+
+	if err := util.WriteFile(fs, c.Path, c.Contents, perm); err != nil {
+		return fmt.Errorf("could not write %s: %v", c.Path, err)
+	}
+
+	return nil
+}
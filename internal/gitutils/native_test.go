@@ -0,0 +1,222 @@
+package gitutils
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-logr/logr"
+	"github.com/rh-ecosystem-edge/gitstream/internal/config"
+	"github.com/rh-ecosystem-edge/gitstream/internal/merge"
+	"github.com/rh-ecosystem-edge/gitstream/internal/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestNativeCherryPicker_Run(t *testing.T) {
+	const markup = "Some-Markup"
+
+	ctx := context.Background()
+
+	setup := func(t *testing.T, downstream, upstream map[string]*string) (*git.Repository, plumbing.Hash) {
+		t.Helper()
+
+		repo, fs := test.NewRepoWithFS(t)
+
+		baseSHA, _ := test.AddCommit(t, repo, fs, "base", map[string]*string{
+			"file.txt": strPtr("one\ntwo\nthree\nfour\nfive\n"),
+		})
+
+		wt, err := repo.Worktree()
+		require.NoError(t, err)
+
+		upstreamSHA, _ := test.AddCommit(t, repo, fs, "upstream", upstream)
+
+		require.NoError(
+			t,
+			wt.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("downstream"), Create: true, Hash: baseSHA, Force: true}),
+		)
+
+		test.AddCommit(t, repo, fs, "downstream", downstream)
+
+		return repo, upstreamSHA
+	}
+
+	t.Run("changes are applied and committed", func(t *testing.T) {
+		repo, upstreamSHA := setup(
+			t,
+			map[string]*string{"file.txt": strPtr("ONE\ntwo\nthree\nfour\nfive\n")},
+			map[string]*string{
+				"file.txt":   strPtr("one\ntwo\nthree\nfour\nFIVE\n"),
+				"dir/new.sh": strPtr("#!/bin/sh\n"),
+			},
+		)
+
+		upstreamCommit, err := repo.CommitObject(upstreamSHA)
+		require.NoError(t, err)
+
+		cp := NewNativeCherryPicker(CommitOptions{Markup: markup}, nil, logr.Discard())
+
+		_, err = cp.Run(ctx, repo, "", upstreamCommit)
+		require.NoError(t, err)
+
+		head, err := repo.Head()
+		require.NoError(t, err)
+
+		headCommit, err := repo.CommitObject(head.Hash())
+		require.NoError(t, err)
+
+		assert.Equal(t, "upstream\n\n"+markup+": "+upstreamSHA.String(), headCommit.Message)
+		assert.Equal(t, upstreamCommit.Author, headCommit.Author)
+
+		for path, expected := range map[string]string{
+			"file.txt":   "ONE\ntwo\nthree\nfour\nFIVE\n",
+			"dir/new.sh": "#!/bin/sh\n",
+		} {
+			f, err := headCommit.File(path)
+			require.NoError(t, err)
+
+			rd, err := f.Reader()
+			require.NoError(t, err)
+
+			b, err := io.ReadAll(rd)
+			require.NoError(t, err)
+			rd.Close()
+
+			assert.Equal(t, expected, string(b))
+		}
+
+		wt, err := repo.Worktree()
+		require.NoError(t, err)
+
+		status, err := wt.Status()
+		require.NoError(t, err)
+		assert.True(t, status.IsClean())
+	})
+
+	t.Run("committer and sign-off", func(t *testing.T) {
+		repo, upstreamSHA := setup(
+			t,
+			map[string]*string{"other.txt": strPtr("other\n")},
+			map[string]*string{"file.txt": strPtr("one\ntwo\nthree\nfour\nFIVE\n")},
+		)
+
+		upstreamCommit, err := repo.CommitObject(upstreamSHA)
+		require.NoError(t, err)
+
+		opts := CommitOptions{
+			Committer: config.Committer{Email: "bot@example.com", Name: "Some Bot"},
+			Markup:    markup,
+			SignOff:   true,
+		}
+
+		_, err = NewNativeCherryPicker(opts, nil, logr.Discard()).Run(ctx, repo, "", upstreamCommit)
+		require.NoError(t, err)
+
+		head, err := repo.Head()
+		require.NoError(t, err)
+
+		headCommit, err := repo.CommitObject(head.Hash())
+		require.NoError(t, err)
+
+		assert.Equal(t, upstreamCommit.Author, headCommit.Author)
+		assert.Equal(t, "Some Bot", headCommit.Committer.Name)
+		assert.Equal(t, "bot@example.com", headCommit.Committer.Email)
+		assert.Equal(
+			t,
+			"upstream\n\nSigned-off-by: Some Bot <bot@example.com>\n"+markup+": "+upstreamSHA.String(),
+			headCommit.Message,
+		)
+	})
+
+	t.Run("conflicts are reported", func(t *testing.T) {
+		repo, upstreamSHA := setup(
+			t,
+			map[string]*string{"file.txt": strPtr("one\ntwo\n3\nfour\nfive\n")},
+			map[string]*string{"file.txt": strPtr("one\ntwo\nTHREE\nfour\nfive\n")},
+		)
+
+		upstreamCommit, err := repo.CommitObject(upstreamSHA)
+		require.NoError(t, err)
+
+		headBefore, err := repo.Head()
+		require.NoError(t, err)
+
+		_, err = NewNativeCherryPicker(CommitOptions{Markup: markup}, nil, logr.Discard()).Run(ctx, repo, "", upstreamCommit)
+
+		cpe := &CherryPickError{}
+		require.ErrorAs(t, err, &cpe)
+		assert.Equal(t, StepCherryPick, cpe.Step)
+
+		ce := &merge.ConflictError{}
+		require.ErrorAs(t, err, &ce)
+		require.Len(t, ce.Conflicts, 1)
+		assert.Equal(t, "file.txt", ce.Conflicts[0].Path)
+		assert.True(t, strings.Contains(ce.Conflicts[0].Details, "=======\nTHREE\n"))
+
+		headAfter, err := repo.Head()
+		require.NoError(t, err)
+		assert.Equal(t, headBefore.Hash(), headAfter.Hash())
+	})
+}
+
+func TestNativeMerge(t *testing.T) {
+	ctx := context.Background()
+
+	repo, fs := test.NewRepoWithFS(t)
+
+	baseSHA, _ := test.AddCommit(t, repo, fs, "base", map[string]*string{
+		"file.txt": strPtr("one\ntwo\nthree\nfour\nfive\n"),
+	})
+
+	test.AddCommit(t, repo, fs, "upstream 1", map[string]*string{"file.txt": strPtr("one\ntwo\nthree\nfour\nFIVE\n")})
+	_, upstream := test.AddCommit(t, repo, fs, "upstream 2", map[string]*string{"new.txt": strPtr("new\n")})
+
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+
+	require.NoError(
+		t,
+		wt.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("downstream"), Create: true, Hash: baseSHA, Force: true}),
+	)
+
+	t.Run("changes since the merge base are applied", func(t *testing.T) {
+		test.AddCommit(t, repo, fs, "downstream", map[string]*string{"file.txt": strPtr("ONE\ntwo\nthree\nfour\nfive\n")})
+
+		require.NoError(t, NativeMerge(ctx, logr.Discard(), repo, upstream))
+
+		for path, expected := range map[string]string{
+			"file.txt": "ONE\ntwo\nthree\nfour\nFIVE\n",
+			"new.txt":  "new\n",
+		} {
+			b, err := util.ReadFile(fs, path)
+			require.NoError(t, err)
+			assert.Equal(t, expected, string(b))
+		}
+
+		require.NoError(t, wt.Reset(&git.ResetOptions{Mode: git.HardReset}))
+	})
+
+	t.Run("conflicts are reported", func(t *testing.T) {
+		test.AddCommit(t, repo, fs, "downstream conflict", map[string]*string{"file.txt": strPtr("ONE\ntwo\nthree\nfour\n5\n")})
+
+		err := NativeMerge(ctx, logr.Discard(), repo, upstream)
+
+		ce := &merge.ConflictError{}
+		require.ErrorAs(t, err, &ce)
+		require.Len(t, ce.Conflicts, 1)
+		assert.Equal(t, "file.txt", ce.Conflicts[0].Path)
+
+		status, err := wt.Status()
+		require.NoError(t, err)
+		assert.True(t, status.IsClean())
+	})
+}
@@ -0,0 +1,111 @@
+package gitutils
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// PatchID returns an identifier of the change that commit makes to its first parent. Like git patch-id, it ignores
+// whitespace, line numbers and context lines, so that the same change applied on different bases has the same ID.
+func PatchID(commit *object.Commit) (plumbing.Hash, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("could not get the tree of %s: %v", commit.Hash, err)
+	}
+
+	parentTree := &object.Tree{}
+
+	if commit.NumParents() > 0 {
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("could not get the first parent of %s: %v", commit.Hash, err)
+		}
+
+		if parentTree, err = parent.Tree(); err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("could not get the tree of %s: %v", parent.Hash, err)
+		}
+	}
+
+	changes, err := object.DiffTree(parentTree, tree)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("could not diff the trees of %s: %v", commit.Hash, err)
+	}
+
+	patch, err := changes.Patch()
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("could not get the patch of %s: %v", commit.Hash, err)
+	}
+
+	fps := patch.FilePatches()
+	files := make([]string, 0, len(fps))
+
+	for _, fp := range fps {
+		var sb strings.Builder
+
+		from, to := fp.Files()
+
+		sb.WriteString("--- " + fileName(from) + "\n+++ " + fileName(to) + "\n")
+
+		for _, chunk := range fp.Chunks() {
+			var prefix string
+
+			switch chunk.Type() {
+			case diff.Add:
+				prefix = "+"
+			case diff.Delete:
+				prefix = "-"
+			default:
+				continue
+			}
+
+			for _, line := range strings.SplitAfter(chunk.Content(), "\n") {
+				if line = stripSpaces(line); line != "" {
+					sb.WriteString(prefix + line + "\n")
+				}
+			}
+		}
+
+		files = append(files, sb.String())
+	}
+
+	// Make the ID independent of the order in which files are diffed.
+	sort.Strings(files)
+
+	h := sha1.New()
+
+	for _, f := range files {
+		_, _ = io.WriteString(h, f)
+	}
+
+	var id plumbing.Hash
+
+	copy(id[:], h.Sum(nil))
+
+	return id, nil
+}
+
+func fileName(f diff.File) string {
+	if f == nil {
+		return "/dev/null"
+	}
+
+	return f.Path()
+}
+
+func stripSpaces(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+
+		return r
+	}, s)
+}
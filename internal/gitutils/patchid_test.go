@@ -0,0 +1,58 @@
+package gitutils
+
+import (
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/rh-ecosystem-edge/gitstream/internal/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatchID(t *testing.T) {
+	repo, fs := test.NewRepoWithFS(t)
+
+	base, _ := test.AddCommit(t, repo, fs, "base", map[string]*string{
+		"file.txt": strPtr("one\ntwo\nthree\n"),
+	})
+
+	_, change := test.AddCommit(t, repo, fs, "change", map[string]*string{
+		"file.txt": strPtr("one\nTWO\nthree\n"),
+		"new.txt":  strPtr("new\n"),
+	})
+
+	_, other := test.AddCommit(t, repo, fs, "other", map[string]*string{
+		"file.txt": strPtr("one\nTWO\nTHREE\n"),
+	})
+
+	// The same change, with different whitespace, on another base.
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+
+	require.NoError(
+		t,
+		wt.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("other"), Create: true, Hash: base, Force: true}),
+	)
+
+	test.AddCommit(t, repo, fs, "other base", map[string]*string{
+		"file.txt": strPtr("zero\none\ntwo\nthree\n"),
+	})
+
+	_, equivalent := test.AddCommit(t, repo, fs, "equivalent", map[string]*string{
+		"file.txt": strPtr("zero\none\n  TWO\nthree\n"),
+		"new.txt":  strPtr("new\n"),
+	})
+
+	changeID, err := PatchID(change)
+	require.NoError(t, err)
+
+	equivalentID, err := PatchID(equivalent)
+	require.NoError(t, err)
+
+	otherID, err := PatchID(other)
+	require.NoError(t, err)
+
+	assert.Equal(t, changeID, equivalentID)
+	assert.NotEqual(t, changeID, otherID)
+}
@@ -0,0 +1,54 @@
+package gitutils
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-logr/logr"
+)
+
+//go:generate mockgen -source=worktree.go -package=gitutils -destination=mock_worktree.go
+
+// WorktreeManager manages linked worktrees of a repository, so that several branches can be checked out at once.
+type WorktreeManager interface {
+	Add(ctx context.Context, path, commitish string) (*git.Repository, error)
+	Remove(ctx context.Context, path string) error
+}
+
+type WorktreeManagerImpl struct {
+	executor Executor
+	logger   logr.Logger
+	repoPath string
+}
+
+func NewWorktreeManager(repoPath string, logger logr.Logger) *WorktreeManagerImpl {
+	return &WorktreeManagerImpl{
+		executor: defaultExecutor,
+		logger:   logger,
+		repoPath: repoPath,
+	}
+}
+
+// Add creates a linked worktree at path with a detached HEAD at commitish, and opens it.
+// Objects and references are shared with the main repository.
+func (w *WorktreeManagerImpl) Add(ctx context.Context, path, commitish string) (*git.Repository, error) {
+	if err := w.executor.RunCommand(ctx, w.logger, "git", w.repoPath, "worktree", "add", "--detach", path, commitish); err != nil {
+		return nil, fmt.Errorf("could not add worktree %s: %w", path, err)
+	}
+
+	repo, err := git.PlainOpenWithOptions(path, &git.PlainOpenOptions{EnableDotGitCommonDir: true})
+	if err != nil {
+		return nil, fmt.Errorf("could not open worktree %s: %v", path, err)
+	}
+
+	return repo, nil
+}
+
+func (w *WorktreeManagerImpl) Remove(ctx context.Context, path string) error {
+	if err := w.executor.RunCommand(ctx, w.logger, "git", w.repoPath, "worktree", "remove", "--force", path); err != nil {
+		return fmt.Errorf("could not remove worktree %s: %w", path, err)
+	}
+
+	return nil
+}
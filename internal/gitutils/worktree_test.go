@@ -0,0 +1,87 @@
+package gitutils
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorktreeManagerImpl(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+
+	ctx := context.Background()
+
+	repoPath := t.TempDir()
+
+	repo, err := git.PlainInit(repoPath, false)
+	require.NoError(t, err)
+
+	sig := &object.Signature{
+		Name:  "Unit tests",
+		Email: "unit.tests@example.com",
+		When:  time.Now(),
+	}
+
+	mainWT, err := repo.Worktree()
+	require.NoError(t, err)
+
+	mainSHA, err := mainWT.Commit("first commit", &git.CommitOptions{AllowEmptyCommits: true, Author: sig})
+	require.NoError(t, err)
+
+	wm := NewWorktreeManager(repoPath, logr.Discard())
+
+	wtPath := filepath.Join(t.TempDir(), "worker")
+
+	wtRepo, err := wm.Add(ctx, wtPath, mainSHA.String())
+	require.NoError(t, err)
+
+	head, err := wtRepo.Head()
+	require.NoError(t, err)
+	assert.Equal(t, mainSHA, head.Hash())
+
+	wt, err := wtRepo.Worktree()
+	require.NoError(t, err)
+
+	const branchName = "some-branch"
+
+	branchRef := plumbing.NewBranchReferenceName(branchName)
+
+	require.NoError(
+		t,
+		wt.Checkout(&git.CheckoutOptions{Branch: branchRef, Create: true}),
+	)
+
+	branchSHA, err := wt.Commit("second commit", &git.CommitOptions{AllowEmptyCommits: true, Author: sig})
+	require.NoError(t, err)
+
+	// The branch and its commit are visible from the main repository
+	ref, err := repo.Reference(branchRef, true)
+	require.NoError(t, err)
+	assert.Equal(t, branchSHA, ref.Hash())
+
+	_, err = repo.CommitObject(branchSHA)
+	assert.NoError(t, err)
+
+	// The main worktree was not touched
+	mainHead, err := repo.Head()
+	require.NoError(t, err)
+	assert.Equal(t, mainSHA, mainHead.Hash())
+
+	require.NoError(
+		t,
+		wm.Remove(ctx, wtPath),
+	)
+
+	assert.NoDirExists(t, wtPath)
+}
@@ -0,0 +1,283 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-logr/logr"
+	"github.com/rh-ecosystem-edge/gitstream/internal/config"
+	"github.com/rh-ecosystem-edge/gitstream/internal/paths"
+	"github.com/rh-ecosystem-edge/gitstream/internal/process"
+)
+
+const (
+	StageAfterCherryPick = config.HookStageAfterCherryPick
+	StageAfterCommit     = config.HookStageAfterCommit
+	StageAfterPush       = config.HookStageAfterPush
+	StageBeforeCommit    = config.HookStageBeforeCommit
+	StageOnFailure       = config.HookStageOnFailure
+)
+
+var stages = map[string]bool{
+	StageAfterCherryPick: true,
+	StageAfterCommit:     true,
+	StageAfterPush:       true,
+	StageBeforeCommit:    true,
+	StageOnFailure:       true,
+}
+
+// Result describes a hook that ran.
+type Result struct {
+	Command string
+	// Err is set if the hook failed but was allowed to.
+	Err    error
+	Name   string
+	Output string
+	Stage  string
+}
+
+// Error is returned when a hook fails. It wraps a *process.Error if the hook exited with a non-zero code.
+type Error struct {
+	Err     error
+	Name    string
+	Stage   string
+	Timeout time.Duration
+}
+
+func (e *Error) Error() string {
+	if e.Timeout != 0 {
+		return fmt.Sprintf("hook %q (%s) timed out after %v: %v", e.Name, e.Stage, e.Timeout, e.Err)
+	}
+
+	return fmt.Sprintf("hook %q (%s) failed: %v", e.Name, e.Stage, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+type Runner struct {
+	execContext func(ctx context.Context, bin string, args ...string) *exec.Cmd
+	hooks       []config.Hook
+	logger      logr.Logger
+	upstreamURL string
+}
+
+// NewRunner returns a Runner for the hooks in cfg.
+// Commands listed in before_commit are run as before-commit hooks, before the hooks listed in hooks.
+func NewRunner(cfg config.Sync, upstreamURL string, logger logr.Logger) (*Runner, error) {
+	hooks := make([]config.Hook, 0, len(cfg.BeforeCommit)+len(cfg.Hooks))
+
+	for i, cmd := range cfg.BeforeCommit {
+		hooks = append(hooks, config.Hook{
+			Command: cmd,
+			Name:    fmt.Sprintf("before_commit[%d]", i),
+			Stage:   StageBeforeCommit,
+		})
+	}
+
+	for i, h := range cfg.Hooks {
+		if h.Name == "" {
+			h.Name = fmt.Sprintf("hooks[%d]", i)
+		}
+
+		if !stages[h.Stage] {
+			return nil, fmt.Errorf("hook %q: %q: invalid stage", h.Name, h.Stage)
+		}
+
+		if len(h.Command) == 0 {
+			return nil, fmt.Errorf("hook %q: empty command", h.Name)
+		}
+
+		hooks = append(hooks, h)
+	}
+
+	r := Runner{
+		execContext: exec.CommandContext,
+		hooks:       hooks,
+		logger:      logger,
+		upstreamURL: upstreamURL,
+	}
+
+	return &r, nil
+}
+
+// Has returns true if at least one hook runs at stage. Has returns false on a nil receiver.
+func (r *Runner) Has(stage string) bool {
+	if r == nil {
+		return false
+	}
+
+	for _, h := range r.hooks {
+		if h.Stage == stage {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Run runs the hooks for stage in dir, in order, with environment variables describing commit in addition to the
+// current environment and extraEnv.
+// Hooks with path conditions only run if commit changes at least one matching file.
+// It returns the results of all hooks that ran. If a hook that is not allowed to fail fails, it stops and returns an
+// *Error. Run does nothing on a nil receiver.
+func (r *Runner) Run(ctx context.Context, stage, dir string, commit *object.Commit, extraEnv ...string) ([]Result, error) {
+	if r == nil {
+		return nil, nil
+	}
+
+	var (
+		changedFiles []string
+		results      []Result
+	)
+
+	for _, h := range r.hooks {
+		if h.Stage != stage {
+			continue
+		}
+
+		logger := r.logger.WithValues("hook", h.Name, "stage", stage)
+
+		if changedFiles == nil {
+			var err error
+
+			if changedFiles, err = ChangedFiles(commit); err != nil {
+				return results, fmt.Errorf("could not list the files changed by %s: %v", commit.Hash, err)
+			}
+		}
+
+		if len(h.Paths) > 0 && !matchAny(h.Paths, changedFiles) {
+			logger.Info("Skipping hook: no changed file matches its paths")
+			continue
+		}
+
+		env := append(commitEnv(commit, changedFiles, r.upstreamURL), extraEnv...)
+		env = append(env, "GITSTREAM_HOOK_NAME="+h.Name, "GITSTREAM_HOOK_STAGE="+stage)
+
+		res, err := r.runHook(ctx, logger, h, dir, env)
+		if err != nil {
+			if !h.AllowFailure {
+				return append(results, res), err
+			}
+
+			logger.Info("Hook failed but is allowed to", "error", err)
+			res.Err = err
+		}
+
+		results = append(results, res)
+	}
+
+	return results, nil
+}
+
+func (r *Runner) runHook(ctx context.Context, logger logr.Logger, h config.Hook, dir string, env []string) (Result, error) {
+	if h.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, h.Timeout)
+		defer cancel()
+	}
+
+	cmd := r.execContext(ctx, h.Command[0], h.Command[1:]...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+
+	logger.Info("Running hook", "command", cmd)
+
+	out, err := cmd.CombinedOutput()
+
+	res := Result{
+		Command: cmd.String(),
+		Name:    h.Name,
+		Output:  string(out),
+		Stage:   h.Stage,
+	}
+
+	if err != nil {
+		ee := &exec.ExitError{}
+
+		if errors.As(err, &ee) {
+			err = process.NewError(ee, out, cmd.String())
+		}
+
+		he := &Error{Err: err, Name: h.Name, Stage: h.Stage}
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			he.Timeout = h.Timeout
+		}
+
+		return res, he
+	}
+
+	logger.V(1).Info("Hook exited normally", "output", res.Output)
+
+	return res, nil
+}
+
+func commitEnv(commit *object.Commit, changedFiles []string, upstreamURL string) []string {
+	return []string{
+		"GITSTREAM_CHANGED_FILES=" + strings.Join(changedFiles, "\n"),
+		"GITSTREAM_COMMIT_AUTHOR_EMAIL=" + commit.Author.Email,
+		"GITSTREAM_COMMIT_AUTHOR_NAME=" + commit.Author.Name,
+		"GITSTREAM_COMMIT_MESSAGE=" + commit.Message,
+		"GITSTREAM_COMMIT_SHA=" + commit.Hash.String(),
+		"GITSTREAM_UPSTREAM_URL=" + upstreamURL,
+	}
+}
+
+func matchAny(patterns, names []string) bool {
+	for _, n := range names {
+		if paths.Match(patterns, n) != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ChangedFiles returns the paths changed by commit relative to its first parent, including both sides of renames.
+func ChangedFiles(commit *object.Commit) ([]string, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("could not get the tree: %v", err)
+	}
+
+	parentTree := &object.Tree{}
+
+	if commit.NumParents() > 0 {
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return nil, fmt.Errorf("could not get the first parent: %v", err)
+		}
+
+		if parentTree, err = parent.Tree(); err != nil {
+			return nil, fmt.Errorf("could not get the tree of the first parent: %v", err)
+		}
+	}
+
+	changes, err := object.DiffTree(parentTree, tree)
+	if err != nil {
+		return nil, fmt.Errorf("could not diff trees: %v", err)
+	}
+
+	files := make([]string, 0, len(changes))
+
+	for _, c := range changes {
+		if c.From.Name != "" {
+			files = append(files, c.From.Name)
+		}
+
+		if c.To.Name != "" && c.To.Name != c.From.Name {
+			files = append(files, c.To.Name)
+		}
+	}
+
+	return files, nil
+}
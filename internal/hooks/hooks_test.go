@@ -0,0 +1,156 @@
+package hooks
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-logr/logr"
+	"github.com/rh-ecosystem-edge/gitstream/internal/config"
+	"github.com/rh-ecosystem-edge/gitstream/internal/process"
+	"github.com/rh-ecosystem-edge/gitstream/internal/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func newCommit(t *testing.T) *object.Commit {
+	t.Helper()
+
+	repo, fs := test.NewRepoWithFS(t)
+
+	test.AddCommit(t, repo, fs, "base", map[string]*string{"docs/README.md": strPtr("readme\n")})
+
+	_, commit := test.AddCommit(t, repo, fs, "Some message", map[string]*string{
+		"docs/README.md": nil,
+		"main.go":        strPtr("package main\n"),
+	})
+
+	return commit
+}
+
+func TestNewRunner(t *testing.T) {
+	t.Run("invalid stage", func(t *testing.T) {
+		_, err := NewRunner(config.Sync{Hooks: []config.Hook{{Command: []string{"true"}, Stage: "before-push"}}}, "", logr.Discard())
+		assert.EqualError(t, err, `hook "hooks[0]": "before-push": invalid stage`)
+	})
+
+	t.Run("empty command", func(t *testing.T) {
+		_, err := NewRunner(config.Sync{Hooks: []config.Hook{{Name: "lint", Stage: StageAfterCommit}}}, "", logr.Discard())
+		assert.EqualError(t, err, `hook "lint": empty command`)
+	})
+}
+
+func TestRunner_Has(t *testing.T) {
+	r, err := NewRunner(config.Sync{BeforeCommit: [][]string{{"true"}}}, "", logr.Discard())
+	require.NoError(t, err)
+
+	assert.True(t, r.Has(StageBeforeCommit))
+	assert.False(t, r.Has(StageAfterPush))
+	assert.False(t, (*Runner)(nil).Has(StageBeforeCommit))
+}
+
+func TestRunner_Run(t *testing.T) {
+	ctx := context.Background()
+	commit := newCommit(t)
+
+	t.Run("nil runner", func(t *testing.T) {
+		var r *Runner
+
+		res, err := r.Run(ctx, StageBeforeCommit, "", commit)
+		assert.NoError(t, err)
+		assert.Nil(t, res)
+	})
+
+	t.Run("hooks run in order with the commit environment", func(t *testing.T) {
+		cfg := config.Sync{
+			BeforeCommit: [][]string{{"echo", "legacy"}},
+			Hooks: []config.Hook{
+				{Command: []string{"echo", "skipped"}, Stage: StageAfterCommit},
+				{
+					Command: []string{"sh", "-c", `printf '%s|%s|%s|%s|%s' "$GITSTREAM_COMMIT_SHA" "$GITSTREAM_CHANGED_FILES" "$GITSTREAM_UPSTREAM_URL" "$GITSTREAM_HOOK_NAME" "$EXTRA"`},
+					Name:    "env",
+					Stage:   StageBeforeCommit,
+				},
+			},
+		}
+
+		r, err := NewRunner(cfg, "some-upstream-url", logr.Discard())
+		require.NoError(t, err)
+
+		res, err := r.Run(ctx, StageBeforeCommit, t.TempDir(), commit, "EXTRA=extra")
+		require.NoError(t, err)
+		require.Len(t, res, 2)
+
+		assert.Equal(t, "before_commit[0]", res[0].Name)
+		assert.Equal(t, "legacy\n", res[0].Output)
+
+		assert.Equal(t, "env", res[1].Name)
+		assert.Equal(t, StageBeforeCommit, res[1].Stage)
+		assert.Equal(t, commit.Hash.String()+"|docs/README.md\nmain.go|some-upstream-url|env|extra", res[1].Output)
+	})
+
+	t.Run("hooks only run if a changed file matches their paths", func(t *testing.T) {
+		cfg := config.Sync{
+			Hooks: []config.Hook{
+				{Command: []string{"true"}, Name: "go", Paths: []string{"*.go"}, Stage: StageAfterCommit},
+				{Command: []string{"true"}, Name: "yaml", Paths: []string{"*.yaml"}, Stage: StageAfterCommit},
+			},
+		}
+
+		r, err := NewRunner(cfg, "", logr.Discard())
+		require.NoError(t, err)
+
+		res, err := r.Run(ctx, StageAfterCommit, t.TempDir(), commit)
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		assert.Equal(t, "go", res[0].Name)
+	})
+
+	t.Run("failures", func(t *testing.T) {
+		cfg := config.Sync{
+			Hooks: []config.Hook{
+				{AllowFailure: true, Command: []string{"false"}, Name: "allowed", Stage: StageOnFailure},
+				{Command: []string{"sh", "-c", "echo failing; exit 3"}, Name: "failing", Stage: StageOnFailure},
+				{Command: []string{"true"}, Name: "not-run", Stage: StageOnFailure},
+			},
+		}
+
+		r, err := NewRunner(cfg, "", logr.Discard())
+		require.NoError(t, err)
+
+		res, err := r.Run(ctx, StageOnFailure, t.TempDir(), commit)
+		require.Len(t, res, 2)
+		assert.Error(t, res[0].Err)
+		assert.Equal(t, "failing\n", res[1].Output)
+
+		he := &Error{}
+		require.ErrorAs(t, err, &he)
+		assert.Equal(t, "failing", he.Name)
+
+		pe := &process.Error{}
+		require.ErrorAs(t, err, &pe)
+		assert.Equal(t, "failing\n", pe.CombinedString())
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		cfg := config.Sync{
+			Hooks: []config.Hook{
+				{Command: []string{"sleep", "10"}, Name: "slow", Stage: StageAfterPush, Timeout: 50 * time.Millisecond},
+			},
+		}
+
+		r, err := NewRunner(cfg, "", logr.Discard())
+		require.NoError(t, err)
+
+		_, err = r.Run(ctx, StageAfterPush, t.TempDir(), commit)
+
+		he := &Error{}
+		require.ErrorAs(t, err, &he)
+		assert.Equal(t, 50*time.Millisecond, he.Timeout)
+	})
+}
@@ -13,9 +13,18 @@ import (
 	"github.com/rh-ecosystem-edge/gitstream/internal"
 	gh "github.com/rh-ecosystem-edge/gitstream/internal/github"
 	"github.com/rh-ecosystem-edge/gitstream/internal/markup"
+	"github.com/rh-ecosystem-edge/gitstream/internal/skiplist"
 )
 
-type CommitIntents map[plumbing.Hash]string
+// Intent records where a downstream intent to include an upstream commit was found.
+type Intent struct {
+	// Markup is the markup or built-in pattern that matched.
+	Markup string
+	Origin string
+}
+
+// CommitIntents maps upstream SHAs, as written downstream, to the intent that refers to them. SHAs may be abbreviated.
+type CommitIntents map[string]Intent
 
 func MergeCommitIntents(cis ...CommitIntents) CommitIntents {
 	length := 0
@@ -40,6 +49,7 @@ func MergeCommitIntents(cis ...CommitIntents) CommitIntents {
 type Getter interface {
 	FromGitHubIssues(ctx context.Context, rn *gh.RepoName) (CommitIntents, error)
 	FromLocalGitRepo(ctx context.Context, repo *git.Repository, from plumbing.Hash, since *time.Time) (CommitIntents, error)
+	FromSkipList(ctx context.Context, repo *git.Repository, from plumbing.Hash) (CommitIntents, error)
 }
 
 type GetterImpl struct {
@@ -77,14 +87,14 @@ func (g *GetterImpl) FromGitHubIssues(ctx context.Context, rn *gh.RepoName) (Com
 				continue
 			}
 
-			shas, err := g.finder.FindSHAs(*issue.Body)
+			matches, err := g.finder.Find(*issue.Body)
 			if err != nil {
 				return nil, fmt.Errorf("error while looking for SHAs in %q: %v", *issue.Body, err)
 			}
 
-			for _, s := range shas {
-				logger.Info("Adding SHA", "SHA", s)
-				intents[s] = url
+			for _, m := range matches {
+				logger.Info("Adding SHA", "SHA", m.SHA, "markup", m.Markup)
+				intents[m.SHA] = Intent{Markup: m.Markup, Origin: url}
 			}
 		}
 
@@ -117,14 +127,14 @@ func (g *GetterImpl) FromLocalGitRepo(ctx context.Context, repo *git.Repository,
 		logger := g.logger.WithValues("commit", hash)
 		logger.Info("Processing commit")
 
-		shas, err := g.finder.FindSHAs(commit.Message)
+		matches, err := g.finder.Find(commit.Message)
 		if err != nil {
 			return fmt.Errorf("error while finding SHAs in commit %s: %v", hash, err)
 		}
 
-		for _, s := range shas {
-			logger.Info("Adding SHA", "sha", s)
-			intents[s] = "commit " + hash.String()
+		for _, m := range matches {
+			logger.Info("Adding SHA", "sha", m.SHA, "markup", m.Markup)
+			intents[m.SHA] = Intent{Markup: m.Markup, Origin: "commit " + hash.String()}
 		}
 
 		return nil
@@ -132,3 +142,46 @@ func (g *GetterImpl) FromLocalGitRepo(ctx context.Context, repo *git.Repository,
 
 	return intents, err
 }
+
+// FromSkipList returns the entries of the skip list in commit from that have not expired.
+func (g *GetterImpl) FromSkipList(ctx context.Context, repo *git.Repository, from plumbing.Hash) (CommitIntents, error) {
+	commit, err := repo.CommitObject(from)
+	if err != nil {
+		return nil, fmt.Errorf("could not get commit %s: %v", from, err)
+	}
+
+	l, err := skiplist.ReadFromCommit(commit)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	intents := make(CommitIntents, len(l.Commits))
+
+	for _, e := range l.Commits {
+		if e.Expired(now) {
+			g.logger.Info("Ignoring expired skip list entry", "sha", e.SHA, "expires", e.Expires)
+			continue
+		}
+
+		intents[e.SHA] = Intent{Markup: skiplist.Path, Origin: "skip list: " + e.Reason}
+	}
+
+	return intents, nil
+}
+
+// downstreamOnlyGetter is a Getter that ignores GitStream issues and PRs.
+type downstreamOnlyGetter struct {
+	Getter
+}
+
+// NewDownstreamOnlyGetter returns a Getter that finds intents like g in the downstream history and in the skip list,
+// but not in GitStream issues and PRs. It is meant for callers that need the upstream commits to actually be in the
+// downstream main branch, or deliberately skipped, and not only to have an open PR or a failed cherry-pick issue.
+func NewDownstreamOnlyGetter(g Getter) Getter {
+	return &downstreamOnlyGetter{Getter: g}
+}
+
+func (g *downstreamOnlyGetter) FromGitHubIssues(_ context.Context, _ *gh.RepoName) (CommitIntents, error) {
+	return make(CommitIntents), nil
+}
@@ -5,7 +5,6 @@ import (
 	"net/http"
 	"testing"
 
-	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-logr/logr"
 	"github.com/golang/mock/gomock"
 	"github.com/google/go-github/v47/github"
@@ -13,7 +12,10 @@ import (
 	gh "github.com/rh-ecosystem-edge/gitstream/internal/github"
 	"github.com/rh-ecosystem-edge/gitstream/internal/intents"
 	"github.com/rh-ecosystem-edge/gitstream/internal/markup"
+	"github.com/rh-ecosystem-edge/gitstream/internal/skiplist"
+	"github.com/rh-ecosystem-edge/gitstream/internal/test"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewIntentsGetter(t *testing.T) {
@@ -78,16 +80,14 @@ func TestGetterImpl_FromGitHubIssues(t *testing.T) {
 
 		finder := markup.NewMockFinder(ctrl)
 
-		hash := plumbing.NewHash(hashStr)
-
 		gomock.InOrder(
-			finder.EXPECT().FindSHAs(msg0),
-			finder.EXPECT().FindSHAs(msg1).Return([]plumbing.Hash{hash}, nil),
+			finder.EXPECT().Find(msg0),
+			finder.EXPECT().Find(msg1).Return([]markup.Match{{Markup: "Some-Markup", SHA: hashStr}}, nil),
 		)
 
 		ci, err := intents.NewIntentsGetter(finder, c, logr.Discard()).FromGitHubIssues(context.Background(), &repoName)
 		assert.NoError(t, err)
-		assert.Equal(t, intents.CommitIntents{hash: issueURL1}, ci)
+		assert.Equal(t, intents.CommitIntents{hashStr: {Markup: "Some-Markup", Origin: issueURL1}}, ci)
 
 	})
 }
@@ -96,14 +96,69 @@ func TestGetterImpl_FromLocalGitRepo(t *testing.T) {
 	// TODO
 }
 
+func TestGetterImpl_FromSkipList(t *testing.T) {
+	ctx := context.Background()
+
+	repo, fs := test.NewRepoWithFS(t)
+
+	emptySHA, _ := test.AddEmptyCommit(t, repo, "no skip list")
+
+	const skipped = `commits:
+  - sha: E3229F3C
+    reason: not needed downstream
+  - sha: 9c08d42326af62aa0f8cea021c4d37971606148f
+    reason: reverted later
+    expires: 2000-01-01
+  - sha: 0d1a26e67d8f5eaf1f6ba5c57fc3c7d91ac0fd1c
+    reason: until the next release
+    expires: 2999-01-01
+`
+
+	sha, _ := test.AddCommit(t, repo, fs, "skip list", map[string]*string{skiplist.Path: strPtr(skipped)})
+
+	ig := intents.NewIntentsGetter(nil, nil, logr.Discard())
+
+	ci, err := ig.FromSkipList(ctx, repo, emptySHA)
+	require.NoError(t, err)
+	assert.Empty(t, ci)
+
+	ci, err = ig.FromSkipList(ctx, repo, sha)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		intents.CommitIntents{
+			"e3229f3c": {Markup: skiplist.Path, Origin: "skip list: not needed downstream"},
+			"0d1a26e67d8f5eaf1f6ba5c57fc3c7d91ac0fd1c": {Markup: skiplist.Path, Origin: "skip list: until the next release"},
+		},
+		ci,
+	)
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestNewDownstreamOnlyGetter(t *testing.T) {
+	ctx := context.Background()
+
+	// GitStream issues and PRs are not looked up.
+	g := intents.NewDownstreamOnlyGetter(intents.NewMockGetter(gomock.NewController(t)))
+
+	cis, err := g.FromGitHubIssues(ctx, &gh.RepoName{Owner: "owner", Repo: "repo"})
+	require.NoError(t, err)
+	assert.Empty(t, cis)
+}
+
 func TestMergeCommitIntents(t *testing.T) {
-	hash1 := plumbing.NewHash("e3229f3c533ed51070beff092e5c7694a8ee81f0")
-	hash2 := plumbing.NewHash("9c08d42326af62aa0f8cea021c4d37971606148f")
+	const (
+		hash1 = "e3229f3c533ed51070beff092e5c7694a8ee81f0"
+		hash2 = "9c08d42326af62aa0f8cea021c4d37971606148f"
+	)
 
 	t.Run("should combine commit intents", func(t *testing.T) {
 		m := intents.MergeCommitIntents(
-			intents.CommitIntents{hash1: "origin 0"},
-			intents.CommitIntents{hash2: "origin 2"},
+			intents.CommitIntents{hash1: {Origin: "origin 0"}},
+			intents.CommitIntents{hash2: {Origin: "origin 2"}},
 		)
 
 		assert.Len(t, m, 2)
@@ -112,11 +167,11 @@ func TestMergeCommitIntents(t *testing.T) {
 	})
 
 	t.Run("double override", func(t *testing.T) {
-		final := intents.CommitIntents{hash1: "origin 2"}
+		final := intents.CommitIntents{hash1: {Origin: "origin 2"}}
 
 		m := intents.MergeCommitIntents(
-			intents.CommitIntents{hash1: "origin 0"},
-			intents.CommitIntents{hash1: "origin 1"},
+			intents.CommitIntents{hash1: {Origin: "origin 0"}},
+			intents.CommitIntents{hash1: {Origin: "origin 1"}},
 			final,
 		)
 
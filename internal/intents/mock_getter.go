@@ -67,3 +67,18 @@ func (mr *MockGetterMockRecorder) FromLocalGitRepo(ctx, repo, from, since interf
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FromLocalGitRepo", reflect.TypeOf((*MockGetter)(nil).FromLocalGitRepo), ctx, repo, from, since)
 }
+
+// FromSkipList mocks base method.
+func (m *MockGetter) FromSkipList(ctx context.Context, repo *v5.Repository, from plumbing.Hash) (CommitIntents, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FromSkipList", ctx, repo, from)
+	ret0, _ := ret[0].(CommitIntents)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FromSkipList indicates an expected call of FromSkipList.
+func (mr *MockGetterMockRecorder) FromSkipList(ctx, repo, from interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FromSkipList", reflect.TypeOf((*MockGetter)(nil).FromSkipList), ctx, repo, from)
+}
@@ -3,40 +3,96 @@ package markup
 import (
 	"fmt"
 	"regexp"
+	"strings"
 
 	"github.com/go-git/go-git/v5/plumbing"
 )
 
 //go:generate mockgen -source=finder.go -package=markup -destination=mock_finder.go
 
+// CherryPickX is the name of the built-in pattern matching the footer added by git cherry-pick -x.
+const CherryPickX = "cherry-pick -x"
+
+// MinAbbrevLength is the minimum length of an abbreviated SHA, as in git.
+const MinAbbrevLength = 4
+
+// Match is an upstream commit SHA found in some text.
+type Match struct {
+	// Markup is the markup or built-in pattern that matched.
+	Markup string
+	// SHA is written in lowercase hexadecimal and may be abbreviated.
+	SHA string
+}
+
 type Finder interface {
-	FindSHAs(string) ([]plumbing.Hash, error)
+	Find(string) ([]Match, error)
+	FindSHAs(string) ([]string, error)
+}
+
+type pattern struct {
+	name string
+	re   *regexp.Regexp
 }
 
 type finder struct {
-	re *regexp.Regexp
+	patterns []pattern
 }
 
-func NewFinder(markup string) (Finder, error) {
-	pattern := fmt.Sprintf(`(?m)^%s:\s*([a-z0-9]+)$`, markup)
+// NewFinder returns a Finder that recognizes each of markups as a trailer, in addition to the footer added by
+// git cherry-pick -x.
+func NewFinder(markups ...string) (Finder, error) {
+	patterns := make([]pattern, 0, len(markups)+1)
 
-	re, err := regexp.Compile(pattern)
-	if err != nil {
-		return nil, fmt.Errorf("invalid regexp: %v", err)
+	for _, m := range markups {
+		re, err := regexp.Compile(fmt.Sprintf(`(?m)^%s:\s*([a-fA-F0-9]+)$`, m))
+		if err != nil {
+			return nil, fmt.Errorf("invalid regexp for markup %q: %v", m, err)
+		}
+
+		patterns = append(patterns, pattern{name: m, re: re})
 	}
 
-	return &finder{re: re}, nil
+	patterns = append(patterns, pattern{
+		name: CherryPickX,
+		re:   regexp.MustCompile(`(?m)^\(cherry picked from commit ([a-f0-9]+)\)$`),
+	})
+
+	return &finder{patterns: patterns}, nil
 }
 
-func (f *finder) FindSHAs(s string) ([]plumbing.Hash, error) {
-	hashes := make([]plumbing.Hash, 0)
+// Find returns the SHAs found in s by each pattern, in the order of the patterns. SHAs may be abbreviated, but SHAs
+// shorter than MinAbbrevLength or longer than a full SHA are ignored.
+func (f *finder) Find(s string) ([]Match, error) {
+	matches := make([]Match, 0)
+
+	for _, p := range f.patterns {
+		for _, item := range p.re.FindAllStringSubmatch(s, -1) {
+			sha := strings.ToLower(item[1])
+
+			if len(sha) < MinAbbrevLength || len(sha) > 2*len(plumbing.ZeroHash) {
+				continue
+			}
+
+			matches = append(matches, Match{Markup: p.name, SHA: sha})
+		}
+	}
+
+	return matches, nil
+}
+
+// FindSHAs returns the SHAs found in s, in lowercase. They may be abbreviated, in which case callers must resolve them
+// against the upstream history.
+func (f *finder) FindSHAs(s string) ([]string, error) {
+	matches, err := f.Find(s)
+	if err != nil {
+		return nil, err
+	}
+
+	shas := make([]string, 0, len(matches))
 
-	for _, item := range f.re.FindAllStringSubmatch(s, -1) {
-		hashes = append(
-			hashes,
-			plumbing.NewHash(item[1]),
-		)
+	for _, m := range matches {
+		shas = append(shas, m.SHA)
 	}
 
-	return hashes, nil
+	return shas, nil
 }
@@ -3,7 +3,6 @@ package markup_test
 import (
 	"testing"
 
-	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/rh-ecosystem-edge/gitstream/internal/markup"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -25,18 +24,18 @@ func TestFinder_FindSHAs(t *testing.T) {
 	cases := []struct {
 		name string
 		text string
-		shas []plumbing.Hash
+		shas []string
 	}{
 		{
 			name: "0 matches",
 			text: "",
-			shas: make([]plumbing.Hash, 0),
+			shas: make([]string, 0),
 		},
 		{
 			name: "1 match",
 			text: "Some-Key: a109a5cfd36f7abe14089da2da0638149c4dc6cc",
-			shas: []plumbing.Hash{
-				plumbing.NewHash("a109a5cfd36f7abe14089da2da0638149c4dc6cc"),
+			shas: []string{
+				"a109a5cfd36f7abe14089da2da0638149c4dc6cc",
 			},
 		},
 		{
@@ -45,8 +44,8 @@ func TestFinder_FindSHAs(t *testing.T) {
 
 Some-Key: a109a5cfd36f7abe14089da2da0638149c4dc6cc
 `,
-			shas: []plumbing.Hash{
-				plumbing.NewHash("a109a5cfd36f7abe14089da2da0638149c4dc6cc"),
+			shas: []string{
+				"a109a5cfd36f7abe14089da2da0638149c4dc6cc",
 			},
 		},
 		{
@@ -58,11 +57,16 @@ Some-Key: a109a5cfd36f7abe14089da2da0638149c4dc6cd
 Invalid line Some-Key: a109a5cfd36f7abe14089da2da0638149c4dc6cd
 Some-Key: a109a5cfd36f7abe14089da2da0638149c4dc6cd another invalid line
 `,
-			shas: []plumbing.Hash{
-				plumbing.NewHash("a109a5cfd36f7abe14089da2da0638149c4dc6cc"),
-				plumbing.NewHash("a109a5cfd36f7abe14089da2da0638149c4dc6cd"),
+			shas: []string{
+				"a109a5cfd36f7abe14089da2da0638149c4dc6cc",
+				"a109a5cfd36f7abe14089da2da0638149c4dc6cd",
 			},
 		},
+		{
+			name: "abbreviated SHAs are returned in lowercase",
+			text: "Some-Key: 1A2B3C4D",
+			shas: []string{"1a2b3c4d"},
+		},
 	}
 
 	f, err := markup.NewFinder("Some-Key")
@@ -76,3 +80,31 @@ Some-Key: a109a5cfd36f7abe14089da2da0638149c4dc6cd another invalid line
 		})
 	}
 }
+
+func TestFinder_Find(t *testing.T) {
+	const text = `Some commit
+
+Old-Key: a109a5cfd36f7abe14089da2da0638149c4dc6cc
+(cherry picked from commit a109a5cfd36f7abe14089da2da0638149c4dc6cd)
+Some-Key: a109a5cfd36f7abe14089da2da0638149c4dc6ce
+Some-Key: 1A2B3C4D
+Some-Key: abc
+`
+
+	f, err := markup.NewFinder("Some-Key", "Old-Key")
+	require.NoError(t, err)
+
+	matches, err := f.Find(text)
+	require.NoError(t, err)
+
+	assert.Equal(
+		t,
+		[]markup.Match{
+			{Markup: "Some-Key", SHA: "a109a5cfd36f7abe14089da2da0638149c4dc6ce"},
+			{Markup: "Some-Key", SHA: "1a2b3c4d"},
+			{Markup: "Old-Key", SHA: "a109a5cfd36f7abe14089da2da0638149c4dc6cc"},
+			{Markup: markup.CherryPickX, SHA: "a109a5cfd36f7abe14089da2da0638149c4dc6cd"},
+		},
+		matches,
+	)
+}
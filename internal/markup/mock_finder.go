@@ -7,7 +7,6 @@ package markup
 import (
 	reflect "reflect"
 
-	plumbing "github.com/go-git/go-git/v5/plumbing"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -34,11 +33,26 @@ func (m *MockFinder) EXPECT() *MockFinderMockRecorder {
 	return m.recorder
 }
 
+// Find mocks base method.
+func (m *MockFinder) Find(arg0 string) ([]Match, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Find", arg0)
+	ret0, _ := ret[0].([]Match)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Find indicates an expected call of Find.
+func (mr *MockFinderMockRecorder) Find(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*MockFinder)(nil).Find), arg0)
+}
+
 // FindSHAs mocks base method.
-func (m *MockFinder) FindSHAs(arg0 string) ([]plumbing.Hash, error) {
+func (m *MockFinder) FindSHAs(arg0 string) ([]string, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "FindSHAs", arg0)
-	ret0, _ := ret[0].([]plumbing.Hash)
+	ret0, _ := ret[0].([]string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
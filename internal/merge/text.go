@@ -0,0 +1,200 @@
+package merge
+
+import (
+	"strings"
+
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+const (
+	labelOurs   = "downstream"
+	labelTheirs = "upstream"
+)
+
+// hunk replaces lines [start, end) of the base with lines.
+type hunk struct {
+	end   int
+	lines []string
+	start int
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	lines := strings.SplitAfter(s, "\n")
+
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// hunks returns the changes needed to turn base into other, in base order.
+func hunks(base, other string) []hunk {
+	var (
+		cur *hunk
+		pos int
+		res []hunk
+	)
+
+	flush := func() {
+		if cur != nil {
+			res = append(res, *cur)
+			cur = nil
+		}
+	}
+
+	for _, d := range diff.Do(base, other) {
+		lines := splitLines(d.Text)
+
+		switch d.Type {
+		case diffmatchpatch.DiffEqual:
+			flush()
+			pos += len(lines)
+		case diffmatchpatch.DiffDelete:
+			if cur == nil {
+				cur = &hunk{start: pos, end: pos}
+			}
+
+			pos += len(lines)
+			cur.end = pos
+		case diffmatchpatch.DiffInsert:
+			if cur == nil {
+				cur = &hunk{start: pos, end: pos}
+			}
+
+			cur.lines = append(cur.lines, lines...)
+		}
+	}
+
+	flush()
+
+	return res
+}
+
+// apply returns base[start:end] with hs applied. All hunks must be within [start, end).
+func apply(base []string, start, end int, hs []hunk) []string {
+	res := make([]string, 0, end-start)
+	pos := start
+
+	for _, h := range hs {
+		res = append(res, base[pos:h.start]...)
+		res = append(res, h.lines...)
+		pos = h.end
+	}
+
+	return append(res, base[pos:end]...)
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Text merges the changes made to base in ours and in theirs, line by line.
+// Changes from both sides that overlap or touch each other conflict, unless they are identical.
+// It returns the merged text, with conflict markers around each conflicting region, and the conflicting regions alone.
+func Text(base, ours, theirs string) (string, []string) {
+	var (
+		baseLines = splitLines(base)
+		conflicts []string
+		i, j, pos int
+		oh        = hunks(base, ours)
+		sb        strings.Builder
+		th        = hunks(base, theirs)
+	)
+
+	for i < len(oh) || j < len(th) {
+		var first hunk
+
+		if j >= len(th) || (i < len(oh) && oh[i].start <= th[j].start) {
+			first = oh[i]
+		} else {
+			first = th[j]
+		}
+
+		start, end := first.start, first.end
+
+		var ourGroup, theirGroup []hunk
+
+		// Grow the region until no hunk from either side overlaps or touches it.
+		for {
+			grown := false
+
+			for i < len(oh) && oh[i].start <= end {
+				ourGroup = append(ourGroup, oh[i])
+				end = max(end, oh[i].end)
+				i++
+				grown = true
+			}
+
+			for j < len(th) && th[j].start <= end {
+				theirGroup = append(theirGroup, th[j])
+				end = max(end, th[j].end)
+				j++
+				grown = true
+			}
+
+			if !grown {
+				break
+			}
+		}
+
+		sb.WriteString(strings.Join(baseLines[pos:start], ""))
+
+		ourLines := apply(baseLines, start, end, ourGroup)
+		theirLines := apply(baseLines, start, end, theirGroup)
+
+		switch {
+		case len(theirGroup) == 0:
+			sb.WriteString(strings.Join(ourLines, ""))
+		case len(ourGroup) == 0 || equalLines(ourLines, theirLines):
+			sb.WriteString(strings.Join(theirLines, ""))
+		default:
+			c := conflictMarkers(ourLines, theirLines)
+			conflicts = append(conflicts, c)
+			sb.WriteString(c)
+		}
+
+		pos = end
+	}
+
+	sb.WriteString(strings.Join(baseLines[pos:], ""))
+
+	return sb.String(), conflicts
+}
+
+func conflictMarkers(ours, theirs []string) string {
+	var sb strings.Builder
+
+	writeLines := func(lines []string) {
+		for _, l := range lines {
+			sb.WriteString(l)
+
+			if !strings.HasSuffix(l, "\n") {
+				sb.WriteString("\n")
+			}
+		}
+	}
+
+	sb.WriteString("<<<<<<< " + labelOurs + "\n")
+	writeLines(ours)
+	sb.WriteString("=======\n")
+	writeLines(theirs)
+	sb.WriteString(">>>>>>> " + labelTheirs + "\n")
+
+	return sb.String()
+}
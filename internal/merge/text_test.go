@@ -0,0 +1,85 @@
+package merge
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestText(t *testing.T) {
+	const base = "one\ntwo\nthree\nfour\nfive\nsix\n"
+
+	cases := []struct {
+		name              string
+		ours              string
+		theirs            string
+		expected          string
+		expectedConflicts []string
+	}{
+		{
+			name:     "no changes",
+			ours:     base,
+			theirs:   base,
+			expected: base,
+		},
+		{
+			name:     "only theirs",
+			ours:     base,
+			theirs:   "one\ntwo\nTHREE\nfour\nfive\nsix\n",
+			expected: "one\ntwo\nTHREE\nfour\nfive\nsix\n",
+		},
+		{
+			name:     "only ours",
+			ours:     "one\ntwo\nTHREE\nfour\nfive\nsix\n",
+			theirs:   base,
+			expected: "one\ntwo\nTHREE\nfour\nfive\nsix\n",
+		},
+		{
+			name:     "distant changes",
+			ours:     "ONE\ntwo\nthree\nfour\nfive\nsix\n",
+			theirs:   "one\ntwo\nthree\nfour\nfive\nSIX\nseven\n",
+			expected: "ONE\ntwo\nthree\nfour\nfive\nSIX\nseven\n",
+		},
+		{
+			name:     "identical changes",
+			ours:     "one\ntwo\n3\nfour\nfive\nsix\n",
+			theirs:   "one\ntwo\n3\nfour\nfive\nsix\n",
+			expected: "one\ntwo\n3\nfour\nfive\nsix\n",
+		},
+		{
+			name:     "deletion and insertion",
+			ours:     "one\nfour\nfive\nsix\n",
+			theirs:   "one\ntwo\nthree\nfour\nfive\nsix\nseven\n",
+			expected: "one\nfour\nfive\nsix\nseven\n",
+		},
+		{
+			name:              "overlapping changes",
+			ours:              "one\ntwo\nthree-ours\nfour\nfive\nsix\n",
+			theirs:            "one\ntwo\nthree-theirs\nfour\nfive\nsix\n",
+			expected:          "one\ntwo\n<<<<<<< downstream\nthree-ours\n=======\nthree-theirs\n>>>>>>> upstream\nfour\nfive\nsix\n",
+			expectedConflicts: []string{"<<<<<<< downstream\nthree-ours\n=======\nthree-theirs\n>>>>>>> upstream\n"},
+		},
+		{
+			name:              "adjacent changes",
+			ours:              "one\ntwo\nTHREE\nfour\nfive\nsix\n",
+			theirs:            "one\ntwo\nthree\nFOUR\nfive\nsix\n",
+			expected:          "one\ntwo\n<<<<<<< downstream\nTHREE\nfour\n=======\nthree\nFOUR\n>>>>>>> upstream\nfive\nsix\n",
+			expectedConflicts: []string{"<<<<<<< downstream\nTHREE\nfour\n=======\nthree\nFOUR\n>>>>>>> upstream\n"},
+		},
+		{
+			name:     "no trailing newline",
+			ours:     "one\ntwo\nthree\nfour\nfive\nsix",
+			theirs:   "ONE\ntwo\nthree\nfour\nfive\nsix\n",
+			expected: "ONE\ntwo\nthree\nfour\nfive\nsix",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			merged, conflicts := Text(base, c.ours, c.theirs)
+
+			assert.Equal(t, c.expected, merged)
+			assert.Equal(t, c.expectedConflicts, conflicts)
+		})
+	}
+}
@@ -0,0 +1,356 @@
+// Package merge implements a three-way merge of git trees, as done by git cherry-pick.
+// "Ours" is the downstream branch the changes are applied to; "theirs" is the upstream commit.
+package merge
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/utils/merkletrie"
+)
+
+// Change is a change to apply to the downstream worktree.
+type Change struct {
+	Contents []byte
+	Delete   bool
+	Mode     filemode.FileMode
+	Path     string
+}
+
+type Conflict struct {
+	// Details contains the conflicting regions, with conflict markers, for content conflicts.
+	Details string
+	Path    string
+	Reason  string
+}
+
+type ConflictError struct {
+	Conflicts []Conflict
+}
+
+func (e *ConflictError) Error() string {
+	items := make([]string, 0, len(e.Conflicts))
+
+	for _, c := range e.Conflicts {
+		items = append(items, fmt.Sprintf("%s: %s", c.Path, c.Reason))
+	}
+
+	return fmt.Sprintf("%d conflict(s): %s", len(e.Conflicts), strings.Join(items, "; "))
+}
+
+type Result struct {
+	Changes   []Change
+	Conflicts []Conflict
+}
+
+// entry is a blob in a tree.
+type entry struct {
+	hash plumbing.Hash
+	mode filemode.FileMode
+	path string
+	tree *object.Tree
+}
+
+func (e *entry) contents() ([]byte, error) {
+	f, err := e.tree.TreeEntryFile(&object.TreeEntry{Name: e.path, Mode: e.mode, Hash: e.hash})
+	if err != nil {
+		return nil, fmt.Errorf("could not get blob for %s: %v", e.path, err)
+	}
+
+	rd, err := f.Reader()
+	if err != nil {
+		return nil, fmt.Errorf("could not read blob for %s: %v", e.path, err)
+	}
+	defer rd.Close()
+
+	return io.ReadAll(rd)
+}
+
+func (e *entry) sameAs(o *entry) bool {
+	return e.hash == o.hash && e.mode == o.mode
+}
+
+type merger struct {
+	// oursRenames maps base paths to the path they were renamed to downstream.
+	oursRenames map[string]string
+	// oursDeletes contains base paths that were deleted downstream.
+	oursDeletes map[string]bool
+	ours        *object.Tree
+	res         Result
+}
+
+func diffTrees(ctx context.Context, a, b *object.Tree) (object.Changes, error) {
+	return object.DiffTreeWithOptions(ctx, a, b, object.DefaultDiffTreeOptions)
+}
+
+// Trees applies the changes between base and theirs to ours.
+// Renames are detected on both sides. Conflicts are returned in the result rather than as an error.
+func Trees(ctx context.Context, base, ours, theirs *object.Tree) (*Result, error) {
+	oursChanges, err := diffTrees(ctx, base, ours)
+	if err != nil {
+		return nil, fmt.Errorf("could not diff the base and downstream trees: %v", err)
+	}
+
+	m := merger{
+		oursRenames: make(map[string]string),
+		oursDeletes: make(map[string]bool),
+		ours:        ours,
+	}
+
+	for _, c := range oursChanges {
+		switch {
+		case c.To.Name == "":
+			m.oursDeletes[c.From.Name] = true
+		case c.From.Name != "" && c.From.Name != c.To.Name:
+			m.oursRenames[c.From.Name] = c.To.Name
+		}
+	}
+
+	theirsChanges, err := diffTrees(ctx, base, theirs)
+	if err != nil {
+		return nil, fmt.Errorf("could not diff the base and upstream trees: %v", err)
+	}
+
+	for _, c := range theirsChanges {
+		action, err := c.Action()
+		if err != nil {
+			return nil, fmt.Errorf("could not get the change type: %v", err)
+		}
+
+		from := &entry{hash: c.From.TreeEntry.Hash, mode: c.From.TreeEntry.Mode, path: c.From.Name, tree: c.From.Tree}
+		to := &entry{hash: c.To.TreeEntry.Hash, mode: c.To.TreeEntry.Mode, path: c.To.Name, tree: c.To.Tree}
+
+		if from.mode == filemode.Submodule || to.mode == filemode.Submodule {
+			m.conflict(c.From.Name+c.To.Name, "submodule changes are not supported", "")
+			continue
+		}
+
+		switch action {
+		case merkletrie.Insert:
+			err = m.insert(to)
+		case merkletrie.Delete:
+			err = m.delete(from)
+		case merkletrie.Modify:
+			err = m.modify(from, to)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &m.res, nil
+}
+
+func (m *merger) conflict(path, reason, details string) {
+	m.res.Conflicts = append(m.res.Conflicts, Conflict{Details: details, Path: path, Reason: reason})
+}
+
+// oursEntry returns the downstream blob at path, or nil if there is none.
+func (m *merger) oursEntry(path string) (*entry, error) {
+	te, err := m.ours.FindEntry(path)
+	if err != nil {
+		if errors.Is(err, object.ErrEntryNotFound) || errors.Is(err, object.ErrDirectoryNotFound) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("could not look up %s downstream: %v", path, err)
+	}
+
+	return &entry{hash: te.Hash, mode: te.Mode, path: path, tree: m.ours}, nil
+}
+
+// oursPath returns the downstream path of a base path, or an empty string if it was deleted downstream.
+func (m *merger) oursPath(basePath string) string {
+	if m.oursDeletes[basePath] {
+		return ""
+	}
+
+	if p, ok := m.oursRenames[basePath]; ok {
+		return p
+	}
+
+	return basePath
+}
+
+func (m *merger) write(e *entry, path string) error {
+	contents, err := e.contents()
+	if err != nil {
+		return err
+	}
+
+	m.res.Changes = append(m.res.Changes, Change{Contents: contents, Mode: e.mode, Path: path})
+
+	return nil
+}
+
+func (m *merger) insert(to *entry) error {
+	oe, err := m.oursEntry(to.path)
+	if err != nil {
+		return err
+	}
+
+	if oe == nil {
+		return m.write(to, to.path)
+	}
+
+	if !oe.sameAs(to) {
+		m.conflict(to.path, "added upstream and downstream with different contents", "")
+	}
+
+	return nil
+}
+
+func (m *merger) delete(from *entry) error {
+	p := m.oursPath(from.path)
+
+	if p == "" {
+		return nil
+	}
+
+	oe, err := m.oursEntry(p)
+	if err != nil {
+		return err
+	}
+
+	if oe == nil {
+		return nil
+	}
+
+	if oe.hash != from.hash {
+		m.conflict(p, "deleted upstream and modified downstream", "")
+		return nil
+	}
+
+	m.res.Changes = append(m.res.Changes, Change{Delete: true, Path: p})
+
+	return nil
+}
+
+func (m *merger) modify(from, to *entry) error {
+	p := m.oursPath(from.path)
+
+	if p == "" {
+		m.conflict(from.path, "modified upstream and deleted downstream", "")
+		return nil
+	}
+
+	oe, err := m.oursEntry(p)
+	if err != nil {
+		return err
+	}
+
+	if oe == nil {
+		m.conflict(p, "modified upstream and deleted downstream", "")
+		return nil
+	}
+
+	dest := p
+
+	if from.path != to.path && p != to.path {
+		if p != from.path {
+			m.conflict(from.path, fmt.Sprintf("renamed to %s upstream and to %s downstream", to.path, p), "")
+			return nil
+		}
+
+		existing, err := m.oursEntry(to.path)
+		if err != nil {
+			return err
+		}
+
+		if existing != nil {
+			m.conflict(to.path, fmt.Sprintf("renamed from %s upstream but already exists downstream", from.path), "")
+			return nil
+		}
+
+		dest = to.path
+	}
+
+	mode := oe.mode
+
+	switch {
+	case oe.mode == from.mode:
+		mode = to.mode
+	case to.mode != from.mode && to.mode != oe.mode:
+		m.conflict(dest, fmt.Sprintf("mode changed to %s upstream and to %s downstream", to.mode, oe.mode), "")
+		return nil
+	}
+
+	var contents []byte
+
+	// Downstream already has the upstream contents, or upstream only renamed the file or changed its mode.
+	unchanged := oe.hash == to.hash || to.hash == from.hash
+
+	switch {
+	case unchanged:
+		if dest == p && mode == oe.mode {
+			return nil
+		}
+
+		if contents, err = oe.contents(); err != nil {
+			return err
+		}
+	case oe.hash == from.hash:
+		if contents, err = to.contents(); err != nil {
+			return err
+		}
+	default:
+		var ok bool
+
+		if contents, ok, err = m.mergeContents(from, oe, to, dest); err != nil || !ok {
+			return err
+		}
+	}
+
+	if dest != p {
+		m.res.Changes = append(m.res.Changes, Change{Delete: true, Path: p})
+	}
+
+	m.res.Changes = append(m.res.Changes, Change{Contents: contents, Mode: mode, Path: dest})
+
+	return nil
+}
+
+func (m *merger) mergeContents(base, ours, theirs *entry, path string) ([]byte, bool, error) {
+	var blobs [3][]byte
+
+	for i, e := range []*entry{base, ours, theirs} {
+		b, err := e.contents()
+		if err != nil {
+			return nil, false, err
+		}
+
+		if isBinary(b) {
+			m.conflict(path, "binary file modified upstream and downstream", "")
+			return nil, false, nil
+		}
+
+		blobs[i] = b
+	}
+
+	merged, conflicts := Text(string(blobs[0]), string(blobs[1]), string(blobs[2]))
+
+	if len(conflicts) > 0 {
+		m.conflict(path, "content modified upstream and downstream", strings.Join(conflicts, "\n"))
+		return nil, false, nil
+	}
+
+	return []byte(merged), true, nil
+}
+
+func isBinary(b []byte) bool {
+	const sniffLen = 8000
+
+	if len(b) > sniffLen {
+		b = b[:sniffLen]
+	}
+
+	return bytes.IndexByte(b, 0) != -1
+}
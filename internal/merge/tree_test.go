@@ -0,0 +1,173 @@
+package merge
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/rh-ecosystem-edge/gitstream/internal/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func lines(n int, prefix string) string {
+	var sb strings.Builder
+
+	for i := 0; i < n; i++ {
+		sb.WriteString(prefix)
+		sb.WriteString(strings.Repeat("x", i))
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+func commitTree(t *testing.T, repo *git.Repository, fs billy.Filesystem, files map[string]*string) (plumbing.Hash, *object.Tree) {
+	t.Helper()
+
+	sha, commit := test.AddCommit(t, repo, fs, "commit", files)
+
+	tree, err := commit.Tree()
+	require.NoError(t, err)
+
+	return sha, tree
+}
+
+// threeTrees creates a base commit, then ours and theirs on top of it.
+func threeTrees(t *testing.T, base, ours, theirs map[string]*string) (*object.Tree, *object.Tree, *object.Tree) {
+	t.Helper()
+
+	repo, fs := test.NewRepoWithFS(t)
+
+	baseSHA, baseTree := commitTree(t, repo, fs, base)
+	_, oursTree := commitTree(t, repo, fs, ours)
+
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+
+	require.NoError(
+		t,
+		wt.Checkout(&git.CheckoutOptions{Hash: baseSHA, Force: true}),
+	)
+
+	_, theirsTree := commitTree(t, repo, fs, theirs)
+
+	return baseTree, oursTree, theirsTree
+}
+
+func TestTrees(t *testing.T) {
+	ctx := context.Background()
+
+	big := lines(20, "line")
+
+	t.Run("clean merge", func(t *testing.T) {
+		base, ours, theirs := threeTrees(
+			t,
+			map[string]*string{
+				"a.txt": strPtr("one\ntwo\nthree\nfour\nfive\nsix\n"),
+				"b.txt": strPtr(big),
+				"c.txt": strPtr("c\n"),
+				"d.txt": strPtr("d\n"),
+			},
+			map[string]*string{
+				"a.txt":     strPtr("ONE\ntwo\nthree\nfour\nfive\nsix\n"),
+				"b.txt":     nil,
+				"dir/b.txt": strPtr(big),
+				"d.txt":     nil,
+			},
+			map[string]*string{
+				"a.txt":   strPtr("one\ntwo\nthree\nfour\nfive\nSIX\n"),
+				"b.txt":   strPtr(big + "appended\n"),
+				"c.txt":   nil,
+				"d.txt":   nil,
+				"new.txt": strPtr("new\n"),
+			},
+		)
+
+		res, err := Trees(ctx, base, ours, theirs)
+		require.NoError(t, err)
+
+		assert.Empty(t, res.Conflicts)
+		assert.ElementsMatch(
+			t,
+			[]Change{
+				{Contents: []byte("ONE\ntwo\nthree\nfour\nfive\nSIX\n"), Mode: filemode.Regular, Path: "a.txt"},
+				{Contents: []byte(big + "appended\n"), Mode: filemode.Regular, Path: "dir/b.txt"},
+				{Delete: true, Path: "c.txt"},
+				{Contents: []byte("new\n"), Mode: filemode.Regular, Path: "new.txt"},
+			},
+			res.Changes,
+		)
+	})
+
+	t.Run("upstream rename of a file modified downstream", func(t *testing.T) {
+		modified := "modified\n" + big
+
+		base, ours, theirs := threeTrees(
+			t,
+			map[string]*string{"old.txt": strPtr(big)},
+			map[string]*string{"old.txt": strPtr(modified)},
+			map[string]*string{"old.txt": nil, "new.txt": strPtr(big)},
+		)
+
+		res, err := Trees(ctx, base, ours, theirs)
+		require.NoError(t, err)
+
+		assert.Empty(t, res.Conflicts)
+		assert.Equal(
+			t,
+			[]Change{
+				{Delete: true, Path: "old.txt"},
+				{Contents: []byte(modified), Mode: filemode.Regular, Path: "new.txt"},
+			},
+			res.Changes,
+		)
+	})
+
+	t.Run("conflicts", func(t *testing.T) {
+		base, ours, theirs := threeTrees(
+			t,
+			map[string]*string{
+				"content.txt": strPtr("one\ntwo\nthree\n"),
+				"deleted.txt": strPtr(big),
+			},
+			map[string]*string{
+				"content.txt": strPtr("one\nTWO\nthree\n"),
+				"deleted.txt": nil,
+				"added.txt":   strPtr("ours\n"),
+			},
+			map[string]*string{
+				"content.txt": strPtr("one\n2\nthree\n"),
+				"deleted.txt": strPtr(big + "appended\n"),
+				"added.txt":   strPtr("theirs\n"),
+			},
+		)
+
+		res, err := Trees(ctx, base, ours, theirs)
+		require.NoError(t, err)
+
+		assert.Empty(t, res.Changes)
+		assert.ElementsMatch(
+			t,
+			[]Conflict{
+				{Path: "added.txt", Reason: "added upstream and downstream with different contents"},
+				{
+					Details: "<<<<<<< downstream\nTWO\n=======\n2\n>>>>>>> upstream\n",
+					Path:    "content.txt",
+					Reason:  "content modified upstream and downstream",
+				},
+				{Path: "deleted.txt", Reason: "modified upstream and deleted downstream"},
+			},
+			res.Conflicts,
+		)
+	})
+}
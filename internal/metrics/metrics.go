@@ -0,0 +1,270 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	typeCounter = "counter"
+	typeGauge   = "gauge"
+)
+
+const (
+	apiCalls                     = "gitstream_github_api_calls_total"
+	apiRateLimitRemaining        = "gitstream_github_rate_limit_remaining"
+	cherryPickFailures           = "gitstream_cherry_pick_failures_total"
+	lastRunDuration              = "gitstream_last_run_duration_seconds"
+	lastRunIssuesCreated         = "gitstream_last_run_issues_created"
+	lastRunPRsCreated            = "gitstream_last_run_prs_created"
+	lastRunTimestamp             = "gitstream_last_run_timestamp_seconds"
+	maxOpenItems                 = "gitstream_max_open_items"
+	missingCommits               = "gitstream_missing_commits"
+	oldestMissingCommitTimestamp = "gitstream_oldest_missing_commit_timestamp_seconds"
+	openItems                    = "gitstream_open_items"
+	runs                         = "gitstream_runs_total"
+)
+
+type family struct {
+	help   string
+	name   string
+	series map[string]float64
+	typ    string
+}
+
+// Metrics holds the GitStream metrics and renders them in the Prometheus text exposition format.
+// All methods are safe for concurrent use. Recording methods do nothing on a nil receiver, so that callers do not need
+// to check whether metrics are enabled.
+type Metrics struct {
+	constLabels string
+	families    map[string]*family
+	mu          sync.Mutex
+	now         func() time.Time
+}
+
+func New(upstream, downstream string) *Metrics {
+	m := &Metrics{
+		constLabels: fmt.Sprintf("downstream=%s,upstream=%s", quote(downstream), quote(upstream)),
+		families:    make(map[string]*family),
+		now:         time.Now,
+	}
+
+	m.register(apiCalls, typeCounter, "Number of requests sent to the GitHub API.")
+	m.register(apiRateLimitRemaining, typeGauge, "Number of GitHub API requests remaining in the current rate limit window.")
+	m.register(cherryPickFailures, typeCounter, "Number of failed cherry-picks, by reason.")
+	m.register(lastRunDuration, typeGauge, "Duration of the last run.")
+	m.register(lastRunIssuesCreated, typeGauge, "Number of issues created during the last run.")
+	m.register(lastRunPRsCreated, typeGauge, "Number of pull requests created during the last run.")
+	m.register(lastRunTimestamp, typeGauge, "Time at which the last run finished, as a Unix timestamp.")
+	m.register(maxOpenItems, typeGauge, "Maximum number of open GitStream issues and pull requests; -1 means unlimited.")
+	m.register(missingCommits, typeGauge, "Number of upstream commits not found downstream.")
+	m.register(
+		oldestMissingCommitTimestamp,
+		typeGauge,
+		"Committer time of the oldest upstream commit not found downstream, as a Unix timestamp. Absent if no commit is missing.",
+	)
+	m.register(openItems, typeGauge, "Number of open GitStream issues and pull requests.")
+	m.register(runs, typeCounter, "Number of runs, by result.")
+
+	return m
+}
+
+func (m *Metrics) register(name, typ, help string) {
+	m.families[name] = &family{
+		help:   help,
+		name:   name,
+		series: make(map[string]float64),
+		typ:    typ,
+	}
+}
+
+// labels renders extra label pairs (name, value, name, value...) after the constant labels.
+func (m *Metrics) labels(pairs ...string) string {
+	items := []string{m.constLabels}
+
+	for i := 0; i+1 < len(pairs); i += 2 {
+		items = append(items, pairs[i]+"="+quote(pairs[i+1]))
+	}
+
+	return strings.Join(items, ",")
+}
+
+func (m *Metrics) set(name string, v float64, labelPairs ...string) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.families[name].series[m.labels(labelPairs...)] = v
+}
+
+func (m *Metrics) unset(name string, labelPairs ...string) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.families[name].series, m.labels(labelPairs...))
+}
+
+func (m *Metrics) add(name string, v float64, labelPairs ...string) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.families[name].series[m.labels(labelPairs...)] += v
+}
+
+// SetMissingCommits records the number of missing commits and the committer time of the oldest one, which is zero if
+// none is missing. The time is exported rather than the age, which would stop growing between runs.
+func (m *Metrics) SetMissingCommits(count int, oldest time.Time) {
+	m.set(missingCommits, float64(count))
+
+	if oldest.IsZero() {
+		m.unset(oldestMissingCommitTimestamp)
+		return
+	}
+
+	m.set(oldestMissingCommitTimestamp, float64(oldest.Unix()))
+}
+
+func (m *Metrics) SetOpenItems(open, max int) {
+	m.set(openItems, float64(open))
+	m.set(maxOpenItems, float64(max))
+}
+
+func (m *Metrics) IncCherryPickFailures(reason string) {
+	m.add(cherryPickFailures, 1, "reason", reason)
+}
+
+// ObserveRun records the outcome of a run.
+func (m *Metrics) ObserveRun(duration time.Duration, prsCreated, issuesCreated int, err error) {
+	if m == nil {
+		return
+	}
+
+	result := "success"
+
+	if err != nil {
+		result = "failure"
+	}
+
+	m.add(runs, 1, "result", result)
+	m.set(lastRunDuration, duration.Seconds())
+	m.set(lastRunPRsCreated, float64(prsCreated))
+	m.set(lastRunIssuesCreated, float64(issuesCreated))
+	m.set(lastRunTimestamp, float64(m.now().Unix()))
+}
+
+// ObserveAPIResponse records a GitHub API call and the remaining rate limit advertised in the response headers, if any.
+func (m *Metrics) ObserveAPIResponse(res *http.Response) {
+	m.add(apiCalls, 1)
+
+	if res == nil {
+		return
+	}
+
+	if remaining, err := strconv.Atoi(res.Header.Get("X-RateLimit-Remaining")); err == nil {
+		m.set(apiRateLimitRemaining, float64(remaining))
+	}
+}
+
+// WriteTo writes all metrics that have at least one value in the Prometheus text exposition format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, len(m.families))
+
+	for n := range m.families {
+		names = append(names, n)
+	}
+
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+
+	for _, n := range names {
+		f := m.families[n]
+
+		if len(f.series) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&buf, "# HELP %s %s\n", f.name, f.help)
+		fmt.Fprintf(&buf, "# TYPE %s %s\n", f.name, f.typ)
+
+		labelSets := make([]string, 0, len(f.series))
+
+		for ls := range f.series {
+			labelSets = append(labelSets, ls)
+		}
+
+		sort.Strings(labelSets)
+
+		for _, ls := range labelSets {
+			fmt.Fprintf(&buf, "%s{%s} %s\n", f.name, ls, strconv.FormatFloat(f.series[ls], 'g', -1, 64))
+		}
+	}
+
+	return buf.WriteTo(w)
+}
+
+// Handler returns an HTTP handler serving the metrics.
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+		_, _ = m.WriteTo(w)
+	})
+}
+
+// WriteTextfile atomically writes the metrics to path, for collection by the node-exporter textfile collector.
+func (m *Metrics) WriteTextfile(path string) error {
+	fd, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("could not create a temporary file: %v", err)
+	}
+	defer os.Remove(fd.Name())
+
+	if _, err = m.WriteTo(fd); err != nil {
+		fd.Close()
+		return fmt.Errorf("could not write metrics: %v", err)
+	}
+
+	if err = fd.Close(); err != nil {
+		return fmt.Errorf("could not close %s: %v", fd.Name(), err)
+	}
+
+	if err = os.Chmod(fd.Name(), 0644); err != nil {
+		return fmt.Errorf("could not change the mode of %s: %v", fd.Name(), err)
+	}
+
+	if err = os.Rename(fd.Name(), path); err != nil {
+		return fmt.Errorf("could not rename %s to %s: %v", fd.Name(), path, err)
+	}
+
+	return nil
+}
+
+func quote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+	return `"` + r.Replace(s) + `"`
+}
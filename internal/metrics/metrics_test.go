@@ -0,0 +1,122 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	now := time.Date(2022, 12, 2, 0, 0, 0, 0, time.UTC)
+
+	m := New("https://github.com/owner/upstream", "owner/downstream")
+	m.now = func() time.Time { return now }
+
+	m.SetMissingCommits(3, now.Add(-time.Hour))
+	m.SetOpenItems(2, 5)
+	m.IncCherryPickFailures("cherry-pick")
+	m.IncCherryPickFailures("cherry-pick")
+	m.IncCherryPickFailures("before-commit")
+	m.ObserveRun(90*time.Second, 1, 2, nil)
+	m.ObserveRun(30*time.Second, 0, 1, errors.New("random error"))
+
+	res := &http.Response{Header: http.Header{}}
+	res.Header.Set("X-RateLimit-Remaining", "4999")
+	m.ObserveAPIResponse(res)
+	m.ObserveAPIResponse(nil)
+
+	const labels = `downstream="owner/downstream",upstream="https://github.com/owner/upstream"`
+
+	expected := []string{
+		`gitstream_cherry_pick_failures_total{` + labels + `,reason="before-commit"} 1`,
+		`gitstream_cherry_pick_failures_total{` + labels + `,reason="cherry-pick"} 2`,
+		`gitstream_github_api_calls_total{` + labels + `} 2`,
+		`gitstream_github_rate_limit_remaining{` + labels + `} 4999`,
+		`gitstream_last_run_duration_seconds{` + labels + `} 30`,
+		`gitstream_last_run_issues_created{` + labels + `} 1`,
+		`gitstream_last_run_prs_created{` + labels + `} 0`,
+		`gitstream_last_run_timestamp_seconds{` + labels + `} 1.6699392e+09`,
+		`gitstream_max_open_items{` + labels + `} 5`,
+		`gitstream_missing_commits{` + labels + `} 3`,
+		`gitstream_oldest_missing_commit_timestamp_seconds{` + labels + `} 1.6699356e+09`,
+		`gitstream_open_items{` + labels + `} 2`,
+		`gitstream_runs_total{` + labels + `,result="failure"} 1`,
+		`gitstream_runs_total{` + labels + `,result="success"} 1`,
+		"# TYPE gitstream_runs_total counter",
+		"# TYPE gitstream_open_items gauge",
+	}
+
+	t.Run("handler", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		body := rec.Body.String()
+
+		for _, e := range expected {
+			assert.Contains(t, body, e+"\n")
+		}
+	})
+
+	t.Run("textfile", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "gitstream.prom")
+
+		require.NoError(t, m.WriteTextfile(path))
+
+		b, err := os.ReadFile(path)
+		require.NoError(t, err)
+
+		for _, e := range expected {
+			assert.Contains(t, string(b), e+"\n")
+		}
+
+		entries, err := os.ReadDir(filepath.Dir(path))
+		require.NoError(t, err)
+		assert.Len(t, entries, 1, "temporary files should be cleaned up")
+	})
+
+	t.Run("the oldest missing commit is unset when no commit is missing", func(t *testing.T) {
+		m := New("us", "ds")
+		m.SetMissingCommits(1, now)
+		m.SetMissingCommits(0, time.Time{})
+
+		var sb strings.Builder
+
+		_, err := m.WriteTo(&sb)
+		require.NoError(t, err)
+		assert.Contains(t, sb.String(), "gitstream_missing_commits{")
+		assert.NotContains(t, sb.String(), "gitstream_oldest_missing_commit_timestamp_seconds{")
+	})
+
+	t.Run("families without values are omitted", func(t *testing.T) {
+		var sb strings.Builder
+
+		_, err := New("us", "ds").WriteTo(&sb)
+		require.NoError(t, err)
+		assert.Empty(t, sb.String())
+	})
+
+	t.Run("nil receiver", func(t *testing.T) {
+		var nilMetrics *Metrics
+
+		assert.NotPanics(t, func() {
+			nilMetrics.SetMissingCommits(1, now)
+			nilMetrics.SetOpenItems(1, 1)
+			nilMetrics.IncCherryPickFailures("reason")
+			nilMetrics.ObserveRun(time.Second, 1, 1, nil)
+			nilMetrics.ObserveAPIResponse(nil)
+		})
+
+		assert.Equal(t, http.DefaultTransport, nilMetrics.WrapTransport(http.DefaultTransport))
+	})
+}
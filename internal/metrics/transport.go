@@ -0,0 +1,29 @@
+package metrics
+
+import "net/http"
+
+type transport struct {
+	base    http.RoundTripper
+	metrics *Metrics
+}
+
+// WrapTransport returns an http.RoundTripper that records every request sent through base as a GitHub API call.
+func (m *Metrics) WrapTransport(base http.RoundTripper) http.RoundTripper {
+	if m == nil {
+		return base
+	}
+
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &transport{base: base, metrics: m}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.base.RoundTrip(req)
+
+	t.metrics.ObserveAPIResponse(res)
+
+	return res, err
+}
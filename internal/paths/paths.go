@@ -0,0 +1,57 @@
+package paths
+
+import (
+	"path"
+	"strings"
+)
+
+// Match returns the first pattern that matches name, or an empty string.
+// Patterns are matched against name one slash-separated segment at a time: a ** segment matches any number of
+// directories, including none, and other segments are matched with path.Match, so * does not match a slash.
+// Patterns ending with a slash match everything under that directory.
+func Match(patterns []string, name string) string {
+	nameSegments := strings.Split(name, "/")
+
+	for _, p := range patterns {
+		if dir := strings.TrimSuffix(p, "/"); dir != p {
+			// name is under dir if its parent directory matches dir/**.
+			if match(append(strings.Split(dir, "/"), "**"), nameSegments[:len(nameSegments)-1]) {
+				return p
+			}
+
+			continue
+		}
+
+		if match(strings.Split(p, "/"), nameSegments) {
+			return p
+		}
+	}
+
+	return ""
+}
+
+func match(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if match(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+
+		pattern, name = pattern[1:], name[1:]
+	}
+
+	return len(name) == 0
+}
@@ -0,0 +1,34 @@
+package paths
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	patterns := []string{"vendor/", "*.md", "docs/*.txt", "api/**/*.proto", "**/testdata/", "build/**"}
+
+	cases := map[string]string{
+		"vendor/a/b.go":           "vendor/",
+		"README.md":               "*.md",
+		"docs/README.md":          "",
+		"docs/file.txt":           "docs/*.txt",
+		"docs/sub/file.txt":       "",
+		"vendored.go":             "",
+		"vendor":                  "",
+		"api/a.proto":             "api/**/*.proto",
+		"api/v1/sub/a.proto":      "api/**/*.proto",
+		"api/v1/a.go":             "",
+		"apis/a.proto":            "",
+		"testdata/a.yml":          "**/testdata/",
+		"pkg/x/testdata/a/b.yml":  "**/testdata/",
+		"pkg/testdata":            "",
+		"build/Dockerfile":        "build/**",
+		"build/images/Dockerfile": "build/**",
+	}
+
+	for name, expected := range cases {
+		assert.Equal(t, expected, Match(patterns, name), name)
+	}
+}
@@ -0,0 +1,98 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	gh "github.com/rh-ecosystem-edge/gitstream/internal/github"
+	"github.com/rh-ecosystem-edge/gitstream/internal/report"
+)
+
+// Version is the version of the plan format. Plans with a different version are rejected.
+const Version = 1
+
+type ActionType string
+
+const (
+	// ActionIssue means that the commit does not cherry-pick cleanly and that an issue is created.
+	ActionIssue ActionType = "issue"
+	// ActionPR means that the commit cherry-picks cleanly, and that its branch is pushed and a PR is created.
+	ActionPR ActionType = "pr"
+	// ActionSkip means that the commit is not processed.
+	ActionSkip ActionType = "skip"
+)
+
+// Action is what is done for one missing upstream commit.
+type Action struct {
+	// AutoMerge is true if auto-merge is enabled on the PR.
+	AutoMerge bool        `json:"auto_merge,omitempty"`
+	Branch    string      `json:"branch,omitempty"`
+	Content   *gh.Content `json:"content,omitempty"`
+	Draft     bool        `json:"draft,omitempty"`
+	// Error is the reason why the commit does not cherry-pick cleanly.
+	Error string `json:"error,omitempty"`
+	// SkipReason is set for skipped commits.
+	SkipReason report.Outcome `json:"skip_reason,omitempty"`
+	SHA        string         `json:"sha"`
+	Subject    string         `json:"subject"`
+	// Tree is the hash of the tree of the cherry-picked commit. Apply checks that it gets the same tree.
+	Tree string     `json:"tree,omitempty"`
+	Type ActionType `json:"type"`
+}
+
+type Downstream struct {
+	MainBranch string `json:"main_branch"`
+	Repo       string `json:"repo"`
+	// SHA is the tip of the main branch when the plan was made.
+	SHA string `json:"sha"`
+}
+
+type Upstream struct {
+	Ref string `json:"ref"`
+	// SHA is the commit that Ref pointed to when the plan was made.
+	SHA string `json:"sha"`
+	URL string `json:"url"`
+}
+
+// Plan is the list of actions that a sync run would take, in order.
+type Plan struct {
+	Actions    []*Action  `json:"actions"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Downstream Downstream `json:"downstream"`
+	Upstream   Upstream   `json:"upstream"`
+	Version    int        `json:"version"`
+}
+
+func Read(path string) (*Plan, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %v", path, err)
+	}
+
+	p := Plan{}
+
+	if err = json.Unmarshal(b, &p); err != nil {
+		return nil, fmt.Errorf("could not unmarshal the plan: %v", err)
+	}
+
+	if p.Version != Version {
+		return nil, fmt.Errorf("unsupported plan version %d, expected %d", p.Version, Version)
+	}
+
+	return &p, nil
+}
+
+func (p *Plan) Write(path string) error {
+	b, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not marshal the plan: %v", err)
+	}
+
+	if err = os.WriteFile(path, append(b, '\n'), 0644); err != nil {
+		return fmt.Errorf("could not write %s: %v", path, err)
+	}
+
+	return nil
+}
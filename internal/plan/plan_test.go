@@ -0,0 +1,55 @@
+package plan
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gh "github.com/rh-ecosystem-edge/gitstream/internal/github"
+	"github.com/rh-ecosystem-edge/gitstream/internal/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlan_WriteRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.json")
+
+	p := &Plan{
+		Actions: []*Action{
+			{
+				Branch:  "gs-e3229f3c533ed51070beff092e5c7694a8ee81f0",
+				Content: &gh.Content{Body: "some body", Title: "some title"},
+				SHA:     "e3229f3c533ed51070beff092e5c7694a8ee81f0",
+				Subject: "Picked commit",
+				Tree:    "9049f1265b7d61be4a8904a9a27120d2064dab3b",
+				Type:    ActionPR,
+			},
+			{
+				SHA:        "0d1a26e67d8f5eaf1f6ba5c57fc3c7d91ac0fd1c",
+				SkipReason: report.OutcomeSkippedIgnoredAuthor,
+				Subject:    "Ignored commit",
+				Type:       ActionSkip,
+			},
+		},
+		CreatedAt:  time.Date(2022, 12, 1, 0, 0, 0, 0, time.UTC),
+		Downstream: Downstream{MainBranch: "main", Repo: "owner/repo", SHA: "9c08d42326af62aa0f8cea021c4d37971606148f"},
+		Upstream:   Upstream{Ref: "main", SHA: "e3229f3c533ed51070beff092e5c7694a8ee81f0", URL: "https://github.com/upstream/repo"},
+		Version:    Version,
+	}
+
+	require.NoError(t, p.Write(path))
+
+	res, err := Read(path)
+	require.NoError(t, err)
+	assert.Equal(t, p, res)
+}
+
+func TestRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.json")
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 0, "actions": []}`), 0644))
+
+	_, err := Read(path)
+	assert.EqualError(t, err, "unsupported plan version 0, expected 1")
+}
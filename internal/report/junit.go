@@ -0,0 +1,102 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+type junitFailure struct {
+	Message  string `xml:"message,attr"`
+	Type     string `xml:"type,attr"`
+	Contents string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Name      string        `xml:"name,attr"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+	Time      string        `xml:"time,attr"`
+}
+
+type junitTestSuite struct {
+	Failures  int             `xml:"failures,attr"`
+	Name      string          `xml:"name,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+	Tests     int             `xml:"tests,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+func formatSeconds(s float64) string {
+	return strconv.FormatFloat(s, 'f', 3, 64)
+}
+
+// WriteJUnit writes the report as JUnit XML, with one test case per candidate commit.
+// Failed commits are reported as failures; skipped and unprocessed commits as skipped.
+func (r *Report) WriteJUnit(path string) error {
+	suite := junitTestSuite{
+		Name:      "gitstream sync " + r.Upstream.URL,
+		TestCases: make([]junitTestCase, 0, len(r.Commits)),
+		Tests:     len(r.Commits),
+		Time:      formatSeconds(r.DurationSeconds),
+		Timestamp: r.StartedAt.UTC().Format("2006-01-02T15:04:05"),
+	}
+
+	for _, c := range r.Commits {
+		tc := junitTestCase{
+			ClassName: r.Upstream.URL,
+			Name:      c.SHA + " " + c.Subject,
+			Time:      formatSeconds(c.DurationSeconds),
+		}
+
+		switch c.Outcome {
+		case OutcomeConflicted, OutcomeFailed:
+			suite.Failures++
+
+			tc.Failure = &junitFailure{
+				Message:  c.Error,
+				Type:     string(c.Outcome),
+				Contents: c.Output,
+			}
+
+			if c.IssueURL != "" {
+				tc.SystemOut = "Issue: " + c.IssueURL
+			}
+		case OutcomeEmpty, OutcomeNotProcessed, OutcomeSkippedIgnoredAuthor, OutcomeSkippedMaxItems, OutcomeSkippedRule:
+			suite.Skipped++
+
+			tc.Skipped = &junitSkipped{Message: string(c.Outcome)}
+		case OutcomePicked:
+			tc.SystemOut = "PR: " + c.PRURL
+		}
+
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+
+	b, err := xml.MarshalIndent(junitTestSuites{Suites: []junitTestSuite{suite}}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not marshal the JUnit report: %v", err)
+	}
+
+	b = append([]byte(xml.Header), b...)
+
+	if err = os.WriteFile(path, append(b, '\n'), 0644); err != nil {
+		return fmt.Errorf("could not write %s: %v", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/rh-ecosystem-edge/gitstream/internal/process"
+)
+
+type Outcome string
+
+const (
+	OutcomeApplied              Outcome = "applied"
+	OutcomeConflicted           Outcome = "conflicted"
+	OutcomeDryRun               Outcome = "dry-run"
+	OutcomeEmpty                Outcome = "empty"
+	OutcomeFailed               Outcome = "failed"
+	OutcomeNotProcessed         Outcome = "not-processed"
+	OutcomePicked               Outcome = "picked"
+	OutcomeSkippedIgnoredAuthor Outcome = "skipped-ignored-author"
+	OutcomeSkippedMaxItems      Outcome = "skipped-max-items"
+	OutcomeSkippedRule          Outcome = "skipped-rule"
+)
+
+type CommitResult struct {
+	Author          string    `json:"author"`
+	Command         string    `json:"command,omitempty"`
+	CommittedAt     time.Time `json:"committed_at"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	Error           string    `json:"error,omitempty"`
+	IssueURL        string    `json:"issue_url,omitempty"`
+	Outcome         Outcome   `json:"outcome"`
+	Output          string    `json:"output,omitempty"`
+	PRURL           string    `json:"pr_url,omitempty"`
+	Rule            string    `json:"rule,omitempty"`
+	SHA             string    `json:"sha"`
+	Subject         string    `json:"subject"`
+
+	start time.Time
+}
+
+// Begin marks the start of the processing of the commit.
+func (cr *CommitResult) Begin() {
+	cr.start = time.Now()
+}
+
+// SetOutcome records the outcome and the time elapsed since Begin was called.
+func (cr *CommitResult) SetOutcome(o Outcome) {
+	cr.Outcome = o
+
+	if !cr.start.IsZero() {
+		cr.DurationSeconds = time.Since(cr.start).Seconds()
+	}
+}
+
+// SetError records err, as well as the command and its output if err wraps a process.Error.
+func (cr *CommitResult) SetError(err error) {
+	cr.Error = err.Error()
+
+	pe := &process.Error{}
+
+	if errors.As(err, &pe) {
+		cr.Command = pe.Command()
+		cr.Output = pe.CombinedString()
+	}
+}
+
+type Downstream struct {
+	MainBranch string `json:"main_branch"`
+	Repo       string `json:"repo"`
+}
+
+type Upstream struct {
+	Ref string `json:"ref"`
+	URL string `json:"url"`
+}
+
+// Report describes the outcome of a sync run for every candidate commit.
+type Report struct {
+	Commits         []*CommitResult `json:"commits"`
+	Downstream      Downstream      `json:"downstream"`
+	DryRun          bool            `json:"dry_run"`
+	DurationSeconds float64         `json:"duration_seconds"`
+	Error           string          `json:"error,omitempty"`
+	FinishedAt      time.Time       `json:"finished_at"`
+	StartedAt       time.Time       `json:"started_at"`
+	Upstream        Upstream        `json:"upstream"`
+}
+
+func (r *Report) Begin() {
+	r.StartedAt = time.Now()
+	r.Commits = make([]*CommitResult, 0)
+}
+
+// Finish records the end of the run and its error, if any.
+func (r *Report) Finish(err error) {
+	r.FinishedAt = time.Now()
+	r.DurationSeconds = r.FinishedAt.Sub(r.StartedAt).Seconds()
+
+	if err != nil {
+		r.Error = err.Error()
+	}
+}
+
+// AddCommit adds a candidate commit to the report with the not-processed outcome.
+func (r *Report) AddCommit(c *object.Commit) *CommitResult {
+	cr := &CommitResult{
+		Author:      c.Author.String(),
+		CommittedAt: c.Committer.When,
+		Outcome:     OutcomeNotProcessed,
+		SHA:         c.Hash.String(),
+		Subject:     strings.SplitN(c.Message, "\n", 2)[0],
+	}
+
+	r.Commits = append(r.Commits, cr)
+
+	return cr
+}
+
+func (r *Report) WriteJSON(path string) error {
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not marshal the report: %v", err)
+	}
+
+	if err = os.WriteFile(path, append(b, '\n'), 0644); err != nil {
+		return fmt.Errorf("could not write %s: %v", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/rh-ecosystem-edge/gitstream/internal/process"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestReport(t *testing.T) *Report {
+	t.Helper()
+
+	r := &Report{
+		Downstream: Downstream{MainBranch: "main", Repo: "owner/repo"},
+		Upstream:   Upstream{Ref: "main", URL: "https://github.com/upstream/repo"},
+	}
+
+	r.Begin()
+
+	newCommit := func(sha, msg string) *object.Commit {
+		return &object.Commit{
+			Author:    object.Signature{Name: "Some Author", Email: "author@example.com"},
+			Committer: object.Signature{When: time.Date(2022, 12, 1, 0, 0, 0, 0, time.UTC)},
+			Hash:      plumbing.NewHash(sha),
+			Message:   msg,
+		}
+	}
+
+	picked := r.AddCommit(newCommit("e3229f3c533ed51070beff092e5c7694a8ee81f0", "Picked commit\n\nWith a body."))
+	picked.Begin()
+	picked.PRURL = "some-pr-url"
+	picked.SetOutcome(OutcomePicked)
+
+	cmd := exec.CommandContext(context.Background(), "false")
+	ee := &exec.ExitError{}
+	require.ErrorAs(t, cmd.Run(), &ee)
+
+	failed := r.AddCommit(newCommit("9c08d42326af62aa0f8cea021c4d37971606148f", "Failed commit"))
+	failed.Begin()
+	failed.SetError(
+		errors.Join(errors.New("could not cherry-pick"), process.NewError(ee, []byte("some output"), "git cherry-pick")),
+	)
+	failed.IssueURL = "some-issue-url"
+	failed.SetOutcome(OutcomeFailed)
+
+	ignored := r.AddCommit(newCommit("0d1a26e67d8f5eaf1f6ba5c57fc3c7d91ac0fd1c", "Ignored commit"))
+	ignored.SetOutcome(OutcomeSkippedIgnoredAuthor)
+
+	r.AddCommit(newCommit("9049f1265b7d61be4a8904a9a27120d2064dab3b", "Unprocessed commit"))
+
+	r.Finish(errors.New("random error"))
+
+	return r
+}
+
+func TestReport_WriteJSON(t *testing.T) {
+	r := newTestReport(t)
+
+	path := filepath.Join(t.TempDir(), "report.json")
+
+	require.NoError(t, r.WriteJSON(path))
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	res := Report{}
+
+	require.NoError(t, json.Unmarshal(b, &res))
+
+	assert.Equal(t, "random error", res.Error)
+	assert.Equal(t, "owner/repo", res.Downstream.Repo)
+	require.Len(t, res.Commits, 4)
+
+	assert.Equal(t, OutcomePicked, res.Commits[0].Outcome)
+	assert.Equal(t, "Picked commit", res.Commits[0].Subject)
+	assert.Equal(t, "some-pr-url", res.Commits[0].PRURL)
+	assert.Equal(t, "Some Author <author@example.com>", res.Commits[0].Author)
+
+	assert.Equal(t, OutcomeFailed, res.Commits[1].Outcome)
+	assert.Equal(t, "git cherry-pick", res.Commits[1].Command)
+	assert.Equal(t, "some output", res.Commits[1].Output)
+	assert.Equal(t, "some-issue-url", res.Commits[1].IssueURL)
+	assert.Contains(t, res.Commits[1].Error, "could not cherry-pick")
+
+	assert.Equal(t, OutcomeSkippedIgnoredAuthor, res.Commits[2].Outcome)
+	assert.Equal(t, OutcomeNotProcessed, res.Commits[3].Outcome)
+}
+
+func TestReport_WriteJUnit(t *testing.T) {
+	r := newTestReport(t)
+
+	path := filepath.Join(t.TempDir(), "junit.xml")
+
+	require.NoError(t, r.WriteJUnit(path))
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	res := junitTestSuites{}
+
+	require.NoError(t, xml.Unmarshal(b, &res))
+	require.Len(t, res.Suites, 1)
+
+	suite := res.Suites[0]
+
+	assert.Equal(t, 4, suite.Tests)
+	assert.Equal(t, 1, suite.Failures)
+	assert.Equal(t, 2, suite.Skipped)
+	require.Len(t, suite.TestCases, 4)
+
+	assert.Nil(t, suite.TestCases[0].Failure)
+	assert.Nil(t, suite.TestCases[0].Skipped)
+
+	require.NotNil(t, suite.TestCases[1].Failure)
+	assert.Equal(t, "some output", suite.TestCases[1].Failure.Contents)
+
+	require.NotNil(t, suite.TestCases[2].Skipped)
+	assert.Equal(t, string(OutcomeSkippedIgnoredAuthor), suite.TestCases[2].Skipped.Message)
+}
+
+func TestReport_WriteJUnit_Rebase(t *testing.T) {
+	r := &Report{}
+	r.Begin()
+
+	for i, o := range []Outcome{OutcomeApplied, OutcomeConflicted, OutcomeEmpty} {
+		c := r.AddCommit(&object.Commit{Message: fmt.Sprintf("carry %d", i)})
+		c.SetOutcome(o)
+	}
+
+	r.Finish(nil)
+
+	path := filepath.Join(t.TempDir(), "junit.xml")
+
+	require.NoError(t, r.WriteJUnit(path))
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	res := junitTestSuites{}
+
+	require.NoError(t, xml.Unmarshal(b, &res))
+	require.Len(t, res.Suites, 1)
+
+	suite := res.Suites[0]
+
+	assert.Equal(t, 1, suite.Failures)
+	assert.Equal(t, 1, suite.Skipped)
+	require.Len(t, suite.TestCases, 3)
+	assert.Nil(t, suite.TestCases[0].Failure)
+	assert.NotNil(t, suite.TestCases[1].Failure)
+	assert.NotNil(t, suite.TestCases[2].Skipped)
+}
@@ -0,0 +1,239 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/rh-ecosystem-edge/gitstream/internal/config"
+	gh "github.com/rh-ecosystem-edge/gitstream/internal/github"
+	"github.com/rh-ecosystem-edge/gitstream/internal/hooks"
+	"github.com/rh-ecosystem-edge/gitstream/internal/paths"
+)
+
+// Decision is the result of evaluating the rules for a commit.
+type Decision struct {
+	Include bool
+	// Rule is the name of the rule that matched the commit, or an empty string if no rule matched.
+	Rule string
+}
+
+type rule struct {
+	authors   []*regexp.Regexp
+	cfg       config.Rule
+	name      string
+	subject   *regexp.Regexp
+	usesLabel bool
+}
+
+// Engine evaluates an ordered list of include and exclude rules against upstream commits. A nil *Engine includes
+// every commit.
+type Engine struct {
+	labels map[string][]string
+	now    func() time.Time
+	rules  []rule
+	uh     gh.UpstreamHelper
+}
+
+// New compiles cfg. uh is only used to get the labels of upstream PRs; it may be nil if no rule has labels.
+func New(cfg []config.Rule, uh gh.UpstreamHelper) (*Engine, error) {
+	e := Engine{
+		labels: make(map[string][]string),
+		now:    time.Now,
+		rules:  make([]rule, 0, len(cfg)),
+		uh:     uh,
+	}
+
+	for i, rc := range cfg {
+		r := rule{
+			authors:   make([]*regexp.Regexp, 0, len(rc.Authors)),
+			cfg:       rc,
+			name:      rc.Name,
+			usesLabel: len(rc.Labels) > 0,
+		}
+
+		if r.name == "" {
+			r.name = "rules[" + strconv.Itoa(i) + "]"
+		}
+
+		if rc.Action != config.RuleActionInclude && rc.Action != config.RuleActionExclude {
+			return nil, fmt.Errorf("%s: %q: invalid action", r.name, rc.Action)
+		}
+
+		for _, a := range rc.Authors {
+			re, err := regexp.Compile(a)
+			if err != nil {
+				return nil, fmt.Errorf("%s: could not compile %q: %v", r.name, a, err)
+			}
+
+			r.authors = append(r.authors, re)
+		}
+
+		if rc.Subject != "" {
+			re, err := regexp.Compile(rc.Subject)
+			if err != nil {
+				return nil, fmt.Errorf("%s: could not compile %q: %v", r.name, rc.Subject, err)
+			}
+
+			r.subject = re
+		}
+
+		if r.usesLabel && uh == nil {
+			return nil, fmt.Errorf("%s: labels require an upstream repository on GitHub", r.name)
+		}
+
+		e.rules = append(e.rules, r)
+	}
+
+	return &e, nil
+}
+
+// Evaluate returns the decision of the first rule that matches commit. Commits that match no rule are included.
+func (e *Engine) Evaluate(ctx context.Context, commit *object.Commit) (Decision, error) {
+	if e == nil {
+		return Decision{Include: true}, nil
+	}
+
+	// Changed files are only computed if a rule needs them, and at most once.
+	var changedFiles []string
+
+	for _, r := range e.rules {
+		ok, err := e.matches(ctx, r, commit, &changedFiles)
+		if err != nil {
+			return Decision{}, fmt.Errorf("%s: %v", r.name, err)
+		}
+
+		if ok {
+			return Decision{Include: r.cfg.Action == config.RuleActionInclude, Rule: r.name}, nil
+		}
+	}
+
+	return Decision{Include: true}, nil
+}
+
+// matches checks the cheap conditions first, so that the changed files and the labels are only fetched if needed.
+func (e *Engine) matches(ctx context.Context, r rule, commit *object.Commit, changedFiles *[]string) (bool, error) {
+	cfg := r.cfg
+
+	if len(r.authors) > 0 && !matchAuthor(r.authors, commit.Author) {
+		return false, nil
+	}
+
+	if cfg.Bot != nil && *cfg.Bot != IsBot(commit.Author) {
+		return false, nil
+	}
+
+	if cfg.Merge != nil && *cfg.Merge != (commit.NumParents() > 1) {
+		return false, nil
+	}
+
+	age := e.now().Sub(commit.Committer.When)
+
+	if cfg.NewerThan > 0 && age >= cfg.NewerThan {
+		return false, nil
+	}
+
+	if cfg.OlderThan > 0 && age <= cfg.OlderThan {
+		return false, nil
+	}
+
+	if r.subject != nil && !r.subject.MatchString(subject(commit.Message)) {
+		return false, nil
+	}
+
+	if len(cfg.Paths) > 0 {
+		if *changedFiles == nil {
+			files, err := hooks.ChangedFiles(commit)
+			if err != nil {
+				return false, fmt.Errorf("could not get the files changed by %s: %v", commit.Hash, err)
+			}
+
+			*changedFiles = files
+		}
+
+		if !matchAnyPath(cfg.Paths, *changedFiles) {
+			return false, nil
+		}
+	}
+
+	if r.usesLabel {
+		labels, err := e.prLabels(ctx, commit.Hash.String())
+		if err != nil {
+			return false, err
+		}
+
+		if !containsAny(labels, cfg.Labels) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func (e *Engine) prLabels(ctx context.Context, sha string) ([]string, error) {
+	if labels, ok := e.labels[sha]; ok {
+		return labels, nil
+	}
+
+	pr, err := e.uh.GetCommitPR(ctx, sha)
+	if err != nil {
+		return nil, err
+	}
+
+	// Commits that are not associated with a PR have no labels.
+	labels := make([]string, 0)
+
+	if pr != nil {
+		labels = pr.Labels
+	}
+
+	e.labels[sha] = labels
+
+	return labels, nil
+}
+
+// IsBot returns true if sig is a GitHub bot account, such as dependabot[bot].
+func IsBot(sig object.Signature) bool {
+	return strings.HasSuffix(sig.Name, "[bot]") || strings.Contains(sig.Email, "[bot]@")
+}
+
+func matchAuthor(res []*regexp.Regexp, sig object.Signature) bool {
+	for _, re := range res {
+		if re.MatchString(sig.Name) || re.MatchString(sig.Email) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchAnyPath(patterns, names []string) bool {
+	for _, n := range names {
+		if paths.Match(patterns, n) != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsAny(values, wanted []string) bool {
+	for _, v := range values {
+		for _, w := range wanted {
+			if v == w {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func subject(message string) string {
+	s, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	return s
+}
@@ -0,0 +1,150 @@
+package rules
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/golang/mock/gomock"
+	"github.com/rh-ecosystem-edge/gitstream/internal/config"
+	gh "github.com/rh-ecosystem-edge/gitstream/internal/github"
+	"github.com/rh-ecosystem-edge/gitstream/internal/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_Evaluate(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	yes := true
+
+	newCommit := func(name, email, message string, age time.Duration) *object.Commit {
+		return &object.Commit{
+			Author:    object.Signature{Email: email, Name: name},
+			Committer: object.Signature{When: now.Add(-age)},
+			Message:   message,
+		}
+	}
+
+	cfg := []config.Rule{
+		{Action: config.RuleActionInclude, Authors: []string{`@example\.com$`}, Name: "trusted"},
+		{Action: config.RuleActionExclude, Name: "docs", Subject: `^docs:`},
+		{Action: config.RuleActionExclude, Bot: &yes},
+		{Action: config.RuleActionExclude, OlderThan: 24 * time.Hour},
+	}
+
+	e, err := New(cfg, nil)
+	require.NoError(t, err)
+
+	e.now = func() time.Time { return now }
+
+	cases := map[string]struct {
+		commit   *object.Commit
+		expected Decision
+	}{
+		"first matching rule wins": {
+			commit:   newCommit("Someone", "someone@example.com", "docs: fix typo", time.Hour),
+			expected: Decision{Include: true, Rule: "trusted"},
+		},
+		"subject": {
+			commit:   newCommit("Someone", "someone@other.com", "docs: fix typo\n\ndocs: body", time.Hour),
+			expected: Decision{Include: false, Rule: "docs"},
+		},
+		"bot": {
+			commit:   newCommit("dependabot[bot]", "49699333+dependabot[bot]@users.noreply.github.com", "Bump x", time.Hour),
+			expected: Decision{Include: false, Rule: "rules[2]"},
+		},
+		"age": {
+			commit:   newCommit("Someone", "someone@other.com", "Old change", 48*time.Hour),
+			expected: Decision{Include: false, Rule: "rules[3]"},
+		},
+		"no matching rule": {
+			commit:   newCommit("Someone", "someone@other.com", "New change", time.Hour),
+			expected: Decision{Include: true},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			d, err := e.Evaluate(ctx, c.commit)
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, d)
+		})
+	}
+
+	t.Run("nil engine", func(t *testing.T) {
+		d, err := (*Engine)(nil).Evaluate(ctx, newCommit("Someone", "", "", 0))
+		require.NoError(t, err)
+		assert.Equal(t, Decision{Include: true}, d)
+	})
+}
+
+func TestEngine_Evaluate_Paths(t *testing.T) {
+	ctx := context.Background()
+
+	repo, fs := test.NewRepoWithFS(t)
+	content := "content"
+
+	_, code := test.AddCommit(t, repo, fs, "code", map[string]*string{"pkg/file.go": &content})
+	_, docs := test.AddCommit(t, repo, fs, "docs", map[string]*string{"docs/README.md": &content})
+
+	e, err := New([]config.Rule{{Action: config.RuleActionExclude, Paths: []string{"docs/", "*.md"}}}, nil)
+	require.NoError(t, err)
+
+	d, err := e.Evaluate(ctx, code)
+	require.NoError(t, err)
+	assert.True(t, d.Include)
+
+	d, err = e.Evaluate(ctx, docs)
+	require.NoError(t, err)
+	assert.False(t, d.Include)
+}
+
+func TestEngine_Evaluate_Labels(t *testing.T) {
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	mockUpstreamHelper := gh.NewMockUpstreamHelper(ctrl)
+
+	commit := &object.Commit{Message: "Some change"}
+	sha := commit.Hash.String()
+
+	e, err := New(
+		[]config.Rule{
+			{Action: config.RuleActionExclude, Labels: []string{"do-not-sync"}, Name: "label"},
+			{Action: config.RuleActionExclude, Labels: []string{"other"}},
+		},
+		mockUpstreamHelper,
+	)
+	require.NoError(t, err)
+
+	// Labels are only fetched once per commit.
+	mockUpstreamHelper.EXPECT().GetCommitPR(ctx, sha).Return(&gh.PullRequest{Labels: []string{"kind/bug"}}, nil)
+
+	d, err := e.Evaluate(ctx, commit)
+	require.NoError(t, err)
+	assert.Equal(t, Decision{Include: true}, d)
+
+	d, err = e.Evaluate(ctx, commit)
+	require.NoError(t, err)
+	assert.Equal(t, Decision{Include: true}, d)
+
+	t.Run("error", func(t *testing.T) {
+		other := &object.Commit{Message: "Other change", Hash: [20]byte{1}}
+
+		mockUpstreamHelper.EXPECT().GetCommitPR(ctx, other.Hash.String()).Return(nil, errors.New("random error"))
+
+		_, err := e.Evaluate(ctx, other)
+		assert.EqualError(t, err, "label: random error")
+	})
+}
+
+func TestNew(t *testing.T) {
+	_, err := New([]config.Rule{{Action: "drop"}}, nil)
+	assert.EqualError(t, err, `rules[0]: "drop": invalid action`)
+
+	_, err = New([]config.Rule{{Action: config.RuleActionExclude, Labels: []string{"some-label"}}}, nil)
+	assert.EqualError(t, err, "rules[0]: labels require an upstream repository on GitHub")
+}
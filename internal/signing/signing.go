@@ -0,0 +1,191 @@
+package signing
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/go-git/go-git/v5"
+	"github.com/rh-ecosystem-edge/gitstream/internal/config"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	FormatGPG = config.SigningFormatGPG
+	FormatSSH = config.SigningFormatSSH
+)
+
+// NewSigner returns a signer for commits using the key configured in cfg, or nil if cfg.Format is empty.
+// If cfg.PassphraseEnv is set, the key is decrypted with the value of that environment variable.
+func NewSigner(cfg config.Signing) (git.Signer, error) {
+	if cfg.Format == "" {
+		return nil, nil
+	}
+
+	if cfg.KeyFile == "" {
+		return nil, errors.New("no key file configured")
+	}
+
+	var passphrase []byte
+
+	if cfg.PassphraseEnv != "" {
+		p, found := os.LookupEnv(cfg.PassphraseEnv)
+		if !found || p == "" {
+			return nil, fmt.Errorf("%s: undefined or empty variable", cfg.PassphraseEnv)
+		}
+
+		passphrase = []byte(p)
+	}
+
+	key, err := os.ReadFile(cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the key file: %v", err)
+	}
+
+	switch format := cfg.Format; format {
+	case FormatGPG:
+		return newGPGSigner(key, passphrase)
+	case FormatSSH:
+		return newSSHSigner(key, passphrase)
+	default:
+		return nil, fmt.Errorf("%q: invalid signing format; valid values are %q and %q", format, FormatGPG, FormatSSH)
+	}
+}
+
+type gpgSigner struct {
+	entity *openpgp.Entity
+}
+
+func newGPGSigner(armoredKey, passphrase []byte) (*gpgSigner, error) {
+	entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(armoredKey))
+	if err != nil {
+		return nil, fmt.Errorf("could not read the armored GPG key: %v", err)
+	}
+
+	if len(entities) != 1 {
+		return nil, fmt.Errorf("expected exactly one GPG key, got %d", len(entities))
+	}
+
+	e := entities[0]
+
+	if e.PrivateKey == nil {
+		return nil, errors.New("the GPG key file does not contain a private key")
+	}
+
+	if e.PrivateKey.Encrypted {
+		if passphrase == nil {
+			return nil, errors.New("the GPG key is encrypted but no passphrase was configured")
+		}
+
+		if err := e.DecryptPrivateKeys(passphrase); err != nil {
+			return nil, fmt.Errorf("could not decrypt the GPG key: %v", err)
+		}
+	}
+
+	return &gpgSigner{entity: e}, nil
+}
+
+func (s *gpgSigner) Sign(message io.Reader) ([]byte, error) {
+	var b bytes.Buffer
+
+	if err := openpgp.ArmoredDetachSign(&b, s.entity, message, nil); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+const (
+	sshSigHashAlgorithm = "sha512"
+	sshSigMagic         = "SSHSIG"
+	sshSigNamespace     = "git"
+	sshSigVersion       = 1
+)
+
+// sshSigner creates SSH signatures in the format described in PROTOCOL.sshsig from OpenSSH, which is what
+// git verify-commit expects when gpg.format is ssh.
+type sshSigner struct {
+	signer ssh.Signer
+}
+
+func newSSHSigner(key, passphrase []byte) (*sshSigner, error) {
+	signer, err := ssh.ParsePrivateKey(key)
+	if err != nil {
+		pme := &ssh.PassphraseMissingError{}
+
+		if !errors.As(err, &pme) {
+			return nil, fmt.Errorf("could not parse the SSH key: %v", err)
+		}
+
+		if passphrase == nil {
+			return nil, errors.New("the SSH key is encrypted but no passphrase was configured")
+		}
+
+		if signer, err = ssh.ParsePrivateKeyWithPassphrase(key, passphrase); err != nil {
+			return nil, fmt.Errorf("could not decrypt the SSH key: %v", err)
+		}
+	}
+
+	return &sshSigner{signer: signer}, nil
+}
+
+func (s *sshSigner) Sign(message io.Reader) ([]byte, error) {
+	h := sha512.New()
+
+	if _, err := io.Copy(h, message); err != nil {
+		return nil, fmt.Errorf("could not hash the message: %v", err)
+	}
+
+	signedData := struct {
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Hash          []byte
+	}{
+		Namespace:     sshSigNamespace,
+		HashAlgorithm: sshSigHashAlgorithm,
+		Hash:          h.Sum(nil),
+	}
+
+	sig, err := s.sign(append([]byte(sshSigMagic), ssh.Marshal(signedData)...))
+	if err != nil {
+		return nil, fmt.Errorf("could not sign: %v", err)
+	}
+
+	blob := struct {
+		Version       uint32
+		PublicKey     []byte
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Signature     []byte
+	}{
+		Version:       sshSigVersion,
+		PublicKey:     s.signer.PublicKey().Marshal(),
+		Namespace:     sshSigNamespace,
+		HashAlgorithm: sshSigHashAlgorithm,
+		Signature:     ssh.Marshal(sig),
+	}
+
+	block := pem.Block{
+		Type:  "SSH SIGNATURE",
+		Bytes: append([]byte(sshSigMagic), ssh.Marshal(blob)...),
+	}
+
+	return pem.EncodeToMemory(&block), nil
+}
+
+// sign signs data, using SHA-512 for RSA keys since OpenSSH does not accept SHA-1 signatures in this format.
+func (s *sshSigner) sign(data []byte) (*ssh.Signature, error) {
+	if as, ok := s.signer.(ssh.AlgorithmSigner); ok && s.signer.PublicKey().Type() == ssh.KeyAlgoRSA {
+		return as.SignWithAlgorithm(rand.Reader, data, ssh.KeyAlgoRSASHA512)
+	}
+
+	return s.signer.Sign(rand.Reader, data)
+}
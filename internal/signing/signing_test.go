@@ -0,0 +1,192 @@
+package signing
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/pem"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/rh-ecosystem-edge/gitstream/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	email         = "unit.tests@example.com"
+	passphrase    = "some-passphrase"
+	passphraseEnv = "GITSTREAM_TEST_PASSPHRASE"
+)
+
+func writeFile(t *testing.T, name string, b []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+
+	require.NoError(t, os.WriteFile(path, b, 0600))
+
+	return path
+}
+
+// signedCommit creates a repository on disk with a single commit signed by signer.
+func signedCommit(t *testing.T, signer git.Signer) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file.txt"), []byte("contents\n"), 0644))
+
+	_, err = wt.Add("file.txt")
+	require.NoError(t, err)
+
+	sig := &object.Signature{Name: "Unit tests", Email: email, When: time.Now()}
+
+	_, err = wt.Commit("Signed commit", &git.CommitOptions{Author: sig, Signer: signer})
+	require.NoError(t, err)
+
+	return dir
+}
+
+func verifyCommit(t *testing.T, dir string, env []string, args ...string) {
+	t.Helper()
+
+	cmd := exec.Command("git", append(args, "verify-commit", "HEAD")...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+
+	out, err := cmd.CombinedOutput()
+	assert.NoError(t, err, string(out))
+}
+
+func TestNewSigner(t *testing.T) {
+	t.Run("no format", func(t *testing.T) {
+		s, err := NewSigner(config.Signing{})
+		assert.NoError(t, err)
+		assert.Nil(t, s)
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		_, err := NewSigner(config.Signing{Format: "x509", KeyFile: writeFile(t, "key", nil)})
+		assert.EqualError(t, err, `"x509": invalid signing format; valid values are "gpg" and "ssh"`)
+	})
+
+	t.Run("missing key file", func(t *testing.T) {
+		_, err := NewSigner(config.Signing{Format: FormatSSH, KeyFile: filepath.Join(t.TempDir(), "missing")})
+		assert.ErrorContains(t, err, "could not read the key file")
+	})
+
+	t.Run("undefined passphrase variable", func(t *testing.T) {
+		_, err := NewSigner(config.Signing{Format: FormatSSH, KeyFile: writeFile(t, "key", nil), PassphraseEnv: passphraseEnv})
+		assert.EqualError(t, err, passphraseEnv+": undefined or empty variable")
+	})
+}
+
+func TestGPGSigner(t *testing.T) {
+	if _, err := exec.LookPath("gpg"); err != nil {
+		t.Skip("gpg is not available")
+	}
+
+	entity, err := openpgp.NewEntity("Unit tests", "", email, nil)
+	require.NoError(t, err)
+
+	var public bytes.Buffer
+
+	w, err := armor.Encode(&public, openpgp.PublicKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.Serialize(w))
+	require.NoError(t, w.Close())
+
+	require.NoError(t, entity.EncryptPrivateKeys([]byte(passphrase), nil))
+
+	var private bytes.Buffer
+
+	w, err = armor.Encode(&private, openpgp.PrivateKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.SerializePrivateWithoutSigning(w, nil))
+	require.NoError(t, w.Close())
+
+	keyFile := writeFile(t, "key.asc", private.Bytes())
+
+	t.Run("missing passphrase", func(t *testing.T) {
+		_, err := NewSigner(config.Signing{Format: FormatGPG, KeyFile: keyFile})
+		assert.EqualError(t, err, "the GPG key is encrypted but no passphrase was configured")
+	})
+
+	t.Run("wrong passphrase", func(t *testing.T) {
+		t.Setenv(passphraseEnv, "wrong")
+
+		_, err := NewSigner(config.Signing{Format: FormatGPG, KeyFile: keyFile, PassphraseEnv: passphraseEnv})
+		assert.ErrorContains(t, err, "could not decrypt the GPG key")
+	})
+
+	t.Run("signature verifies with git", func(t *testing.T) {
+		t.Setenv(passphraseEnv, passphrase)
+
+		s, err := NewSigner(config.Signing{Format: FormatGPG, KeyFile: keyFile, PassphraseEnv: passphraseEnv})
+		require.NoError(t, err)
+
+		dir := signedCommit(t, s)
+
+		home := t.TempDir()
+
+		out, err := exec.Command("gpg", "--homedir", home, "--batch", "--import", writeFile(t, "public.asc", public.Bytes())).CombinedOutput()
+		require.NoError(t, err, string(out))
+
+		verifyCommit(t, dir, []string{"GNUPGHOME=" + home})
+	})
+}
+
+func TestSSHSigner(t *testing.T) {
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen is not available")
+	}
+
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	for name, key := range map[string]crypto.Signer{"ed25519": ed25519Key, "rsa": rsaKey} {
+		t.Run(name, func(t *testing.T) {
+			block, err := ssh.MarshalPrivateKeyWithPassphrase(key, "", []byte(passphrase))
+			require.NoError(t, err)
+
+			keyFile := writeFile(t, "id", pem.EncodeToMemory(block))
+
+			_, err = NewSigner(config.Signing{Format: FormatSSH, KeyFile: keyFile})
+			assert.EqualError(t, err, "the SSH key is encrypted but no passphrase was configured")
+
+			t.Setenv(passphraseEnv, passphrase)
+
+			s, err := NewSigner(config.Signing{Format: FormatSSH, KeyFile: keyFile, PassphraseEnv: passphraseEnv})
+			require.NoError(t, err)
+
+			dir := signedCommit(t, s)
+
+			pub, err := ssh.NewPublicKey(key.Public())
+			require.NoError(t, err)
+
+			allowedSigners := writeFile(t, "allowed_signers", []byte(email+" "+string(ssh.MarshalAuthorizedKey(pub))))
+
+			verifyCommit(t, dir, nil, "-c", "gpg.format=ssh", "-c", "gpg.ssh.allowedSignersFile="+allowedSigners)
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package skiplist
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/rh-ecosystem-edge/gitstream/internal/markup"
+	"gopkg.in/yaml.v3"
+)
+
+// Path is the path of the skip list, relative to the root of the downstream repository.
+const Path = ".gitstream/skipped.yml"
+
+// Entry is an upstream commit that should not be picked.
+type Entry struct {
+	// Expires is the time from which the entry is ignored. The entry never expires if it is nil.
+	Expires *time.Time `yaml:"expires,omitempty"`
+	Reason  string     `yaml:"reason"`
+	// SHA may be abbreviated.
+	SHA string `yaml:"sha"`
+}
+
+// Expired returns true if e is ignored at now.
+func (e Entry) Expired(now time.Time) bool {
+	return e.Expires != nil && !now.Before(*e.Expires)
+}
+
+// List is the content of the skip list.
+type List struct {
+	Commits []Entry `yaml:"commits"`
+}
+
+var shaRegexp = regexp.MustCompile(`^[a-f0-9]+$`)
+
+// NormalizeSHA returns sha in lowercase, or an error if it is not a possibly abbreviated SHA.
+func NormalizeSHA(sha string) (string, error) {
+	s := strings.ToLower(sha)
+
+	if !shaRegexp.MatchString(s) || len(s) < markup.MinAbbrevLength || len(s) > 40 {
+		return "", fmt.Errorf("%q: invalid SHA", sha)
+	}
+
+	return s, nil
+}
+
+func Parse(rd io.Reader) (*List, error) {
+	l := List{}
+
+	dec := yaml.NewDecoder(rd)
+	dec.KnownFields(true)
+
+	if err := dec.Decode(&l); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("could not decode the skip list: %v", err)
+	}
+
+	for i, e := range l.Commits {
+		sha, err := NormalizeSHA(e.SHA)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: %v", i, err)
+		}
+
+		l.Commits[i].SHA = sha
+	}
+
+	return &l, nil
+}
+
+// ReadFile reads the skip list at path. It returns an empty list if path does not exist.
+func ReadFile(path string) (*List, error) {
+	fd, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &List{}, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not open the skip list: %v", err)
+	}
+	defer fd.Close()
+
+	return Parse(fd)
+}
+
+// ReadFromCommit reads the skip list in the tree of commit. It returns an empty list if the tree has no skip list.
+func ReadFromCommit(commit *object.Commit) (*List, error) {
+	f, err := commit.File(Path)
+	if errors.Is(err, object.ErrFileNotFound) {
+		return &List{}, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not get %s in commit %s: %v", Path, commit.Hash, err)
+	}
+
+	rd, err := f.Reader()
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s in commit %s: %v", Path, commit.Hash, err)
+	}
+	defer rd.Close()
+
+	return Parse(rd)
+}
+
+// WriteFile writes l to path, creating the parent directory if needed.
+func (l *List) WriteFile(path string) error {
+	b, err := yaml.Marshal(l)
+	if err != nil {
+		return fmt.Errorf("could not marshal the skip list: %v", err)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("could not create the directory of the skip list: %v", err)
+	}
+
+	if err = os.WriteFile(path, b, 0644); err != nil {
+		return fmt.Errorf("could not write %s: %v", path, err)
+	}
+
+	return nil
+}
+
+// Add adds e to l, replacing any entry with the same SHA.
+func (l *List) Add(e Entry) {
+	for i := range l.Commits {
+		if l.Commits[i].SHA == e.SHA {
+			l.Commits[i] = e
+			return
+		}
+	}
+
+	l.Commits = append(l.Commits, e)
+}
+
+// Remove removes the entry for sha from l. It returns false if l has no entry for sha.
+func (l *List) Remove(sha string) bool {
+	for i := range l.Commits {
+		if l.Commits[i].SHA == sha {
+			l.Commits = append(l.Commits[:i], l.Commits[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
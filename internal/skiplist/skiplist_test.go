@@ -0,0 +1,67 @@
+package skiplist
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		l, err := Parse(strings.NewReader("commits:\n  - sha: ABCD1234\n    reason: some reason\n"))
+		require.NoError(t, err)
+		assert.Equal(t, &List{Commits: []Entry{{Reason: "some reason", SHA: "abcd1234"}}}, l)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		l, err := Parse(strings.NewReader(""))
+		require.NoError(t, err)
+		assert.Empty(t, l.Commits)
+	})
+
+	t.Run("invalid SHA", func(t *testing.T) {
+		_, err := Parse(strings.NewReader("commits:\n  - sha: abc\n"))
+		assert.EqualError(t, err, `entry 0: "abc": invalid SHA`)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		_, err := Parse(strings.NewReader("commits:\n  - sha: abcd\n    comment: abc\n"))
+		assert.ErrorContains(t, err, "field comment not found")
+	})
+}
+
+func TestEntry_Expired(t *testing.T) {
+	expires := time.Date(2022, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.False(t, Entry{}.Expired(expires))
+	assert.False(t, Entry{Expires: &expires}.Expired(expires.Add(-time.Second)))
+	assert.True(t, Entry{Expires: &expires}.Expired(expires))
+}
+
+func TestList_WriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), Path)
+
+	l, err := ReadFile(path)
+	require.NoError(t, err)
+	assert.Empty(t, l.Commits)
+
+	expires := time.Date(2022, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	l.Add(Entry{Reason: "first", SHA: "abcd"})
+	l.Add(Entry{Expires: &expires, Reason: "second", SHA: "ef01"})
+	l.Add(Entry{Reason: "first, updated", SHA: "abcd"})
+
+	require.NoError(t, l.WriteFile(path))
+
+	res, err := ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, l, res)
+
+	assert.True(t, res.Remove("abcd"))
+	assert.False(t, res.Remove("abcd"))
+	assert.Equal(t, []Entry{{Expires: &expires, Reason: "second", SHA: "ef01"}}, res.Commits)
+}
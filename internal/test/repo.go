@@ -6,6 +6,7 @@ import (
 
 	"github.com/go-git/go-billy/v5"
 	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
@@ -37,6 +38,48 @@ func AddEmptyCommit(t *testing.T, repo *git.Repository, msg string) (plumbing.Ha
 	return sha, commit
 }
 
+// AddCommit writes files to the worktree and commits them.
+// A nil value deletes the corresponding file.
+func AddCommit(t *testing.T, repo *git.Repository, fs billy.Filesystem, msg string, files map[string]*string) (plumbing.Hash, *object.Commit) {
+	t.Helper()
+
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+
+	for name, contents := range files {
+		if contents == nil {
+			_, err = wt.Remove(name)
+			require.NoError(t, err)
+
+			continue
+		}
+
+		require.NoError(
+			t,
+			util.WriteFile(fs, name, []byte(*contents), 0644),
+		)
+
+		_, err = wt.Add(name)
+		require.NoError(t, err)
+	}
+
+	co := git.CommitOptions{
+		Author: &object.Signature{
+			Name:  "Unit tests",
+			Email: "unit.tests@example.com",
+			When:  time.Now(),
+		},
+	}
+
+	sha, err := wt.Commit(msg, &co)
+	require.NoError(t, err)
+
+	commit, err := repo.CommitObject(sha)
+	require.NoError(t, err)
+
+	return sha, commit
+}
+
 func NewRepo(t *testing.T) *git.Repository {
 	t.Helper()
 
@@ -0,0 +1,77 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// Debouncer coalesces triggers so that at most one run is in progress at any time.
+// Triggers received while a run is in progress schedule exactly one more run.
+type Debouncer struct {
+	delay   time.Duration
+	logger  logr.Logger
+	pending chan struct{}
+	run     func(ctx context.Context) error
+}
+
+func NewDebouncer(delay time.Duration, run func(ctx context.Context) error, logger logr.Logger) *Debouncer {
+	return &Debouncer{
+		delay:   delay,
+		logger:  logger,
+		pending: make(chan struct{}, 1),
+		run:     run,
+	}
+}
+
+// Trigger schedules a run. It never blocks.
+func (d *Debouncer) Trigger() {
+	select {
+	case d.pending <- struct{}{}:
+	default:
+		d.logger.V(1).Info("Run already pending")
+	}
+}
+
+// Run processes triggers until ctx is done. A run starts once no trigger has been received for the debounce delay.
+func (d *Debouncer) Run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-d.pending:
+		}
+
+		if err := d.wait(ctx); err != nil {
+			return err
+		}
+
+		d.logger.Info("Starting run")
+
+		if err := d.run(ctx); err != nil {
+			d.logger.Error(err, "Run failed")
+			continue
+		}
+
+		d.logger.Info("Run finished")
+	}
+}
+
+func (d *Debouncer) wait(ctx context.Context) error {
+	timer := time.NewTimer(d.delay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-d.pending:
+			d.logger.V(1).Info("Trigger received; resetting the debounce delay")
+
+			timer.Reset(d.delay)
+		case <-timer.C:
+			return nil
+		}
+	}
+}
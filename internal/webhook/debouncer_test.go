@@ -0,0 +1,67 @@
+package webhook
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebouncer(t *testing.T) {
+	const delay = 20 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		running int32
+		runs    int32
+	)
+
+	release := make(chan struct{})
+
+	run := func(context.Context) error {
+		assert.Equal(t, int32(1), atomic.AddInt32(&running, 1), "runs must not overlap")
+		atomic.AddInt32(&runs, 1)
+
+		<-release
+
+		atomic.AddInt32(&running, -1)
+		return nil
+	}
+
+	d := NewDebouncer(delay, run, logr.Discard())
+
+	done := make(chan error)
+
+	go func() {
+		done <- d.Run(ctx)
+	}()
+
+	// A burst of triggers results in a single run.
+	for i := 0; i < 5; i++ {
+		d.Trigger()
+	}
+
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&runs) == 1 }, time.Second, delay/4)
+
+	// Triggers received during a run are coalesced into one more run.
+	for i := 0; i < 5; i++ {
+		d.Trigger()
+	}
+
+	release <- struct{}{}
+
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&runs) == 2 }, time.Second, delay/4)
+
+	release <- struct{}{}
+
+	time.Sleep(5 * delay)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&runs))
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
@@ -0,0 +1,86 @@
+package webhook
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-github/v47/github"
+	gh "github.com/rh-ecosystem-edge/gitstream/internal/github"
+)
+
+// Handler receives GitHub webhooks and calls trigger for each push to the upstream ref.
+type Handler struct {
+	logger   logr.Logger
+	ref      string
+	repoName *gh.RepoName
+	secret   []byte
+	trigger  func()
+}
+
+func NewHandler(secret []byte, repoName *gh.RepoName, branchName string, trigger func(), logger logr.Logger) *Handler {
+	return &Handler{
+		logger:   logger,
+		ref:      "refs/heads/" + branchName,
+		repoName: repoName,
+		secret:   secret,
+		trigger:  trigger,
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	eventType := github.WebHookType(r)
+
+	logger := h.logger.WithValues("event", eventType, "delivery", github.DeliveryID(r))
+
+	payload, err := github.ValidatePayload(r, h.secret)
+	if err != nil {
+		logger.Info("Rejecting webhook", "error", err)
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	event, err := github.ParseWebHook(eventType, payload)
+	if err != nil {
+		logger.Info("Could not parse webhook", "error", err)
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	switch e := event.(type) {
+	case *github.PingEvent:
+		logger.Info("Received ping")
+		fmt.Fprintln(w, "pong")
+	case *github.PushEvent:
+		if !h.isUpstreamPush(e) {
+			logger.V(1).Info("Ignoring push", "repo", e.GetRepo().GetFullName(), "ref", e.GetRef())
+			fmt.Fprintln(w, "ignored")
+			return
+		}
+
+		logger.Info("Upstream push received; scheduling sync", "after", e.GetAfter())
+		h.trigger()
+
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprintln(w, "sync scheduled")
+	default:
+		logger.V(1).Info("Ignoring event")
+		fmt.Fprintln(w, "ignored")
+	}
+}
+
+func (h *Handler) isUpstreamPush(e *github.PushEvent) bool {
+	if e.GetRef() != h.ref || e.GetDeleted() {
+		return false
+	}
+
+	repo := strings.TrimSuffix(h.repoName.Repo, ".git")
+
+	return strings.EqualFold(e.GetRepo().GetFullName(), h.repoName.Owner+"/"+repo)
+}
@@ -0,0 +1,120 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-logr/logr"
+	gh "github.com/rh-ecosystem-edge/gitstream/internal/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	secret := []byte("some-secret")
+
+	var triggered int32
+
+	h := NewHandler(
+		secret,
+		&gh.RepoName{Owner: "owner", Repo: "repo.git"},
+		"main",
+		func() { atomic.AddInt32(&triggered, 1) },
+		logr.Discard(),
+	)
+
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	sign := func(payload []byte) string {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(payload)
+
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	post := func(t *testing.T, event, file string, signature func([]byte) string) *http.Response {
+		t.Helper()
+
+		payload, err := os.ReadFile(filepath.Join("testdata", file))
+		require.NoError(t, err)
+
+		req, err := http.NewRequest(http.MethodPost, srv.URL, bytes.NewReader(payload))
+		require.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-GitHub-Event", event)
+		req.Header.Set("X-GitHub-Delivery", "72d3162e-cc78-11e3-81ab-4c9367dc0958")
+		req.Header.Set("X-Hub-Signature-256", signature(payload))
+
+		res, err := srv.Client().Do(req)
+		require.NoError(t, err)
+
+		t.Cleanup(func() { res.Body.Close() })
+
+		return res
+	}
+
+	t.Run("push to the upstream ref", func(t *testing.T) {
+		atomic.StoreInt32(&triggered, 0)
+
+		res := post(t, "push", "push.json", sign)
+
+		assert.Equal(t, http.StatusAccepted, res.StatusCode)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&triggered))
+	})
+
+	t.Run("push to another branch", func(t *testing.T) {
+		atomic.StoreInt32(&triggered, 0)
+
+		res := post(t, "push", "push_other_branch.json", sign)
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, int32(0), atomic.LoadInt32(&triggered))
+	})
+
+	t.Run("ping", func(t *testing.T) {
+		atomic.StoreInt32(&triggered, 0)
+
+		res := post(t, "ping", "ping.json", sign)
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, int32(0), atomic.LoadInt32(&triggered))
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		atomic.StoreInt32(&triggered, 0)
+
+		res := post(t, "push", "push.json", func([]byte) string {
+			return "sha256=" + hex.EncodeToString(make([]byte, sha256.Size))
+		})
+
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		assert.Equal(t, int32(0), atomic.LoadInt32(&triggered))
+	})
+
+	t.Run("missing signature", func(t *testing.T) {
+		atomic.StoreInt32(&triggered, 0)
+
+		res := post(t, "push", "push.json", func([]byte) string { return "" })
+
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		assert.Equal(t, int32(0), atomic.LoadInt32(&triggered))
+	})
+
+	t.Run("wrong method", func(t *testing.T) {
+		res, err := srv.Client().Get(srv.URL)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusMethodNotAllowed, res.StatusCode)
+	})
+}